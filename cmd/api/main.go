@@ -11,14 +11,23 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/creators-of-happiness/amigo-backend/internal/avatar"
+	"github.com/creators-of-happiness/amigo-backend/internal/catalog"
 	"github.com/creators-of-happiness/amigo-backend/internal/config"
 	"github.com/creators-of-happiness/amigo-backend/internal/db"
+	"github.com/creators-of-happiness/amigo-backend/internal/facereview"
+	"github.com/creators-of-happiness/amigo-backend/internal/handlers/admin"
 	"github.com/creators-of-happiness/amigo-backend/internal/handlers/auth"
 	"github.com/creators-of-happiness/amigo-backend/internal/handlers/health"
 	"github.com/creators-of-happiness/amigo-backend/internal/handlers/meta"
 	"github.com/creators-of-happiness/amigo-backend/internal/handlers/misc"
+	"github.com/creators-of-happiness/amigo-backend/internal/handlers/notification"
+	"github.com/creators-of-happiness/amigo-backend/internal/handlers/photo"
 	"github.com/creators-of-happiness/amigo-backend/internal/handlers/profile"
 	"github.com/creators-of-happiness/amigo-backend/internal/httpserver"
+	"github.com/creators-of-happiness/amigo-backend/internal/media"
+	"github.com/creators-of-happiness/amigo-backend/internal/moderation"
+	"github.com/creators-of-happiness/amigo-backend/internal/storage"
 )
 
 func main() {
@@ -33,6 +42,42 @@ func main() {
 	}
 	defer pool.Close()
 
+	// 업로드 객체 저장소
+	store, err := storage.FromConfig(cfg)
+	if err != nil {
+		log.Fatalf("storage init failed: %v", err)
+	}
+
+	// 이미지 파생본 생성 작업자(업로드 후 비동기)
+	pipe := media.NewPipeline(pool, store)
+	pipe.AllowHosts, pipe.MaxDimension = cfg.MediaFetchHosts, cfg.UploadMaxDimension
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	defer stopWorker()
+	go pipe.Run(workerCtx, 10*time.Second)
+
+	// 아바타 합성 이미지(요청 시 그려 캐시)
+	avatars := avatar.NewRenderer(pool, store)
+	avatars.Source.AllowHosts = cfg.MediaFetchHosts
+
+	// 얼굴 사진 심사(자동 분류기 → 관리자 심사)
+	cls, err := facereview.NewClassifier(cfg.FaceClassifier, cfg.FaceAutoApproveScore, cfg.FaceAutoRejectScore)
+	if err != nil {
+		log.Fatalf("face classifier init failed: %v", err)
+	}
+	reviewer := &facereview.Service{Pool: pool, Classifier: cls, Store: store, GalleryMax: cfg.PhotoMaxCount}
+
+	// meta 응답 캐시(catalog_changed 알림으로 모든 인스턴스가 함께 비움)
+	metaCache := catalog.NewCache(pool)
+	go metaCache.Run(workerCtx)
+	// 시즌 항목 노출 기간 경계마다 버전을 올린다
+	go catalog.WatchWindows(workerCtx, pool, time.Minute)
+
+	// 사용자 입력 금칙어 필터
+	filter, err := moderation.FromConfig(cfg)
+	if err != nil {
+		log.Fatalf("moderation wordlist load failed: %v", err)
+	}
+
 	// 라우터
 	r := gin.New()
 	r.Use(gin.Logger(), gin.Recovery())
@@ -40,12 +85,20 @@ func main() {
 	// 헬스
 	health.Register(r, pool)
 
+	// 로컬 저장소 사용 시 업로드 파일 정적 서빙
+	if local, ok := store.(*storage.Local); ok {
+		r.Static("/media", local.Dir)
+	}
+
 	// API v1
 	v1 := r.Group("/api/v1")
-	misc.Register(v1, pool, cfg.AuthSecret)    // /ping, /dbtime, /me
-	auth.Register(v1, pool, cfg)               // /auth/request-code, /auth/verify
-	meta.Register(v1, pool, cfg.AuthSecret)    // /meta/* (리스트 조회)
-	profile.Register(v1, pool, cfg.AuthSecret) // /me/* (단계별 설정)
+	misc.Register(v1, pool, cfg.AuthSecret)              // /ping, /dbtime, /me
+	auth.Register(v1, pool, cfg, filter)                 // /auth/request-code, /auth/verify
+	meta.Register(v1, pool, cfg.AuthSecret, metaCache)   // /meta/* (리스트 조회)
+	profile.Register(v1, pool, cfg, filter, avatars)     // /me/* (단계별 설정)
+	photo.Register(v1, pool, cfg, store, pipe, reviewer) // /me/photo*, /me/face-uploads, /uploads/:id
+	notification.Register(v1, pool, cfg.AuthSecret)      // /me/notifications*
+	admin.Register(v1, pool, cfg, reviewer)              // /admin/* (ADMIN_USER_IDS 전용)
 
 	// HTTP 서버 + graceful shutdown
 	srv := httpserver.New(":"+cfg.Port, r)
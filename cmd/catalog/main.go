@@ -0,0 +1,152 @@
+// 카탈로그 정의 파일(YAML/JSON) 적재·비교·내보내기.
+//
+//	go run ./cmd/catalog diff   -file db/catalog/catalog.yaml
+//	go run ./cmd/catalog import -file db/catalog/catalog.yaml [-prune] [-actor <admin uuid>]
+//	go run ./cmd/catalog export [-file out.yaml]
+//
+// 행은 자연 키(code, 이름, 규칙 조건, url)로 맞추고 바뀐 것만 쓰므로 같은 파일을 여러 번 적재해도 된다.
+// -prune 이면 파일에 없는 행은 지우지 않고 숨긴다(active=false)
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/catalog"
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+	"github.com/creators-of-happiness/amigo-backend/internal/history"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: catalog <diff|import|export> [flags]")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd := os.Args[1]
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	file := fs.String("file", "", "catalog definition file (.yaml/.yml or .json); export writes to stdout if empty")
+	prune := fs.Bool("prune", false, "disable rows and remove translations that are not in the file")
+	actor := fs.String("actor", "", "admin user id recorded in catalog_audit (import)")
+	format := fs.String("format", "", "yaml or json (default: from the file extension)")
+	_ = fs.Parse(os.Args[2:])
+	if *format == "" {
+		*format = catalog.FormatOf(*file)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	switch cmd {
+	case "diff", "import":
+		if *file == "" {
+			fs.Usage()
+			os.Exit(2)
+		}
+		want := readFile(*file, *format)
+		pool := connect(ctx)
+		defer pool.Close()
+		if cmd == "diff" {
+			have, err := catalog.Export(ctx, pool)
+			if err != nil {
+				log.Fatalf("export: %v", err)
+			}
+			printChanges(catalog.Diff(want, have, *prune))
+			return
+		}
+		runImport(ctx, pool, want, *prune, *actor)
+	case "export":
+		pool := connect(ctx)
+		defer pool.Close()
+		f, err := catalog.Export(ctx, pool)
+		if err != nil {
+			log.Fatalf("export: %v", err)
+		}
+		out := os.Stdout
+		if *file != "" {
+			if out, err = os.Create(*file); err != nil {
+				log.Fatalf("create: %v", err)
+			}
+		}
+		w := bufio.NewWriter(out)
+		if err := catalog.Encode(w, f, *format); err != nil {
+			log.Fatalf("encode: %v", err)
+		}
+		if err := w.Flush(); err != nil {
+			log.Fatalf("write: %v", err)
+		}
+		if out != os.Stdout {
+			if err := out.Close(); err != nil {
+				log.Fatalf("close: %v", err)
+			}
+		}
+	default:
+		usage()
+	}
+}
+
+func readFile(path, format string) catalog.File {
+	r, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("open: %v", err)
+	}
+	defer r.Close()
+	f, err := catalog.Decode(r, format)
+	if err != nil {
+		log.Fatalf("%s: %v", path, err)
+	}
+	return f
+}
+
+func connect(ctx context.Context) *pgxpool.Pool {
+	pool, err := db.NewPool(ctx, db.DSNFromEnv())
+	if err != nil {
+		log.Fatalf("postgres connect failed: %v", err)
+	}
+	return pool
+}
+
+func printChanges(changes []catalog.Change) {
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	log.Printf("%d changes", len(changes))
+}
+
+// 비교와 적용을 한 트랜잭션에서(그 사이 관리자 API 로 바뀐 것과 섞이지 않게 버전 행을 먼저 잠근다)
+func runImport(ctx context.Context, pool *pgxpool.Pool, want catalog.File, prune bool, actorID string) {
+	a := history.Actor{Source: history.SourceSystem}
+	if actorID != "" {
+		a = history.Actor{ID: actorID, Source: history.SourceAdmin}
+	}
+	var changes []catalog.Change
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		if err := history.SetActor(ctx, tx, a); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `SELECT 1 FROM catalog_version FOR UPDATE`); err != nil {
+			return err
+		}
+		have, err := catalog.Export(ctx, tx)
+		if err != nil {
+			return err
+		}
+		changes = catalog.Diff(want, have, prune)
+		return catalog.Apply(ctx, tx, changes)
+	})
+	if err != nil {
+		log.Fatalf("import: %v", err)
+	}
+	printChanges(changes)
+}
@@ -0,0 +1,61 @@
+// 행정구역 CSV 일괄 적재.
+//
+//	go run ./cmd/regions -file regions.csv
+//
+// CSV 머리글: code,name,parent_code[,latitude,longitude] (code 기준 upsert)
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+	"github.com/creators-of-happiness/amigo-backend/internal/region"
+)
+
+func main() {
+	file := flag.String("file", "", "administrative division CSV path")
+	dryRun := flag.Bool("dry-run", false, "validate the file without writing")
+	flag.Parse()
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("open: %v", err)
+	}
+	rows, err := region.ParseCSV(f)
+	f.Close()
+	if err != nil {
+		log.Fatalf("parse: %v", err)
+	}
+	if *dryRun {
+		log.Printf("%d rows ok", len(rows))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	pool, err := db.NewPool(ctx, db.DSNFromEnv())
+	if err != nil {
+		log.Fatalf("postgres connect failed: %v", err)
+	}
+	defer pool.Close()
+
+	var n int
+	err = pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		n, err = region.Load(ctx, tx, rows)
+		return err
+	})
+	if err != nil {
+		log.Fatalf("load: %v", err)
+	}
+	log.Printf("loaded %d regions", n)
+}
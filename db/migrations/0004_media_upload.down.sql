@@ -0,0 +1,13 @@
+ALTER TABLE user_face_upload DROP COLUMN IF EXISTS asset_id;
+
+DROP TABLE IF EXISTS media_upload;
+
+DROP INDEX IF EXISTS uq_media_asset_storage_key;
+
+ALTER TABLE media_asset
+  DROP COLUMN IF EXISTS owner_id,
+  DROP COLUMN IF EXISTS size_bytes,
+  DROP COLUMN IF EXISTS height,
+  DROP COLUMN IF EXISTS width,
+  DROP COLUMN IF EXISTS content_type,
+  DROP COLUMN IF EXISTS storage_key;
@@ -0,0 +1,32 @@
+-- 업로드 이미지: 저장소 객체 키/메타데이터 + 2단계(서명 URL) 업로드 세션
+
+ALTER TABLE media_asset
+  ADD COLUMN storage_key  TEXT,
+  ADD COLUMN content_type TEXT,
+  ADD COLUMN width        INTEGER,
+  ADD COLUMN height       INTEGER,
+  ADD COLUMN size_bytes   BIGINT,
+  ADD COLUMN owner_id     UUID REFERENCES app_users(id) ON DELETE SET NULL;
+
+-- 외부 URL 로만 등록된 기존 에셋은 storage_key 가 NULL
+CREATE UNIQUE INDEX IF NOT EXISTS uq_media_asset_storage_key
+  ON media_asset(storage_key) WHERE storage_key IS NOT NULL;
+
+CREATE TABLE media_upload (
+  id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id      UUID NOT NULL REFERENCES app_users(id) ON DELETE CASCADE,
+  purpose      TEXT NOT NULL,
+  status       TEXT NOT NULL DEFAULT 'pending',
+  asset_id     UUID REFERENCES media_asset(id) ON DELETE SET NULL,
+  expires_at   TIMESTAMPTZ NOT NULL,
+  completed_at TIMESTAMPTZ,
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CONSTRAINT chk_media_upload_status CHECK (status IN ('pending','completed')),
+  CONSTRAINT chk_media_upload_purpose CHECK (purpose IN ('profile_photo'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_media_upload_user ON media_upload(user_id, created_at DESC);
+
+-- 얼굴 사진 업로드 로그에 실제 에셋 연결
+ALTER TABLE user_face_upload
+  ADD COLUMN asset_id UUID REFERENCES media_asset(id) ON DELETE SET NULL;
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS media_asset_variant;
+
+DROP INDEX IF EXISTS idx_media_asset_variant_queue;
+
+ALTER TABLE media_asset DROP CONSTRAINT IF EXISTS chk_media_asset_variant_status;
+
+ALTER TABLE media_asset
+  DROP COLUMN IF EXISTS variant_updated_at,
+  DROP COLUMN IF EXISTS variant_error,
+  DROP COLUMN IF EXISTS variant_attempts,
+  DROP COLUMN IF EXISTS variant_status;
@@ -0,0 +1,28 @@
+-- 이미지 파생본(썸네일/중간/정사각/WebP): 업로드 후 비동기 생성
+
+ALTER TABLE media_asset
+  ADD COLUMN variant_status     TEXT NOT NULL DEFAULT 'pending',
+  ADD COLUMN variant_attempts   INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN variant_error      TEXT,
+  ADD COLUMN variant_updated_at TIMESTAMPTZ;
+
+ALTER TABLE media_asset
+  ADD CONSTRAINT chk_media_asset_variant_status
+  CHECK (variant_status IN ('pending','processing','done','failed'));
+
+-- 작업자 대기열 조회용(대부분 'done' 이므로 부분 인덱스)
+CREATE INDEX IF NOT EXISTS idx_media_asset_variant_queue
+  ON media_asset(created_at) WHERE variant_status IN ('pending','processing');
+
+CREATE TABLE media_asset_variant (
+  asset_id     UUID NOT NULL REFERENCES media_asset(id) ON DELETE CASCADE,
+  name         TEXT NOT NULL,
+  storage_key  TEXT NOT NULL,
+  url          TEXT NOT NULL,
+  content_type TEXT NOT NULL,
+  width        INTEGER NOT NULL,
+  height       INTEGER NOT NULL,
+  size_bytes   BIGINT NOT NULL,
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (asset_id, name)
+);
@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS user_notification;
+
+DROP INDEX IF EXISTS idx_user_face_upload_user;
+DROP INDEX IF EXISTS idx_user_face_upload_queue;
+
+ALTER TABLE user_face_upload DROP CONSTRAINT IF EXISTS chk_user_face_upload_reject_reason;
+ALTER TABLE user_face_upload DROP CONSTRAINT IF EXISTS chk_user_face_upload_review_source;
+ALTER TABLE user_face_upload DROP CONSTRAINT IF EXISTS chk_user_face_upload_status;
+
+ALTER TABLE user_face_upload
+  DROP COLUMN IF EXISTS updated_at,
+  DROP COLUMN IF EXISTS reviewed_at,
+  DROP COLUMN IF EXISTS reviewed_by,
+  DROP COLUMN IF EXISTS review_source,
+  DROP COLUMN IF EXISTS classifier_score,
+  DROP COLUMN IF EXISTS classifier_label,
+  DROP COLUMN IF EXISTS reject_reason;
@@ -0,0 +1,54 @@
+-- 얼굴 사진 심사: uploaded → pending_review → approved | rejected
+
+ALTER TABLE user_face_upload
+  ADD COLUMN reject_reason    TEXT,
+  ADD COLUMN classifier_label TEXT,
+  ADD COLUMN classifier_score DOUBLE PRECISION,
+  ADD COLUMN review_source    TEXT,
+  ADD COLUMN reviewed_by      UUID REFERENCES app_users(id) ON DELETE SET NULL,
+  ADD COLUMN reviewed_at      TIMESTAMPTZ,
+  ADD COLUMN updated_at       TIMESTAMPTZ NOT NULL DEFAULT now();
+
+ALTER TABLE user_face_upload
+  ADD CONSTRAINT chk_user_face_upload_status
+  CHECK (status IN ('uploaded','pending_review','approved','rejected'));
+
+ALTER TABLE user_face_upload
+  ADD CONSTRAINT chk_user_face_upload_review_source
+  CHECK (review_source IS NULL OR review_source IN ('admin','classifier'));
+
+-- 반려는 사유 필수
+ALTER TABLE user_face_upload
+  ADD CONSTRAINT chk_user_face_upload_reject_reason
+  CHECK (status <> 'rejected' OR reject_reason IS NOT NULL);
+
+-- 0004 이전 로그는 asset_id 가 비어 있다: 저장된 URL 로 에셋을 찾는다(지금 쓰는 프로필 사진 우선)
+UPDATE user_face_upload f
+SET asset_id = COALESCE(
+  (SELECT ma.id FROM user_profile up JOIN media_asset ma ON ma.id = up.profile_image_id
+   WHERE up.user_id = f.user_id AND ma.url = f.url),
+  (SELECT ma.id FROM media_asset ma WHERE ma.url = f.url ORDER BY ma.created_at DESC LIMIT 1))
+WHERE f.asset_id IS NULL;
+
+-- 기존 로그의 프로필 사진은 이미 사용 중이므로 승인된 것으로 간주
+UPDATE user_face_upload f
+SET status='approved', review_source='admin', reviewed_at=now()
+FROM user_profile up
+WHERE f.status='uploaded' AND up.user_id=f.user_id AND up.profile_image_id=f.asset_id;
+
+UPDATE user_face_upload SET status='pending_review' WHERE status='uploaded';
+
+CREATE INDEX IF NOT EXISTS idx_user_face_upload_queue ON user_face_upload(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_user_face_upload_user  ON user_face_upload(user_id, created_at DESC);
+
+-- 사용자 알림(심사 결과 등)
+CREATE TABLE user_notification (
+  id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id    UUID NOT NULL REFERENCES app_users(id) ON DELETE CASCADE,
+  kind       TEXT NOT NULL,
+  payload    JSONB NOT NULL DEFAULT '{}'::jsonb,
+  read_at    TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_notification_user ON user_notification(user_id, created_at DESC);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS uq_app_users_nickname_key;
+
+ALTER TABLE app_users
+  DROP COLUMN IF EXISTS nickname_changed_at,
+  DROP COLUMN IF EXISTS nickname_key;
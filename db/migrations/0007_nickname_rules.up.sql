@@ -0,0 +1,20 @@
+-- 닉네임 규칙: 대소문자/전각·반각 무시 유일성 + 변경 쿨다운
+
+ALTER TABLE app_users
+  ADD COLUMN nickname_key        TEXT,
+  ADD COLUMN nickname_changed_at TIMESTAMPTZ;
+
+-- 정규화 키가 겹치는 기존 닉네임은 먼저 가입한 사용자만 유지하고 나머지는 접미사를 붙인다
+WITH ranked AS (
+  SELECT id, row_number() OVER (PARTITION BY lower(normalize(nickname, NFKC)) ORDER BY created_at, id) AS rn
+  FROM app_users
+  WHERE nickname IS NOT NULL
+)
+UPDATE app_users u
+SET nickname = u.nickname || '_' || substr(u.id::text, 1, 4)
+FROM ranked r
+WHERE r.id = u.id AND r.rn > 1;
+
+UPDATE app_users SET nickname_key = lower(normalize(nickname, NFKC)) WHERE nickname IS NOT NULL;
+
+CREATE UNIQUE INDEX uq_app_users_nickname_key ON app_users(nickname_key);
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS trg_user_avatar_history ON user_avatar;
+DROP TRIGGER IF EXISTS trg_user_job_history ON user_job;
+DROP TRIGGER IF EXISTS trg_user_profile_history ON user_profile;
+DROP TRIGGER IF EXISTS trg_app_users_history ON app_users;
+
+DROP FUNCTION IF EXISTS record_profile_history();
+
+DROP TABLE IF EXISTS profile_history;
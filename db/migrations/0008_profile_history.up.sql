@@ -0,0 +1,61 @@
+-- 프로필 변경 이력: app_users / user_profile / user_job / user_avatar 의 모든 컬럼 변경을 트리거로 기록.
+-- 행위자/출처는 같은 트랜잭션에서 set_config('amigo.actor_id'|'amigo.source', ..., true) 로 넘긴다.
+-- 탈퇴 후에도 이력은 남도록 user_id 에 FK 를 두지 않는다.
+
+CREATE TABLE profile_history (
+  id         BIGSERIAL PRIMARY KEY,
+  user_id    UUID NOT NULL,
+  table_name TEXT NOT NULL,
+  op         TEXT NOT NULL CHECK (op IN ('insert','update','delete')),
+  field      TEXT NOT NULL,
+  old_value  JSONB,
+  new_value  JSONB,
+  actor_id   UUID,
+  source     TEXT NOT NULL,
+  changed_at TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp()
+);
+
+CREATE INDEX idx_profile_history_user ON profile_history(user_id, changed_at DESC, id DESC);
+
+-- TG_ARGV: 기록하지 않을 컬럼(타임스탬프, 파생 키 등)
+CREATE OR REPLACE FUNCTION record_profile_history() RETURNS trigger AS $$
+DECLARE
+  o     jsonb := CASE WHEN TG_OP = 'INSERT' THEN '{}'::jsonb ELSE to_jsonb(OLD) END;
+  n     jsonb := CASE WHEN TG_OP = 'DELETE' THEN '{}'::jsonb ELSE to_jsonb(NEW) END;
+  uid   uuid;
+  actor uuid := NULLIF(current_setting('amigo.actor_id', true), '')::uuid;
+  src   text := COALESCE(NULLIF(current_setting('amigo.source', true), ''), 'system');
+  k     text;
+BEGIN
+  IF TG_TABLE_NAME = 'app_users' THEN
+    uid := COALESCE(n->>'id', o->>'id')::uuid;
+  ELSE
+    uid := COALESCE(n->>'user_id', o->>'user_id')::uuid;
+  END IF;
+
+  FOR k IN SELECT jsonb_object_keys(o || n) LOOP
+    CONTINUE WHEN k = ANY(TG_ARGV) OR k IN ('id', 'user_id');
+    IF (o->k) IS DISTINCT FROM (n->k) THEN
+      INSERT INTO profile_history (user_id, table_name, op, field, old_value, new_value, actor_id, source)
+      VALUES (uid, TG_TABLE_NAME, lower(TG_OP), k, o->k, n->k, actor, src);
+    END IF;
+  END LOOP;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_app_users_history
+  AFTER INSERT OR UPDATE OR DELETE ON app_users
+  FOR EACH ROW EXECUTE FUNCTION record_profile_history('created_at', 'updated_at', 'nickname_key', 'nickname_changed_at');
+
+CREATE TRIGGER trg_user_profile_history
+  AFTER INSERT OR UPDATE OR DELETE ON user_profile
+  FOR EACH ROW EXECUTE FUNCTION record_profile_history('created_at', 'updated_at');
+
+CREATE TRIGGER trg_user_job_history
+  AFTER INSERT OR UPDATE OR DELETE ON user_job
+  FOR EACH ROW EXECUTE FUNCTION record_profile_history('created_at');
+
+CREATE TRIGGER trg_user_avatar_history
+  AFTER INSERT OR UPDATE OR DELETE ON user_avatar
+  FOR EACH ROW EXECUTE FUNCTION record_profile_history('selected_at');
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS user_friend_request;
+DROP TABLE IF EXISTS user_friend;
+DROP TABLE IF EXISTS user_privacy;
@@ -0,0 +1,33 @@
+-- 필드별 공개 범위(everyone | friends | nobody). 행이 없으면 컬럼 기본값과 같다
+CREATE TABLE user_privacy (
+  user_id    UUID PRIMARY KEY REFERENCES app_users(id) ON DELETE CASCADE,
+  birthdate  TEXT NOT NULL DEFAULT 'friends',
+  region     TEXT NOT NULL DEFAULT 'everyone',
+  job        TEXT NOT NULL DEFAULT 'everyone',
+  photo      TEXT NOT NULL DEFAULT 'everyone',
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CONSTRAINT chk_user_privacy_level CHECK (
+    birthdate IN ('everyone','friends','nobody') AND
+    region    IN ('everyone','friends','nobody') AND
+    job       IN ('everyone','friends','nobody') AND
+    photo     IN ('everyone','friends','nobody'))
+);
+
+-- 친구 관계. 양쪽 방향으로 한 행씩 저장하며 'friends' 공개 범위 판정에 쓴다
+CREATE TABLE user_friend (
+  user_id    UUID NOT NULL REFERENCES app_users(id) ON DELETE CASCADE,
+  friend_id  UUID NOT NULL REFERENCES app_users(id) ON DELETE CASCADE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_id, friend_id),
+  CONSTRAINT chk_user_friend_self CHECK (user_id <> friend_id)
+);
+
+-- 친구 신청. 받은 사람이 같은 사람에게 신청(수락)하면 user_friend 양방향 두 행으로 바뀌고 신청은 지운다
+CREATE TABLE user_friend_request (
+  from_id    UUID NOT NULL REFERENCES app_users(id) ON DELETE CASCADE,
+  to_id      UUID NOT NULL REFERENCES app_users(id) ON DELETE CASCADE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (from_id, to_id),
+  CONSTRAINT chk_user_friend_request_self CHECK (from_id <> to_id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_friend_request_to ON user_friend_request(to_id, created_at DESC);
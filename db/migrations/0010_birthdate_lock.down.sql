@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS trg_user_profile_birth_lock ON user_profile;
+DROP FUNCTION IF EXISTS lock_birth_date();
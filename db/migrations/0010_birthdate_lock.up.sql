@@ -0,0 +1,17 @@
+-- 생년월일은 처음 설정한 뒤엔 고객지원(amigo.source='admin')으로만 바꿀 수 있다.
+-- 앱은 잠긴 행을 먼저 걸러 409 로 응답하므로 이 트리거는 마지막 방어선이다.
+CREATE OR REPLACE FUNCTION lock_birth_date() RETURNS trigger AS $$
+BEGIN
+  IF OLD.birth_date IS NOT NULL
+     AND NEW.birth_date IS DISTINCT FROM OLD.birth_date
+     AND COALESCE(current_setting('amigo.source', true), '') <> 'admin' THEN
+    RAISE EXCEPTION 'birth_date is locked'
+      USING ERRCODE = 'check_violation', CONSTRAINT = 'chk_user_profile_birth_locked';
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_user_profile_birth_lock
+  BEFORE UPDATE OF birth_date ON user_profile
+  FOR EACH ROW EXECUTE FUNCTION lock_birth_date();
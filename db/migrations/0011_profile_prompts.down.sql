@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS user_prompt;
+DROP TABLE IF EXISTS profile_prompt;
+
+ALTER TABLE user_profile DROP CONSTRAINT IF EXISTS chk_user_profile_bio;
+ALTER TABLE user_profile DROP COLUMN IF EXISTS bio;
@@ -0,0 +1,36 @@
+-- 자기소개 + 질문/답변 섹션
+
+ALTER TABLE user_profile ADD COLUMN bio TEXT;
+
+ALTER TABLE user_profile
+  ADD CONSTRAINT chk_user_profile_bio
+  CHECK (bio IS NULL OR char_length(bio) <= 500);
+
+-- 질문 목록(pref_type 처럼 코드 기준). active=false 면 새로 고를 수 없다
+CREATE TABLE profile_prompt (
+  code       TEXT PRIMARY KEY,
+  text       TEXT NOT NULL,
+  max_length INTEGER NOT NULL DEFAULT 150 CHECK (max_length BETWEEN 1 AND 500),
+  sort_order INTEGER NOT NULL DEFAULT 0,
+  active     BOOLEAN NOT NULL DEFAULT true
+);
+
+-- 사용자 답변. position 은 0부터 화면 표시 순서
+CREATE TABLE user_prompt (
+  user_id     UUID NOT NULL REFERENCES app_users(id) ON DELETE CASCADE,
+  prompt_code TEXT NOT NULL REFERENCES profile_prompt(code),
+  answer      TEXT NOT NULL CHECK (char_length(answer) BETWEEN 1 AND 500),
+  position    SMALLINT NOT NULL CHECK (position >= 0),
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_id, prompt_code),
+  CONSTRAINT uq_user_prompt_position UNIQUE (user_id, position)
+);
+
+INSERT INTO profile_prompt (code, text, max_length, sort_order) VALUES
+  ('weekend',     'My weekend looks like…', 150, 10),
+  ('perfect_day', 'My perfect day is…', 150, 20),
+  ('looking_for', 'I''m looking for…', 150, 30),
+  ('fun_fact',    'A fun fact about me is…', 150, 40),
+  ('travel',      'The best trip I''ve taken was…', 150, 50)
+ON CONFLICT (code) DO NOTHING;
@@ -0,0 +1,8 @@
+DELETE FROM media_upload WHERE purpose='gallery_photo';
+ALTER TABLE media_upload DROP CONSTRAINT IF EXISTS chk_media_upload_purpose;
+ALTER TABLE media_upload
+  ADD CONSTRAINT chk_media_upload_purpose CHECK (purpose IN ('profile_photo'));
+
+ALTER TABLE user_face_upload DROP COLUMN IF EXISTS target;
+
+DROP TABLE IF EXISTS user_photo;
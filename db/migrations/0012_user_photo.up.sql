@@ -0,0 +1,30 @@
+-- 프로필 사진 갤러리. 대표 사진(is_primary)은 user_profile.profile_image_id 와 같게 유지한다(기존 클라이언트 호환)
+
+CREATE TABLE user_photo (
+  id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id    UUID NOT NULL REFERENCES app_users(id) ON DELETE CASCADE,
+  asset_id   UUID NOT NULL REFERENCES media_asset(id) ON DELETE CASCADE,
+  position   SMALLINT NOT NULL CHECK (position >= 0),
+  is_primary BOOLEAN NOT NULL DEFAULT false,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CONSTRAINT uq_user_photo_asset UNIQUE (user_id, asset_id),
+  -- 순서 변경은 한 문장에서 자리를 맞바꾸므로 커밋 시점에 검사
+  CONSTRAINT uq_user_photo_position UNIQUE (user_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE UNIQUE INDEX uq_user_photo_primary ON user_photo(user_id) WHERE is_primary;
+
+-- 승인 시 대표 사진 교체(primary) 인지 갤러리 추가(gallery) 인지
+ALTER TABLE user_face_upload
+  ADD COLUMN target TEXT NOT NULL DEFAULT 'primary'
+  CONSTRAINT chk_user_face_upload_target CHECK (target IN ('primary','gallery'));
+
+-- 2단계 업로드로 갤러리 사진 추가
+ALTER TABLE media_upload DROP CONSTRAINT chk_media_upload_purpose;
+ALTER TABLE media_upload
+  ADD CONSTRAINT chk_media_upload_purpose CHECK (purpose IN ('profile_photo','gallery_photo'));
+
+-- 기존 프로필 사진을 대표 사진으로 이관
+INSERT INTO user_photo (user_id, asset_id, position, is_primary)
+SELECT user_id, profile_image_id, 0, true
+FROM user_profile WHERE profile_image_id IS NOT NULL;
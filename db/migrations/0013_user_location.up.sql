@@ -0,0 +1,14 @@
+-- 사용자 위치. 좌표는 소수 2자리(≈1.1km)로 낮춰 저장하고 지오해시/가까운 지역을 함께 둔다.
+-- updated_at 이 오래된 위치는 주변 검색에서 뺀다(LOCATION_STALE_HOURS)
+CREATE TABLE user_location (
+  user_id    UUID PRIMARY KEY REFERENCES app_users(id) ON DELETE CASCADE,
+  latitude   NUMERIC(9,6) NOT NULL CHECK (latitude BETWEEN -90 AND 90),
+  longitude  NUMERIC(9,6) NOT NULL CHECK (longitude BETWEEN -180 AND 180),
+  geohash    TEXT NOT NULL,
+  region_id  INTEGER REFERENCES region(id) ON DELETE SET NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- 지오해시 접두사 검색 + 최신 위치만 걸러내기
+CREATE INDEX IF NOT EXISTS idx_user_location_geohash ON user_location(geohash text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_user_location_updated ON user_location(updated_at);
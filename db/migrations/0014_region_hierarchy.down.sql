@@ -0,0 +1,14 @@
+-- 하위 지역 제거(사용자 지역은 ON DELETE SET NULL)
+DELETE FROM region WHERE level > 1;
+
+DROP INDEX IF EXISTS idx_region_parent;
+ALTER TABLE region DROP CONSTRAINT IF EXISTS uq_region_parent_name;
+ALTER TABLE region ADD CONSTRAINT uq_region_name UNIQUE (name);
+
+ALTER TABLE region DROP CONSTRAINT IF EXISTS chk_region_parent;
+ALTER TABLE region DROP CONSTRAINT IF EXISTS chk_region_level;
+ALTER TABLE region DROP CONSTRAINT IF EXISTS uq_region_code;
+ALTER TABLE region
+  DROP COLUMN IF EXISTS level,
+  DROP COLUMN IF EXISTS parent_id,
+  DROP COLUMN IF EXISTS code;
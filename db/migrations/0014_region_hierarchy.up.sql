@@ -0,0 +1,23 @@
+-- 지역 계층: 1=시/도, 2=시/군/구, 3=읍/면/동
+-- code 는 행정구역 코드(10자리 법정동코드). 일괄 적재(cmd/regions)는 code 로 upsert 한다
+ALTER TABLE region
+  ADD COLUMN code      TEXT,
+  ADD COLUMN parent_id INTEGER REFERENCES region(id) ON DELETE CASCADE,
+  ADD COLUMN level     SMALLINT NOT NULL DEFAULT 1;
+
+ALTER TABLE region
+  ADD CONSTRAINT uq_region_code UNIQUE (code),
+  ADD CONSTRAINT chk_region_level CHECK (level BETWEEN 1 AND 3),
+  ADD CONSTRAINT chk_region_parent CHECK ((level = 1) = (parent_id IS NULL));
+
+-- 같은 이름의 구/동이 여러 곳에 있으므로 이름은 같은 부모 안에서만 유일
+ALTER TABLE region DROP CONSTRAINT IF EXISTS uq_region_name;
+ALTER TABLE region
+  ADD CONSTRAINT uq_region_parent_name UNIQUE NULLS NOT DISTINCT (parent_id, name);
+
+CREATE INDEX IF NOT EXISTS idx_region_parent ON region(parent_id);
+
+-- 기존 시드는 시/도 코드로 연결(적재 시 같은 행이 갱신되어 region_id 가 유지된다)
+UPDATE region SET code = '1100000000' WHERE name = 'Seoul';
+UPDATE region SET code = '2600000000' WHERE name = 'Busan';
+UPDATE region SET code = '2800000000' WHERE name = 'Incheon';
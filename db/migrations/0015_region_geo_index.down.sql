@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_region_lat_lng;
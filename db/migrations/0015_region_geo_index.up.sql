@@ -0,0 +1,3 @@
+-- 위경도 사각형 범위 검색(가까운 지역/반경 검색)
+CREATE INDEX IF NOT EXISTS idx_region_lat_lng ON region(latitude, longitude)
+  WHERE latitude IS NOT NULL AND longitude IS NOT NULL;
@@ -0,0 +1,41 @@
+-- 카탈로그 이름 번역. entity_key 는 대상 테이블의 키(id 또는 code)를 text 로
+CREATE TABLE translation (
+  entity     TEXT NOT NULL,
+  entity_key TEXT NOT NULL,
+  locale     TEXT NOT NULL,
+  name       TEXT NOT NULL,
+  PRIMARY KEY (entity, entity_key, locale),
+  CONSTRAINT chk_translation_entity CHECK (entity IN
+    ('region','job_category','character_category','character_item','bg_item','pref_item','profile_prompt')),
+  CONSTRAINT chk_translation_locale CHECK (locale ~ '^[a-z]{2}$')
+);
+
+-- 기본 시드 한국어
+INSERT INTO translation (entity, entity_key, locale, name)
+SELECT 'region', id::text, 'ko', v.name
+FROM (VALUES ('1100000000','서울특별시'), ('2600000000','부산광역시'), ('2800000000','인천광역시')) AS v(code, name)
+JOIN region r ON r.code = v.code
+ON CONFLICT DO NOTHING;
+
+INSERT INTO translation (entity, entity_key, locale, name) VALUES
+  ('job_category','dev','ko','개발자'),
+  ('job_category','design','ko','디자이너'),
+  ('job_category','pm','ko','프로덕트 매니저'),
+  ('job_category','sales','ko','영업'),
+  ('character_category','basic','ko','기본'),
+  ('profile_prompt','weekend','ko','나의 주말은…'),
+  ('profile_prompt','perfect_day','ko','나의 완벽한 하루는…'),
+  ('profile_prompt','looking_for','ko','내가 찾는 사람은…'),
+  ('profile_prompt','fun_fact','ko','나에 대한 재미있는 사실…'),
+  ('profile_prompt','travel','ko','가장 좋았던 여행은…')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO translation (entity, entity_key, locale, name)
+SELECT 'character_item', id::text, 'ko', '캐릭터 ' || substring(name FROM '[0-9]+$')
+FROM character_item WHERE category_code='basic' AND name ~ '^Character [0-9]+$'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO translation (entity, entity_key, locale, name)
+SELECT 'bg_item', id::text, 'ko', '배경 ' || substring(name FROM '[0-9]+$')
+FROM bg_item WHERE name ~ '^Background [0-9]+$'
+ON CONFLICT DO NOTHING;
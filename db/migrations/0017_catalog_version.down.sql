@@ -0,0 +1,14 @@
+DROP TRIGGER IF EXISTS trg_media_asset_variant_catalog_version ON media_asset_variant;
+DO $$
+DECLARE
+  t TEXT;
+BEGIN
+  FOREACH t IN ARRAY ARRAY['region','job_category','character_category','character_item','bg_item',
+                           'pref_type','pref_item','profile_prompt','translation'] LOOP
+    EXECUTE format('DROP TRIGGER IF EXISTS trg_%s_catalog_version ON %I', t, t);
+  END LOOP;
+END $$;
+DROP FUNCTION IF EXISTS bump_catalog_version_for_preview();
+DROP FUNCTION IF EXISTS bump_catalog_version();
+DROP FUNCTION IF EXISTS bump_catalog_version_now();
+DROP TABLE IF EXISTS catalog_version;
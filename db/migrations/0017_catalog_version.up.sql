@@ -0,0 +1,58 @@
+-- 카탈로그 버전. 카탈로그 테이블이 바뀔 때마다 1 올리고 catalog_changed 로 알린다.
+-- API 인스턴스는 LISTEN 으로 받아 메모리 캐시를 비운다
+CREATE TABLE catalog_version (
+  id         BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+  version    BIGINT NOT NULL DEFAULT 1,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+INSERT INTO catalog_version DEFAULT VALUES;
+
+-- NOTIFY 는 커밋 시점에 전달되므로 롤백된 변경은 알리지 않는다
+CREATE OR REPLACE FUNCTION bump_catalog_version_now() RETURNS BIGINT AS $$
+DECLARE
+  v BIGINT;
+BEGIN
+  UPDATE catalog_version SET version = version + 1, updated_at = now() RETURNING version INTO v;
+  -- payload: "<version>:<updated_at 유닉스 마이크로초>"
+  PERFORM pg_notify('catalog_changed', v || ':' || floor(extract(epoch FROM now()) * 1000000)::bigint);
+  RETURN v;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION bump_catalog_version() RETURNS trigger AS $$
+BEGIN
+  PERFORM bump_catalog_version_now();
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+DECLARE
+  t TEXT;
+BEGIN
+  FOREACH t IN ARRAY ARRAY['region','job_category','character_category','character_item','bg_item',
+                           'pref_type','pref_item','profile_prompt','translation'] LOOP
+    EXECUTE format('CREATE TRIGGER trg_%s_catalog_version
+                    AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON %I
+                    FOR EACH STATEMENT EXECUTE FUNCTION bump_catalog_version()', t, t);
+  END LOOP;
+END $$;
+
+-- 미리보기 이미지 파생본이 생기면 목록 응답(preview_variants)이 바뀐다.
+-- 사용자 사진 파생본으로는 버전을 올리지 않도록 카탈로그 미리보기인지 행마다 확인
+CREATE OR REPLACE FUNCTION bump_catalog_version_for_preview() RETURNS trigger AS $$
+DECLARE
+  aid UUID;
+BEGIN
+  IF TG_OP = 'DELETE' THEN aid := OLD.asset_id; ELSE aid := NEW.asset_id; END IF;
+  IF EXISTS (SELECT 1 FROM character_item WHERE preview_asset = aid)
+     OR EXISTS (SELECT 1 FROM bg_item WHERE preview_asset = aid) THEN
+    PERFORM bump_catalog_version_now();
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_media_asset_variant_catalog_version
+  AFTER INSERT OR UPDATE OR DELETE ON media_asset_variant
+  FOR EACH ROW EXECUTE FUNCTION bump_catalog_version_for_preview();
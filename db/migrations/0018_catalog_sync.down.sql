@@ -0,0 +1,37 @@
+CREATE OR REPLACE FUNCTION bump_catalog_version_for_preview() RETURNS trigger AS $$
+DECLARE
+  aid UUID;
+BEGIN
+  IF TG_OP = 'DELETE' THEN aid := OLD.asset_id; ELSE aid := NEW.asset_id; END IF;
+  IF EXISTS (SELECT 1 FROM character_item WHERE preview_asset = aid)
+     OR EXISTS (SELECT 1 FROM bg_item WHERE preview_asset = aid) THEN
+    PERFORM bump_catalog_version_now();
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_translation_touch ON translation;
+DROP FUNCTION IF EXISTS touch_translated_row();
+
+DO $$
+DECLARE
+  t TEXT;
+BEGIN
+  FOREACH t IN ARRAY ARRAY['region','job_category','character_category','character_item','bg_item'] LOOP
+    EXECUTE format('DROP TRIGGER IF EXISTS trg_%s_catalog_rev ON %I', t, t);
+    EXECUTE format('DROP TRIGGER IF EXISTS trg_%s_catalog_tombstone ON %I', t, t);
+    EXECUTE format('ALTER TABLE %I DROP COLUMN IF EXISTS catalog_rev', t);
+  END LOOP;
+  FOREACH t IN ARRAY ARRAY['region','job_category','character_category','character_item','bg_item',
+                           'pref_type','pref_item','profile_prompt','translation'] LOOP
+    EXECUTE format('DROP TRIGGER IF EXISTS trg_%s_catalog_version ON %I', t, t);
+    EXECUTE format('CREATE TRIGGER trg_%s_catalog_version
+                    AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON %I
+                    FOR EACH STATEMENT EXECUTE FUNCTION bump_catalog_version()', t, t);
+  END LOOP;
+END $$;
+
+DROP FUNCTION IF EXISTS record_catalog_tombstone();
+DROP FUNCTION IF EXISTS set_catalog_rev();
+DROP TABLE IF EXISTS catalog_tombstone;
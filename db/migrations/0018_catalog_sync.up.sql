@@ -0,0 +1,111 @@
+-- 카탈로그 증분 동기화(GET /meta/catalog?since=)
+-- 행마다 마지막으로 바뀐 catalog_version 을 catalog_rev 에, 지운 행은 catalog_tombstone 에 남긴다.
+-- 버전은 문장 시작(BEFORE STATEMENT) 때 올려 행 잠금을 커밋까지 잡으므로
+-- 동시에 쓰는 트랜잭션은 버전 순서대로 커밋되고, 행에는 그 트랜잭션의 버전이 찍힌다
+
+CREATE TABLE catalog_tombstone (
+  entity      TEXT NOT NULL,
+  entity_key  TEXT NOT NULL,
+  catalog_rev BIGINT NOT NULL,
+  deleted_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (entity, entity_key)
+);
+CREATE INDEX IF NOT EXISTS idx_catalog_tombstone_rev ON catalog_tombstone(catalog_rev);
+
+-- 기존 행은 최초 버전(1)으로 본다
+ALTER TABLE region             ADD COLUMN catalog_rev BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE job_category       ADD COLUMN catalog_rev BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE character_category ADD COLUMN catalog_rev BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE character_item     ADD COLUMN catalog_rev BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE bg_item            ADD COLUMN catalog_rev BIGINT NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS idx_region_catalog_rev             ON region(catalog_rev);
+CREATE INDEX IF NOT EXISTS idx_job_category_catalog_rev       ON job_category(catalog_rev);
+CREATE INDEX IF NOT EXISTS idx_character_category_catalog_rev ON character_category(catalog_rev);
+CREATE INDEX IF NOT EXISTS idx_character_item_catalog_rev     ON character_item(catalog_rev);
+CREATE INDEX IF NOT EXISTS idx_bg_item_catalog_rev            ON bg_item(catalog_rev);
+
+-- 버전 증가를 문장 앞으로 옮긴다
+DO $$
+DECLARE
+  t TEXT;
+BEGIN
+  FOREACH t IN ARRAY ARRAY['region','job_category','character_category','character_item','bg_item',
+                           'pref_type','pref_item','profile_prompt','translation'] LOOP
+    EXECUTE format('DROP TRIGGER IF EXISTS trg_%s_catalog_version ON %I', t, t);
+    EXECUTE format('CREATE TRIGGER trg_%s_catalog_version
+                    BEFORE INSERT OR UPDATE OR DELETE OR TRUNCATE ON %I
+                    FOR EACH STATEMENT EXECUTE FUNCTION bump_catalog_version()', t, t);
+  END LOOP;
+END $$;
+
+-- TG_ARGV: (entity, 키 컬럼)
+CREATE OR REPLACE FUNCTION set_catalog_rev() RETURNS trigger AS $$
+BEGIN
+  NEW.catalog_rev := (SELECT version FROM catalog_version);
+  IF TG_OP = 'INSERT' THEN
+    -- 지웠다가 같은 키로 다시 만든 경우
+    DELETE FROM catalog_tombstone WHERE entity = TG_ARGV[0] AND entity_key = to_jsonb(NEW) ->> TG_ARGV[1];
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION record_catalog_tombstone() RETURNS trigger AS $$
+BEGIN
+  INSERT INTO catalog_tombstone (entity, entity_key, catalog_rev)
+  VALUES (TG_ARGV[0], to_jsonb(OLD) ->> TG_ARGV[1], (SELECT version FROM catalog_version))
+  ON CONFLICT (entity, entity_key) DO UPDATE SET catalog_rev = EXCLUDED.catalog_rev, deleted_at = now();
+  RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+DECLARE
+  t TEXT[];
+BEGIN
+  FOREACH t SLICE 1 IN ARRAY ARRAY[['region','id'],['job_category','code'],['character_category','code'],
+                                   ['character_item','id'],['bg_item','id']] LOOP
+    EXECUTE format('CREATE TRIGGER trg_%s_catalog_rev BEFORE INSERT OR UPDATE ON %I
+                    FOR EACH ROW EXECUTE FUNCTION set_catalog_rev(%L, %L)', t[1], t[1], t[1], t[2]);
+    EXECUTE format('CREATE TRIGGER trg_%s_catalog_tombstone AFTER DELETE ON %I
+                    FOR EACH ROW EXECUTE FUNCTION record_catalog_tombstone(%L, %L)', t[1], t[1], t[1], t[2]);
+  END LOOP;
+END $$;
+
+-- 번역이 바뀌면 대상 행도 바뀐 것으로 표시
+CREATE OR REPLACE FUNCTION touch_translated_row() RETURNS trigger AS $$
+DECLARE
+  r RECORD;
+  keycol TEXT;
+BEGIN
+  IF TG_OP = 'DELETE' THEN r := OLD; ELSE r := NEW; END IF;
+  keycol := CASE r.entity
+    WHEN 'region' THEN 'id' WHEN 'character_item' THEN 'id' WHEN 'bg_item' THEN 'id'
+    WHEN 'job_category' THEN 'code' WHEN 'character_category' THEN 'code' END;
+  IF keycol IS NOT NULL THEN
+    EXECUTE format('UPDATE %I SET catalog_rev = catalog_rev WHERE %I::text = $1', r.entity, keycol) USING r.entity_key;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_translation_touch
+  AFTER INSERT OR UPDATE OR DELETE ON translation
+  FOR EACH ROW EXECUTE FUNCTION touch_translated_row();
+
+-- 미리보기 파생본이 바뀌면 그 캐릭터/배경 행을 바뀐 것으로 표시(버전은 그 UPDATE 가 올린다)
+CREATE OR REPLACE FUNCTION bump_catalog_version_for_preview() RETURNS trigger AS $$
+DECLARE
+  aid UUID;
+BEGIN
+  IF TG_OP = 'DELETE' THEN aid := OLD.asset_id; ELSE aid := NEW.asset_id; END IF;
+  IF EXISTS (SELECT 1 FROM character_item WHERE preview_asset = aid) THEN
+    UPDATE character_item SET catalog_rev = catalog_rev WHERE preview_asset = aid;
+  END IF;
+  IF EXISTS (SELECT 1 FROM bg_item WHERE preview_asset = aid) THEN
+    UPDATE bg_item SET catalog_rev = catalog_rev WHERE preview_asset = aid;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,25 @@
+DROP TRIGGER IF EXISTS trg_media_asset_touch ON media_asset;
+DROP FUNCTION IF EXISTS touch_previewing_items();
+
+DROP TRIGGER IF EXISTS trg_media_asset_catalog_audit ON media_asset;
+
+DO $$
+DECLARE
+  t TEXT;
+BEGIN
+  FOREACH t IN ARRAY ARRAY['region','job_category','character_category','character_item','bg_item',
+                           'pref_type','pref_item'] LOOP
+    EXECUTE format('DROP TRIGGER IF EXISTS trg_%s_catalog_audit ON %I', t, t);
+  END LOOP;
+END $$;
+
+DROP FUNCTION IF EXISTS record_catalog_audit();
+DROP TABLE IF EXISTS catalog_audit;
+
+ALTER TABLE pref_item          DROP COLUMN IF EXISTS active;
+ALTER TABLE pref_type          DROP COLUMN IF EXISTS active;
+ALTER TABLE bg_item            DROP COLUMN IF EXISTS active;
+ALTER TABLE character_item     DROP COLUMN IF EXISTS active;
+ALTER TABLE character_category DROP COLUMN IF EXISTS active;
+ALTER TABLE job_category       DROP COLUMN IF EXISTS active;
+ALTER TABLE region             DROP COLUMN IF EXISTS active;
@@ -0,0 +1,96 @@
+-- 관리자 카탈로그 관리(/admin/catalog).
+-- 지우면 user_avatar/user_job 등의 참조가 깨지므로 active=false 로 숨기고,
+-- 누가 무엇을 바꿨는지 catalog_audit 에 행 단위로 남긴다(행위자는 profile_history 와 같은 amigo.* 설정)
+
+ALTER TABLE region             ADD COLUMN active BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE job_category       ADD COLUMN active BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE character_category ADD COLUMN active BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE character_item     ADD COLUMN active BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE bg_item            ADD COLUMN active BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE pref_type          ADD COLUMN active BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE pref_item          ADD COLUMN active BOOLEAN NOT NULL DEFAULT true;
+
+CREATE TABLE catalog_audit (
+  id         BIGSERIAL PRIMARY KEY,
+  entity     TEXT NOT NULL,
+  entity_key TEXT NOT NULL,
+  action     TEXT NOT NULL CHECK (action IN ('create','update','disable','enable','delete')),
+  before     JSONB,
+  after      JSONB,
+  actor_id   UUID,
+  source     TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp()
+);
+
+CREATE INDEX idx_catalog_audit_entity ON catalog_audit(entity, entity_key, id DESC);
+
+-- TG_ARGV: (키 컬럼, 기록하지 않을 컬럼...). catalog_rev 는 항상 뺀다
+CREATE OR REPLACE FUNCTION record_catalog_audit() RETURNS trigger AS $$
+DECLARE
+  o   jsonb := CASE WHEN TG_OP = 'INSERT' THEN NULL ELSE to_jsonb(OLD) - 'catalog_rev' END;
+  n   jsonb := CASE WHEN TG_OP = 'DELETE' THEN NULL ELSE to_jsonb(NEW) - 'catalog_rev' END;
+  act text;
+BEGIN
+  -- 사용자가 올린 사진은 카탈로그가 아니다
+  IF TG_TABLE_NAME = 'media_asset' AND COALESCE(n, o) ->> 'owner_id' IS NOT NULL THEN
+    RETURN NULL;
+  END IF;
+  FOR i IN 1 .. TG_NARGS - 1 LOOP
+    o := o - TG_ARGV[i];
+    n := n - TG_ARGV[i];
+  END LOOP;
+
+  IF TG_OP = 'INSERT' THEN
+    act := 'create';
+  ELSIF TG_OP = 'DELETE' THEN
+    act := 'delete';
+  ELSIF o = n THEN
+    -- 번역/미리보기 때문에 catalog_rev 만 건드린 경우
+    RETURN NULL;
+  ELSIF (o - 'active') = (n - 'active') THEN
+    act := CASE WHEN (n ->> 'active')::boolean THEN 'enable' ELSE 'disable' END;
+  ELSE
+    act := 'update';
+  END IF;
+
+  INSERT INTO catalog_audit (entity, entity_key, action, before, after, actor_id, source)
+  VALUES (TG_TABLE_NAME, COALESCE(n, o) ->> TG_ARGV[0], act, o, n,
+          NULLIF(current_setting('amigo.actor_id', true), '')::uuid,
+          COALESCE(NULLIF(current_setting('amigo.source', true), ''), 'system'));
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+DECLARE
+  t TEXT[];
+BEGIN
+  FOREACH t SLICE 1 IN ARRAY ARRAY[['region','id'],['job_category','code'],['character_category','code'],
+                                   ['character_item','id'],['bg_item','id'],['pref_type','code'],['pref_item','id']] LOOP
+    EXECUTE format('CREATE TRIGGER trg_%s_catalog_audit AFTER INSERT OR UPDATE OR DELETE ON %I
+                    FOR EACH ROW EXECUTE FUNCTION record_catalog_audit(%L)', t[1], t[1], t[2]);
+  END LOOP;
+END $$;
+
+-- 파생본 작업자가 갱신하는 처리 상태는 기록하지 않는다
+CREATE TRIGGER trg_media_asset_catalog_audit
+  AFTER INSERT OR UPDATE OR DELETE ON media_asset
+  FOR EACH ROW EXECUTE FUNCTION record_catalog_audit('id', 'variant_status', 'variant_attempts', 'variant_error', 'variant_updated_at');
+
+-- 관리자가 미리보기 에셋 URL 을 바꾸면 그 캐릭터/배경 행을 바뀐 것으로 표시
+CREATE OR REPLACE FUNCTION touch_previewing_items() RETURNS trigger AS $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM character_item WHERE preview_asset = NEW.id) THEN
+    UPDATE character_item SET catalog_rev = catalog_rev WHERE preview_asset = NEW.id;
+  END IF;
+  IF EXISTS (SELECT 1 FROM bg_item WHERE preview_asset = NEW.id) THEN
+    UPDATE bg_item SET catalog_rev = catalog_rev WHERE preview_asset = NEW.id;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_media_asset_touch
+  AFTER UPDATE OF url ON media_asset
+  FOR EACH ROW WHEN (OLD.url IS DISTINCT FROM NEW.url)
+  EXECUTE FUNCTION touch_previewing_items();
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_translation_name_trgm;
+DROP INDEX IF EXISTS idx_profile_prompt_text_trgm;
+DROP INDEX IF EXISTS idx_bg_item_name_trgm;
+DROP INDEX IF EXISTS idx_character_item_name_trgm;
+DROP INDEX IF EXISTS idx_character_category_name_trgm;
+DROP INDEX IF EXISTS idx_job_category_name_trgm;
+
+-- 확장은 보통 유지합니다. 꼭 필요할 때만 내리세요.
+-- DROP EXTENSION IF EXISTS pg_trgm;
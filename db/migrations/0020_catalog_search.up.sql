@@ -0,0 +1,9 @@
+-- meta 목록 q 검색(접두어 ILIKE + trigram 유사도). 원본 이름과 모든 언어의 번역 이름을 본다
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_job_category_name_trgm       ON job_category       USING gin (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_character_category_name_trgm ON character_category USING gin (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_character_item_name_trgm     ON character_item     USING gin (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_bg_item_name_trgm            ON bg_item            USING gin (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_profile_prompt_text_trgm     ON profile_prompt     USING gin (text gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_translation_name_trgm        ON translation        USING gin (name gin_trgm_ops);
@@ -0,0 +1,20 @@
+DROP FUNCTION IF EXISTS bump_catalog_version_for_windows();
+
+DO $$
+DECLARE
+  t TEXT;
+BEGIN
+  FOREACH t IN ARRAY ARRAY['character_item','bg_item'] LOOP
+    EXECUTE format('DROP INDEX IF EXISTS idx_%s_tags', t);
+    EXECUTE format('ALTER TABLE %I
+      DROP CONSTRAINT IF EXISTS chk_%s_rarity,
+      DROP CONSTRAINT IF EXISTS chk_%s_window,
+      DROP CONSTRAINT IF EXISTS chk_%s_tags,
+      DROP COLUMN IF EXISTS tags,
+      DROP COLUMN IF EXISTS rarity,
+      DROP COLUMN IF EXISTS sort_weight,
+      DROP COLUMN IF EXISTS available_from,
+      DROP COLUMN IF EXISTS available_until,
+      DROP COLUMN IF EXISTS is_default', t, t, t, t);
+  END LOOP;
+END $$;
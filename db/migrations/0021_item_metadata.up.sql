@@ -0,0 +1,42 @@
+-- 캐릭터/배경 메타데이터: 태그, 희귀도, 정렬 가중치(작을수록 앞), 노출 기간(시즌 항목), 기본 추천 여부.
+-- 기간 밖의 항목은 목록에 나오지 않고 새로 고를 수 없다(이미 고른 사용자는 유지)
+DO $$
+DECLARE
+  t TEXT;
+BEGIN
+  FOREACH t IN ARRAY ARRAY['character_item','bg_item'] LOOP
+    EXECUTE format('ALTER TABLE %I
+      ADD COLUMN tags            TEXT[]      NOT NULL DEFAULT ''{}'',
+      ADD COLUMN rarity          TEXT        NOT NULL DEFAULT ''common'',
+      ADD COLUMN sort_weight     INTEGER     NOT NULL DEFAULT 0,
+      ADD COLUMN available_from  TIMESTAMPTZ,
+      ADD COLUMN available_until TIMESTAMPTZ,
+      ADD COLUMN is_default      BOOLEAN     NOT NULL DEFAULT false,
+      ADD CONSTRAINT chk_%s_rarity CHECK (rarity IN (''common'',''rare'',''epic'',''legendary'')),
+      ADD CONSTRAINT chk_%s_window CHECK (available_until IS NULL OR available_from IS NULL OR available_until > available_from),
+      ADD CONSTRAINT chk_%s_tags   CHECK (cardinality(tags) <= 20)', t, t, t, t);
+    EXECUTE format('CREATE INDEX IF NOT EXISTS idx_%s_tags ON %I USING gin (tags)', t, t);
+  END LOOP;
+END $$;
+
+-- 기간 경계는 행이 바뀌지 않아도 목록을 바꾸므로, 마지막 버전 이후 경계를 지났으면 버전을 올린다
+-- (API 인스턴스가 주기적으로 부른다. 여러 인스턴스가 동시에 불러도 한 번만 올린다)
+CREATE OR REPLACE FUNCTION bump_catalog_version_for_windows() RETURNS BOOLEAN AS $$
+DECLARE
+  since TIMESTAMPTZ;
+BEGIN
+  SELECT updated_at INTO since FROM catalog_version FOR UPDATE;
+  IF EXISTS (
+    SELECT 1 FROM (
+      SELECT available_from AS b FROM character_item WHERE active
+      UNION ALL SELECT available_until FROM character_item WHERE active
+      UNION ALL SELECT available_from FROM bg_item WHERE active
+      UNION ALL SELECT available_until FROM bg_item WHERE active
+    ) w WHERE w.b > since AND w.b <= now()
+  ) THEN
+    PERFORM bump_catalog_version_now();
+    RETURN true;
+  END IF;
+  RETURN false;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS trg_avatar_compat_rule_catalog_audit ON avatar_compat_rule;
+DROP TRIGGER IF EXISTS trg_avatar_compat_rule_catalog_version ON avatar_compat_rule;
+DROP FUNCTION IF EXISTS avatar_bg_compatible(UUID, UUID);
+DROP TABLE IF EXISTS avatar_compat_rule;
+
+ALTER TABLE user_avatar DROP CONSTRAINT IF EXISTS fk_user_avatar_character_category;
+ALTER TABLE character_item DROP CONSTRAINT IF EXISTS uq_character_item_id_category;
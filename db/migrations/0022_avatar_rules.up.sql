@@ -0,0 +1,45 @@
+-- 아바타 분류는 캐릭터에서 정한다: 어긋난 기존 행을 캐릭터 분류로 고치고,
+-- (character_id, category_code) 복합 FK 로 막는다. 관리자가 캐릭터 분류를 바꾸면 함께 따라간다
+UPDATE user_avatar ua SET category_code = ci.category_code
+FROM character_item ci
+WHERE ci.id = ua.character_id AND ua.category_code IS DISTINCT FROM ci.category_code;
+
+ALTER TABLE character_item ADD CONSTRAINT uq_character_item_id_category UNIQUE (id, category_code);
+ALTER TABLE user_avatar ADD CONSTRAINT fk_user_avatar_character_category
+  FOREIGN KEY (character_id, category_code) REFERENCES character_item (id, category_code) ON UPDATE CASCADE;
+
+-- 캐릭터↔배경 호환 규칙. 캐릭터 조건(분류/태그, 비우면 모든 캐릭터)에 맞는 규칙 중
+-- deny 는 bg_tag 를 가진 배경을 막고, allow 가 하나라도 있으면 그중 하나의 bg_tag 를 가진 배경만 허용한다
+CREATE TABLE avatar_compat_rule (
+  id                 SERIAL PRIMARY KEY,
+  character_category TEXT REFERENCES character_category(code),
+  character_tag      TEXT,
+  bg_tag             TEXT NOT NULL,
+  effect             TEXT NOT NULL CHECK (effect IN ('allow','deny')),
+  note               TEXT,
+  active             BOOLEAN NOT NULL DEFAULT true,
+  created_at         TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE OR REPLACE FUNCTION avatar_bg_compatible(cid UUID, bid UUID) RETURNS BOOLEAN AS $$
+  WITH r AS (
+    SELECT r.effect, r.bg_tag = ANY (b.tags) AS hit
+    FROM avatar_compat_rule r
+    JOIN character_item c ON c.id = cid
+    JOIN bg_item b ON b.id = bid
+    WHERE r.active
+      AND (r.character_category IS NULL OR r.character_category = c.category_code)
+      AND (r.character_tag IS NULL OR r.character_tag = ANY (c.tags))
+  )
+  SELECT NOT EXISTS (SELECT 1 FROM r WHERE effect = 'deny' AND hit)
+     AND (NOT EXISTS (SELECT 1 FROM r WHERE effect = 'allow') OR EXISTS (SELECT 1 FROM r WHERE effect = 'allow' AND hit))
+$$ LANGUAGE sql STABLE;
+
+-- 규칙이 바뀌면 /meta/backgrounds?character= 응답이 바뀐다
+CREATE TRIGGER trg_avatar_compat_rule_catalog_version
+  AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON avatar_compat_rule
+  FOR EACH STATEMENT EXECUTE FUNCTION bump_catalog_version();
+
+CREATE TRIGGER trg_avatar_compat_rule_catalog_audit
+  AFTER INSERT OR UPDATE OR DELETE ON avatar_compat_rule
+  FOR EACH ROW EXECUTE FUNCTION record_catalog_audit('id');
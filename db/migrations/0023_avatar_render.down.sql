@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS trg_user_avatar_drop_renders_on_delete ON user_avatar;
+DROP TRIGGER IF EXISTS trg_user_avatar_drop_renders ON user_avatar;
+DROP FUNCTION IF EXISTS drop_avatar_renders();
+
+DELETE FROM media_asset WHERE id IN (SELECT asset_id FROM avatar_render);
+DROP TABLE IF EXISTS avatar_render;
@@ -0,0 +1,32 @@
+-- 서버에서 합성한 아바타 이미지(배경 위에 캐릭터). 크기/형식별로 하나씩 media_asset 으로 저장하고,
+-- source 는 합성 입력(미리보기 URL, 크기, 형식)의 해시라 미리보기가 바뀌면 다시 그린다
+CREATE TABLE avatar_render (
+  user_id    UUID NOT NULL REFERENCES user_avatar(user_id) ON DELETE CASCADE,
+  size       INTEGER NOT NULL,
+  format     TEXT NOT NULL CHECK (format IN ('png','webp')),
+  source     TEXT NOT NULL,
+  asset_id   UUID NOT NULL REFERENCES media_asset(id) ON DELETE CASCADE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_id, size, format)
+);
+
+-- 아바타를 바꾸거나 지우면 합성본 에셋을 지운다(avatar_render 는 FK 로 함께 지워진다).
+-- 삭제는 FK 연쇄보다 먼저 돌도록 BEFORE 트리거
+CREATE OR REPLACE FUNCTION drop_avatar_renders() RETURNS trigger AS $$
+BEGIN
+  DELETE FROM media_asset WHERE id IN (SELECT asset_id FROM avatar_render WHERE user_id = OLD.user_id);
+  IF TG_OP = 'DELETE' THEN
+    RETURN OLD;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_user_avatar_drop_renders
+  AFTER UPDATE ON user_avatar
+  FOR EACH ROW WHEN (OLD.character_id IS DISTINCT FROM NEW.character_id OR OLD.bg_id IS DISTINCT FROM NEW.bg_id)
+  EXECUTE FUNCTION drop_avatar_renders();
+
+CREATE TRIGGER trg_user_avatar_drop_renders_on_delete
+  BEFORE DELETE ON user_avatar
+  FOR EACH ROW EXECUTE FUNCTION drop_avatar_renders();
@@ -0,0 +1,81 @@
+CREATE OR REPLACE FUNCTION bump_catalog_version_for_windows() RETURNS BOOLEAN AS $$
+DECLARE
+  since TIMESTAMPTZ;
+BEGIN
+  SELECT updated_at INTO since FROM catalog_version FOR UPDATE;
+  IF EXISTS (
+    SELECT 1 FROM (
+      SELECT available_from AS b FROM character_item WHERE active
+      UNION ALL SELECT available_until FROM character_item WHERE active
+      UNION ALL SELECT available_from FROM bg_item WHERE active
+      UNION ALL SELECT available_until FROM bg_item WHERE active
+    ) w WHERE w.b > since AND w.b <= now()
+  ) THEN
+    PERFORM bump_catalog_version_now();
+    RETURN true;
+  END IF;
+  RETURN false;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION touch_previewing_items() RETURNS trigger AS $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM character_item WHERE preview_asset = NEW.id) THEN
+    UPDATE character_item SET catalog_rev = catalog_rev WHERE preview_asset = NEW.id;
+  END IF;
+  IF EXISTS (SELECT 1 FROM bg_item WHERE preview_asset = NEW.id) THEN
+    UPDATE bg_item SET catalog_rev = catalog_rev WHERE preview_asset = NEW.id;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION bump_catalog_version_for_preview() RETURNS trigger AS $$
+DECLARE
+  aid UUID;
+BEGIN
+  IF TG_OP = 'DELETE' THEN aid := OLD.asset_id; ELSE aid := NEW.asset_id; END IF;
+  IF EXISTS (SELECT 1 FROM character_item WHERE preview_asset = aid) THEN
+    UPDATE character_item SET catalog_rev = catalog_rev WHERE preview_asset = aid;
+  END IF;
+  IF EXISTS (SELECT 1 FROM bg_item WHERE preview_asset = aid) THEN
+    UPDATE bg_item SET catalog_rev = catalog_rev WHERE preview_asset = aid;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION touch_translated_row() RETURNS trigger AS $$
+DECLARE
+  r RECORD;
+  keycol TEXT;
+BEGIN
+  IF TG_OP = 'DELETE' THEN r := OLD; ELSE r := NEW; END IF;
+  keycol := CASE r.entity
+    WHEN 'region' THEN 'id' WHEN 'character_item' THEN 'id' WHEN 'bg_item' THEN 'id'
+    WHEN 'job_category' THEN 'code' WHEN 'character_category' THEN 'code' END;
+  IF keycol IS NOT NULL THEN
+    EXECUTE format('UPDATE %I SET catalog_rev = catalog_rev WHERE %I::text = $1', r.entity, keycol) USING r.entity_key;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DELETE FROM translation WHERE entity = 'avatar_part';
+ALTER TABLE translation DROP CONSTRAINT chk_translation_entity;
+ALTER TABLE translation ADD CONSTRAINT chk_translation_entity CHECK (entity IN
+  ('region','job_category','character_category','character_item','bg_item','pref_item','profile_prompt'));
+
+DROP TRIGGER IF EXISTS trg_user_avatar_drop_renders ON user_avatar;
+CREATE TRIGGER trg_user_avatar_drop_renders
+  AFTER UPDATE ON user_avatar
+  FOR EACH ROW WHEN (OLD.character_id IS DISTINCT FROM NEW.character_id OR OLD.bg_id IS DISTINCT FROM NEW.bg_id)
+  EXECUTE FUNCTION drop_avatar_renders();
+
+ALTER TABLE user_avatar
+  DROP COLUMN IF EXISTS accessory_id,
+  DROP COLUMN IF EXISTS expression_id,
+  DROP COLUMN IF EXISTS outfit_id;
+
+DROP TABLE IF EXISTS avatar_part;
+DROP TABLE IF EXISTS avatar_slot;
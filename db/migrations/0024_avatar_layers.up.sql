@@ -0,0 +1,145 @@
+-- 레이어 아바타. 슬롯마다 카탈로그 항목 하나를 고르고 z_order 가 낮은 슬롯부터 그린다.
+-- base(캐릭터)/background(배경)는 기존 user_avatar.character_id/bg_id 이므로 기존 아바타는 그대로
+-- base·background 슬롯이 되고, 나머지 슬롯은 avatar_part 에서 고른다(비워 둘 수 있다)
+CREATE TABLE avatar_slot (
+  code     TEXT PRIMARY KEY,
+  z_order  INTEGER NOT NULL UNIQUE,
+  required BOOLEAN NOT NULL DEFAULT false
+);
+INSERT INTO avatar_slot (code, z_order, required) VALUES
+  ('background', 0,  true),
+  ('base',       10, true),
+  ('outfit',     20, false),
+  ('expression', 30, false),
+  ('accessory',  40, false);
+
+-- 옷/표정/장신구. anchor_x/anchor_y 는 캔버스(0~1) 안에서 항목 중심이 놓일 위치,
+-- scale 은 캔버스 한 변 대비 항목 긴 변의 비율
+CREATE TABLE avatar_part (
+  id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  slot_code       TEXT NOT NULL REFERENCES avatar_slot(code),
+  name            TEXT NOT NULL,
+  preview_asset   UUID REFERENCES media_asset(id) ON DELETE SET NULL,
+  anchor_x        REAL NOT NULL DEFAULT 0.5,
+  anchor_y        REAL NOT NULL DEFAULT 0.5,
+  scale           REAL NOT NULL DEFAULT 1,
+  tags            TEXT[]      NOT NULL DEFAULT '{}',
+  rarity          TEXT        NOT NULL DEFAULT 'common',
+  sort_weight     INTEGER     NOT NULL DEFAULT 0,
+  available_from  TIMESTAMPTZ,
+  available_until TIMESTAMPTZ,
+  is_default      BOOLEAN     NOT NULL DEFAULT false,
+  active          BOOLEAN     NOT NULL DEFAULT true,
+  catalog_rev     BIGINT      NOT NULL DEFAULT 1,
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CONSTRAINT uq_avatar_part_slot_name UNIQUE (slot_code, name),
+  CONSTRAINT chk_avatar_part_slot   CHECK (slot_code NOT IN ('base','background')),
+  CONSTRAINT chk_avatar_part_anchor CHECK (anchor_x BETWEEN 0 AND 1 AND anchor_y BETWEEN 0 AND 1),
+  CONSTRAINT chk_avatar_part_scale  CHECK (scale > 0 AND scale <= 1),
+  CONSTRAINT chk_avatar_part_rarity CHECK (rarity IN ('common','rare','epic','legendary')),
+  CONSTRAINT chk_avatar_part_window CHECK (available_until IS NULL OR available_from IS NULL OR available_until > available_from),
+  CONSTRAINT chk_avatar_part_tags   CHECK (cardinality(tags) <= 20)
+);
+CREATE INDEX IF NOT EXISTS idx_avatar_part_tags ON avatar_part USING gin (tags);
+CREATE INDEX IF NOT EXISTS idx_avatar_part_catalog_rev ON avatar_part(catalog_rev);
+CREATE INDEX IF NOT EXISTS idx_avatar_part_name_trgm ON avatar_part USING gin (name gin_trgm_ops);
+
+CREATE TRIGGER trg_avatar_part_catalog_version
+  BEFORE INSERT OR UPDATE OR DELETE OR TRUNCATE ON avatar_part
+  FOR EACH STATEMENT EXECUTE FUNCTION bump_catalog_version();
+CREATE TRIGGER trg_avatar_part_catalog_rev BEFORE INSERT OR UPDATE ON avatar_part
+  FOR EACH ROW EXECUTE FUNCTION set_catalog_rev('avatar_part', 'id');
+CREATE TRIGGER trg_avatar_part_catalog_tombstone AFTER DELETE ON avatar_part
+  FOR EACH ROW EXECUTE FUNCTION record_catalog_tombstone('avatar_part', 'id');
+CREATE TRIGGER trg_avatar_part_catalog_audit AFTER INSERT OR UPDATE OR DELETE ON avatar_part
+  FOR EACH ROW EXECUTE FUNCTION record_catalog_audit('id');
+
+-- 슬롯 선택. 항목의 슬롯은 관리자 API 에서 바꿀 수 없으므로 열 이름으로 슬롯이 정해진다
+ALTER TABLE user_avatar
+  ADD COLUMN outfit_id     UUID REFERENCES avatar_part(id),
+  ADD COLUMN expression_id UUID REFERENCES avatar_part(id),
+  ADD COLUMN accessory_id  UUID REFERENCES avatar_part(id);
+
+-- 합성본은 어느 슬롯이 바뀌어도 다시 그린다
+DROP TRIGGER IF EXISTS trg_user_avatar_drop_renders ON user_avatar;
+CREATE TRIGGER trg_user_avatar_drop_renders
+  AFTER UPDATE ON user_avatar
+  FOR EACH ROW WHEN ((OLD.character_id, OLD.bg_id, OLD.outfit_id, OLD.expression_id, OLD.accessory_id)
+                     IS DISTINCT FROM (NEW.character_id, NEW.bg_id, NEW.outfit_id, NEW.expression_id, NEW.accessory_id))
+  EXECUTE FUNCTION drop_avatar_renders();
+
+-- 번역/미리보기/노출 기간을 캐릭터·배경과 똑같이 따라가도록 avatar_part 추가
+ALTER TABLE translation DROP CONSTRAINT chk_translation_entity;
+ALTER TABLE translation ADD CONSTRAINT chk_translation_entity CHECK (entity IN
+  ('region','job_category','character_category','character_item','bg_item','pref_item','profile_prompt','avatar_part'));
+
+CREATE OR REPLACE FUNCTION touch_translated_row() RETURNS trigger AS $$
+DECLARE
+  r RECORD;
+  keycol TEXT;
+BEGIN
+  IF TG_OP = 'DELETE' THEN r := OLD; ELSE r := NEW; END IF;
+  keycol := CASE r.entity
+    WHEN 'region' THEN 'id' WHEN 'character_item' THEN 'id' WHEN 'bg_item' THEN 'id' WHEN 'avatar_part' THEN 'id'
+    WHEN 'job_category' THEN 'code' WHEN 'character_category' THEN 'code' END;
+  IF keycol IS NOT NULL THEN
+    EXECUTE format('UPDATE %I SET catalog_rev = catalog_rev WHERE %I::text = $1', r.entity, keycol) USING r.entity_key;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION bump_catalog_version_for_preview() RETURNS trigger AS $$
+DECLARE
+  aid UUID;
+BEGIN
+  IF TG_OP = 'DELETE' THEN aid := OLD.asset_id; ELSE aid := NEW.asset_id; END IF;
+  IF EXISTS (SELECT 1 FROM character_item WHERE preview_asset = aid) THEN
+    UPDATE character_item SET catalog_rev = catalog_rev WHERE preview_asset = aid;
+  END IF;
+  IF EXISTS (SELECT 1 FROM bg_item WHERE preview_asset = aid) THEN
+    UPDATE bg_item SET catalog_rev = catalog_rev WHERE preview_asset = aid;
+  END IF;
+  IF EXISTS (SELECT 1 FROM avatar_part WHERE preview_asset = aid) THEN
+    UPDATE avatar_part SET catalog_rev = catalog_rev WHERE preview_asset = aid;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION touch_previewing_items() RETURNS trigger AS $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM character_item WHERE preview_asset = NEW.id) THEN
+    UPDATE character_item SET catalog_rev = catalog_rev WHERE preview_asset = NEW.id;
+  END IF;
+  IF EXISTS (SELECT 1 FROM bg_item WHERE preview_asset = NEW.id) THEN
+    UPDATE bg_item SET catalog_rev = catalog_rev WHERE preview_asset = NEW.id;
+  END IF;
+  IF EXISTS (SELECT 1 FROM avatar_part WHERE preview_asset = NEW.id) THEN
+    UPDATE avatar_part SET catalog_rev = catalog_rev WHERE preview_asset = NEW.id;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION bump_catalog_version_for_windows() RETURNS BOOLEAN AS $$
+DECLARE
+  since TIMESTAMPTZ;
+BEGIN
+  SELECT updated_at INTO since FROM catalog_version FOR UPDATE;
+  IF EXISTS (
+    SELECT 1 FROM (
+      SELECT available_from AS b FROM character_item WHERE active
+      UNION ALL SELECT available_until FROM character_item WHERE active
+      UNION ALL SELECT available_from FROM bg_item WHERE active
+      UNION ALL SELECT available_until FROM bg_item WHERE active
+      UNION ALL SELECT available_from FROM avatar_part WHERE active
+      UNION ALL SELECT available_until FROM avatar_part WHERE active
+    ) w WHERE w.b > since AND w.b <= now()
+  ) THEN
+    PERFORM bump_catalog_version_now();
+    RETURN true;
+  END IF;
+  RETURN false;
+END;
+$$ LANGUAGE plpgsql;
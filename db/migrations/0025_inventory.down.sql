@@ -0,0 +1,18 @@
+DROP TRIGGER IF EXISTS trg_avatar_part_drop_inventory ON avatar_part;
+DROP TRIGGER IF EXISTS trg_bg_item_drop_inventory ON bg_item;
+DROP TRIGGER IF EXISTS trg_character_item_drop_inventory ON character_item;
+DROP FUNCTION IF EXISTS drop_inventory_item();
+DROP TABLE IF EXISTS user_inventory;
+
+DO $$
+DECLARE
+  t TEXT;
+BEGIN
+  FOREACH t IN ARRAY ARRAY['character_item','bg_item','avatar_part'] LOOP
+    EXECUTE format('ALTER TABLE %I
+      DROP CONSTRAINT IF EXISTS chk_%s_unlock_locked,
+      DROP CONSTRAINT IF EXISTS chk_%s_unlock_source,
+      DROP COLUMN IF EXISTS unlock_source,
+      DROP COLUMN IF EXISTS locked', t, t, t);
+  END LOOP;
+END $$;
@@ -0,0 +1,42 @@
+-- 잠금 항목과 사용자 보유 목록. locked 인 캐릭터/배경/파트는 user_inventory 에 있어야 고를 수 있다
+-- (이미 그것을 쓰고 있는 사용자는 유지). unlock_source 는 얻는 경로이고,
+-- onboarding 항목은 온보딩을 마치면 자동으로 지급된다
+DO $$
+DECLARE
+  t TEXT;
+BEGIN
+  FOREACH t IN ARRAY ARRAY['character_item','bg_item','avatar_part'] LOOP
+    EXECUTE format('ALTER TABLE %I
+      ADD COLUMN locked        BOOLEAN NOT NULL DEFAULT false,
+      ADD COLUMN unlock_source TEXT,
+      ADD CONSTRAINT chk_%s_unlock_source CHECK (unlock_source IN (''onboarding'',''achievement'',''purchase'',''admin'')),
+      ADD CONSTRAINT chk_%s_unlock_locked CHECK (unlock_source IS NULL OR locked)', t, t, t);
+  END LOOP;
+END $$;
+
+-- 항목 테이블이 셋이라 (entity, item_id) 로 가리키고, 항목이 지워지면 트리거로 함께 지운다
+CREATE TABLE user_inventory (
+  user_id     UUID NOT NULL REFERENCES app_users(id) ON DELETE CASCADE,
+  entity      TEXT NOT NULL CHECK (entity IN ('character_item','bg_item','avatar_part')),
+  item_id     UUID NOT NULL,
+  source      TEXT NOT NULL CHECK (source IN ('onboarding','achievement','purchase','admin')),
+  source_ref  TEXT,                                    -- 업적 코드, 구매 id 등
+  granted_by  UUID,                                    -- 지급한 관리자
+  acquired_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_id, entity, item_id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_inventory_item ON user_inventory(entity, item_id);
+
+CREATE OR REPLACE FUNCTION drop_inventory_item() RETURNS trigger AS $$
+BEGIN
+  DELETE FROM user_inventory WHERE entity = TG_ARGV[0] AND item_id = OLD.id;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_character_item_drop_inventory AFTER DELETE ON character_item
+  FOR EACH ROW EXECUTE FUNCTION drop_inventory_item('character_item');
+CREATE TRIGGER trg_bg_item_drop_inventory AFTER DELETE ON bg_item
+  FOR EACH ROW EXECUTE FUNCTION drop_inventory_item('bg_item');
+CREATE TRIGGER trg_avatar_part_drop_inventory AFTER DELETE ON avatar_part
+  FOR EACH ROW EXECUTE FUNCTION drop_inventory_item('avatar_part');
@@ -0,0 +1,18 @@
+DROP TABLE IF EXISTS wallet_entry;
+DROP TABLE IF EXISTS wallet_txn;
+DROP TABLE IF EXISTS wallet_account;
+DROP FUNCTION IF EXISTS wallet_append_only();
+DROP FUNCTION IF EXISTS wallet_check_balanced();
+DROP FUNCTION IF EXISTS wallet_apply_entry();
+
+DO $$
+DECLARE
+  t TEXT;
+BEGIN
+  FOREACH t IN ARRAY ARRAY['character_item','bg_item','avatar_part'] LOOP
+    EXECUTE format('ALTER TABLE %I
+      DROP CONSTRAINT IF EXISTS chk_%s_price_purchase,
+      DROP CONSTRAINT IF EXISTS chk_%s_price,
+      DROP COLUMN IF EXISTS price', t, t, t);
+  END LOOP;
+END $$;
@@ -0,0 +1,103 @@
+-- 코인 지갑. 잔액은 wallet_account 에 두고, 모든 변동은 바뀌지 않는 복식 원장(wallet_entry)으로 남긴다.
+-- 거래(wallet_txn) 하나의 분개 합은 항상 0 이다: 사용자 계정이 빠지면 시스템 계정이 같은 만큼 늘어난다
+
+-- 구매로 얻는 항목의 가격(코인). unlock_source='purchase' 인 잠긴 항목만 팔 수 있다
+DO $$
+DECLARE
+  t TEXT;
+BEGIN
+  FOREACH t IN ARRAY ARRAY['character_item','bg_item','avatar_part'] LOOP
+    EXECUTE format('ALTER TABLE %I
+      ADD COLUMN price INTEGER,
+      ADD CONSTRAINT chk_%s_price CHECK (price BETWEEN 1 AND 1000000),
+      ADD CONSTRAINT chk_%s_price_purchase CHECK (price IS NULL OR unlock_source = ''purchase'')', t, t, t);
+  END LOOP;
+END $$;
+
+-- 계정. 사용자 계정은 처음 쓸 때 만들고 잔액이 음수가 될 수 없다.
+-- 시스템 계정: issuance(관리자 지급의 출처, 음수로 쌓인다), revenue(구매 대금)
+CREATE TABLE wallet_account (
+  id         BIGSERIAL PRIMARY KEY,
+  user_id    UUID UNIQUE REFERENCES app_users(id) ON DELETE SET NULL, -- 탈퇴해도 원장은 남긴다
+  code       TEXT UNIQUE,                                            -- 시스템 계정
+  balance    BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CONSTRAINT chk_wallet_account_kind CHECK (user_id IS NULL OR code IS NULL),
+  CONSTRAINT chk_wallet_account_balance CHECK (code IS NOT NULL OR balance >= 0)
+);
+INSERT INTO wallet_account (code) VALUES ('issuance'), ('revenue');
+
+-- 거래. 구매와 지급은 종류별로 (user_id, request_id) 당 한 번만 처리되고, 환불은 구매 하나에 한 번
+CREATE TABLE wallet_txn (
+  id         BIGSERIAL PRIMARY KEY,
+  user_id    UUID NOT NULL,                                        -- 탈퇴 뒤에도 남도록 FK 없음
+  kind       TEXT NOT NULL CHECK (kind IN ('purchase','grant','refund')),
+  amount     BIGINT NOT NULL CHECK (amount > 0),                   -- 사용자 계정 변동의 크기
+  request_id TEXT,                                                 -- 클라이언트/관리자 요청 id
+  entity     TEXT CHECK (entity IN ('character_item','bg_item','avatar_part')),
+  item_id    UUID,                                                 -- 구매(와 그 환불)한 항목
+  refund_of  BIGINT UNIQUE REFERENCES wallet_txn(id),
+  reason     TEXT,
+  actor_id   UUID,                                                 -- 지급/환불한 관리자
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CONSTRAINT uq_wallet_txn_request UNIQUE (user_id, kind, request_id), -- 사용자 구매와 관리자 지급의 id 는 서로 겹쳐도 된다
+  CONSTRAINT chk_wallet_txn_item CHECK ((item_id IS NULL) = (entity IS NULL) AND (kind <> 'purchase' OR item_id IS NOT NULL)),
+  CONSTRAINT chk_wallet_txn_refund CHECK ((kind = 'refund') = (refund_of IS NOT NULL))
+);
+CREATE INDEX IF NOT EXISTS idx_wallet_txn_user ON wallet_txn(user_id, id DESC);
+
+-- 분개. amount 는 계정 잔액 변동(+ 입금, - 출금)이고 balance_after 는 반영 뒤 잔액
+CREATE TABLE wallet_entry (
+  id            BIGSERIAL PRIMARY KEY,
+  txn_id        BIGINT NOT NULL REFERENCES wallet_txn(id),
+  account_id    BIGINT NOT NULL REFERENCES wallet_account(id),
+  amount        BIGINT NOT NULL CHECK (amount <> 0),
+  balance_after BIGINT NOT NULL,
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_wallet_entry_txn ON wallet_entry(txn_id);
+CREATE INDEX IF NOT EXISTS idx_wallet_entry_account ON wallet_entry(account_id, id);
+
+-- 분개를 넣으면 계정 잔액에 반영한다. 사용자 잔액이 모자라면 chk_wallet_account_balance 위반
+CREATE OR REPLACE FUNCTION wallet_apply_entry() RETURNS trigger AS $$
+BEGIN
+  UPDATE wallet_account SET balance = balance + NEW.amount WHERE id = NEW.account_id
+  RETURNING balance INTO NEW.balance_after;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_wallet_entry_apply
+  BEFORE INSERT ON wallet_entry
+  FOR EACH ROW EXECUTE FUNCTION wallet_apply_entry();
+
+-- 커밋 시점에 거래의 분개 합이 0 인지 확인한다
+CREATE OR REPLACE FUNCTION wallet_check_balanced() RETURNS trigger AS $$
+BEGIN
+  IF (SELECT SUM(amount) FROM wallet_entry WHERE txn_id = NEW.txn_id) <> 0 THEN
+    RAISE EXCEPTION 'wallet transaction % is not balanced', NEW.txn_id
+      USING ERRCODE = 'check_violation', CONSTRAINT = 'chk_wallet_txn_balanced';
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER trg_wallet_entry_balanced
+  AFTER INSERT ON wallet_entry
+  DEFERRABLE INITIALLY DEFERRED
+  FOR EACH ROW EXECUTE FUNCTION wallet_check_balanced();
+
+-- 원장은 추가만 할 수 있다. 바로잡을 때는 반대 거래(환불)를 넣는다
+CREATE OR REPLACE FUNCTION wallet_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION '% is append-only', TG_TABLE_NAME
+    USING ERRCODE = 'check_violation', CONSTRAINT = 'chk_wallet_append_only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_wallet_txn_append_only
+  BEFORE UPDATE OR DELETE ON wallet_txn
+  FOR EACH ROW EXECUTE FUNCTION wallet_append_only();
+CREATE TRIGGER trg_wallet_entry_append_only
+  BEFORE UPDATE OR DELETE ON wallet_entry
+  FOR EACH ROW EXECUTE FUNCTION wallet_append_only();
@@ -0,0 +1 @@
+-- 지급한 항목은 사용자가 이미 쓰고 있을 수 있어 되돌리지 않는다
@@ -0,0 +1,21 @@
+-- 보상 지급이 단계 쓰기로 옮겨 가기 전에 온보딩을 마친 사용자에게 온보딩 항목을 지급한다.
+-- 완료 조건은 onboarding.State 와 같다(사진은 대표 사진이 있거나 최근 업로드가 심사 대기 중)
+INSERT INTO user_inventory (user_id, entity, item_id, source)
+SELECT u.id, i.entity, i.id, 'onboarding'
+FROM app_users u
+JOIN user_profile up ON up.user_id = u.id
+JOIN user_job j ON j.user_id = u.id
+JOIN user_avatar ua ON ua.user_id = u.id
+CROSS JOIN (
+  SELECT 'character_item' AS entity, id FROM character_item WHERE active AND locked AND unlock_source = 'onboarding'
+  UNION ALL SELECT 'bg_item', id FROM bg_item WHERE active AND locked AND unlock_source = 'onboarding'
+  UNION ALL SELECT 'avatar_part', id FROM avatar_part WHERE active AND locked AND unlock_source = 'onboarding'
+) i
+WHERE u.nickname IS NOT NULL
+  AND up.gender IS NOT NULL AND up.birth_date IS NOT NULL AND up.region_id IS NOT NULL
+  AND j.category IS NOT NULL
+  AND ua.character_id IS NOT NULL AND ua.bg_id IS NOT NULL
+  AND (up.profile_image_id IS NOT NULL OR (
+    SELECT f.status FROM user_face_upload f WHERE f.user_id = u.id ORDER BY f.created_at DESC LIMIT 1
+  ) = 'pending_review')
+ON CONFLICT (user_id, entity, item_id) DO NOTHING;
@@ -0,0 +1,42 @@
+package age
+
+import (
+	"fmt"
+	"time"
+)
+
+// 나이 계산 기준 시간대(날짜가 바뀌는 시점)
+var KST = time.FixedZone("KST", 9*60*60)
+
+// 오늘 날짜(KST)
+func Today() time.Time {
+	return time.Now().In(KST)
+}
+
+// 만 나이. 생일이 지나야 한 살 더한다(2월 29일생은 평년엔 3월 1일에)
+func International(birth, today time.Time) int {
+	n := today.Year() - birth.Year()
+	if today.Month() < birth.Month() || (today.Month() == birth.Month() && today.Day() < birth.Day()) {
+		n--
+	}
+	return n
+}
+
+// 세는 나이. 태어난 해에 1살, 해가 바뀔 때마다 한 살
+func Korean(birth, today time.Time) int {
+	return today.Year() - birth.Year() + 1
+}
+
+// 공개용 나이대(만 나이 기준 5살 단위). 음수면 빈 문자열
+func Bucket(n int) string {
+	switch {
+	case n < 0:
+		return ""
+	case n < 20:
+		return "under-20"
+	case n >= 60:
+		return "60+"
+	}
+	lo := n / 5 * 5
+	return fmt.Sprintf("%d-%d", lo, lo+4)
+}
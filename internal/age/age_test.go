@@ -0,0 +1,47 @@
+package age
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestInternational(t *testing.T) {
+	cases := []struct {
+		birth, today string
+		want         int
+	}{
+		{"2000-05-10", "2024-05-09", 23},
+		{"2000-05-10", "2024-05-10", 24},
+		{"2000-02-29", "2023-02-28", 22}, // 평년: 3월 1일에 한 살
+		{"2000-02-29", "2023-03-01", 23},
+		{"2000-02-29", "2024-02-29", 24},
+		{"2024-06-01", "2024-01-01", -1}, // 미래 날짜
+	}
+	for _, tc := range cases {
+		if got := International(date(tc.birth), date(tc.today)); got != tc.want {
+			t.Errorf("International(%s, %s) = %d, want %d", tc.birth, tc.today, got, tc.want)
+		}
+	}
+}
+
+func TestKorean(t *testing.T) {
+	if got := Korean(date("2000-12-31"), date("2001-01-01")); got != 2 {
+		t.Fatalf("want 2, got %d", got)
+	}
+	if got := Korean(date("2000-01-01"), date("2000-12-31")); got != 1 {
+		t.Fatalf("want 1, got %d", got)
+	}
+}
+
+func TestBucket(t *testing.T) {
+	for n, want := range map[int]string{-1: "", 0: "under-20", 19: "under-20", 20: "20-24", 24: "20-24", 25: "25-29", 59: "55-59", 60: "60+", 99: "60+"} {
+		if got := Bucket(n); got != want {
+			t.Errorf("Bucket(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
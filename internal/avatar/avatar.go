@@ -0,0 +1,277 @@
+// Package avatar 는 사용자 아바타(슬롯별 카탈로그 항목) 선택 규칙이다.
+package avatar
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	pgconnv5 "github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/catalog"
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+	"github.com/creators-of-happiness/amigo-backend/internal/i18n"
+	"github.com/creators-of-happiness/amigo-backend/internal/inventory"
+)
+
+// 슬롯(avatar_slot). base 는 캐릭터, background 는 배경이고 둘 다 반드시 고른다
+const (
+	SlotBase       = "base"
+	SlotBackground = "background"
+)
+
+// avatar_part 로 채우는 슬롯. user_avatar 의 <slot>_id 열에 저장하고 비워 둘 수 있다
+var PartSlots = catalog.PartSlots
+
+// 모든 슬롯(요청 slots 맵의 키)
+var Slots = append([]string{SlotBase, SlotBackground}, PartSlots...)
+
+// FieldError.Code
+const (
+	CodeRequired     = "required"     // 필수 슬롯이 비었다
+	CodeUnknown      = "unknown"      // 없는 항목이나 슬롯
+	CodeUnavailable  = "unavailable"  // 숨겼거나 노출 기간 밖(지금 쓰는 사용자만 유지)
+	CodeMismatch     = "mismatch"     // category_code 가 캐릭터의 분류와 다르거나, 다른 슬롯의 항목
+	CodeIncompatible = "incompatible" // 캐릭터와 함께 쓸 수 없는 배경(avatar_compat_rule)
+	CodeLocked       = "locked"       // 잠긴 항목인데 보유하지 않았다(user_inventory)
+)
+
+// 요청 필드 하나의 문제(400)
+type FieldError struct {
+	Field string
+	Code  string
+}
+
+func (e *FieldError) Error() string {
+	switch e.Code {
+	case CodeRequired:
+		return e.Field + " is required"
+	case CodeMismatch:
+		if e.Field == "category_code" {
+			return e.Field + " does not match the character's category"
+		}
+		return e.Field + " belongs to another slot"
+	case CodeIncompatible:
+		return e.Field + " cannot be used with this character"
+	case CodeUnavailable:
+		return "unavailable " + e.Field
+	case CodeLocked:
+		return e.Field + " is locked"
+	}
+	return "unknown " + e.Field
+}
+
+type Selection struct {
+	CategoryCode string // 비우면 캐릭터의 분류
+	CharacterID  string
+	BgID         string
+	Parts        map[string]string // 슬롯 → avatar_part id. 없는 슬롯은 비운다
+	BySlot       bool              // slots 맵으로 받았다(오류 필드가 slots.<slot>, 파트 슬롯도 바꾼다)
+}
+
+// slots 맵 요청. 값이 null 이거나 빠진 파트 슬롯은 비운다
+func FromSlots(categoryCode string, slots map[string]*string) (*Selection, error) {
+	s := &Selection{CategoryCode: categoryCode, Parts: map[string]string{}, BySlot: true}
+	for _, slot := range slices.Sorted(func(yield func(string) bool) {
+		for k := range slots {
+			if !yield(k) {
+				return
+			}
+		}
+	}) {
+		if !slices.Contains(Slots, slot) {
+			return nil, &FieldError{Field: s.field(slot), Code: CodeUnknown}
+		}
+		if slots[slot] == nil || *slots[slot] == "" {
+			continue
+		}
+		// DB 가 돌려주는 uuid 문자열(소문자)과 맞춰 비교한다
+		id := strings.ToLower(*slots[slot])
+		switch slot {
+		case SlotBase:
+			s.CharacterID = id
+		case SlotBackground:
+			s.BgID = id
+		default:
+			s.Parts[slot] = id
+		}
+	}
+	for _, slot := range []string{SlotBase, SlotBackground} {
+		if s.slotID(slot) == "" {
+			return nil, &FieldError{Field: s.field(slot), Code: CodeRequired}
+		}
+	}
+	return s, nil
+}
+
+// 오류에 쓸 요청 필드 이름. 예전 형식(character_id/bg_id)으로 받았으면 그 이름
+func (s *Selection) field(slot string) string {
+	if !s.BySlot {
+		switch slot {
+		case SlotBase:
+			return "character_id"
+		case SlotBackground:
+			return "bg_id"
+		}
+	}
+	return "slots." + slot
+}
+
+func (s *Selection) slotID(slot string) string {
+	switch slot {
+	case SlotBase:
+		return s.CharacterID
+	case SlotBackground:
+		return s.BgID
+	}
+	return s.Parts[slot]
+}
+
+// 파트 슬롯 값(user_avatar 의 <slot>_id). 비었으면 nil
+func (s *Selection) Part(slot string) *string {
+	if id, ok := s.Parts[slot]; ok {
+		return &id
+	}
+	return nil
+}
+
+// 선택을 검사하고 CategoryCode 를 캐릭터의 분류로 채운다.
+// 숨긴 항목/기간 밖 항목/호환되지 않는 조합/보유하지 않은 잠긴 항목은 지금 그것을 쓰고 있는 사용자만 유지할 수 있다
+func Check(ctx context.Context, q db.Querier, uid string, s *Selection) error {
+	var (
+		charFound, charOK, charOwned, catOK, bgFound, bgOK, bgOwned, compatible bool
+		category                                                                *string
+	)
+	err := q.QueryRow(ctx, `
+		WITH cur AS (SELECT * FROM user_avatar WHERE user_id = $1)
+		SELECT ci.id IS NOT NULL,
+		       COALESCE(ci.active AND `+catalog.Available("ci")+`, false) OR EXISTS (SELECT 1 FROM cur WHERE character_id = $2::uuid),
+		       COALESCE(`+inventory.Owned(i18n.CharacterItem, "$1", "ci")+`, false) OR EXISTS (SELECT 1 FROM cur WHERE character_id = $2::uuid),
+		       ci.category_code,
+		       cc.code IS NULL OR cc.active OR EXISTS (SELECT 1 FROM cur WHERE category_code = cc.code),
+		       b.id IS NOT NULL,
+		       COALESCE(b.active AND `+catalog.Available("b")+`, false) OR EXISTS (SELECT 1 FROM cur WHERE bg_id = $3::uuid),
+		       COALESCE(`+inventory.Owned(i18n.BgItem, "$1", "b")+`, false) OR EXISTS (SELECT 1 FROM cur WHERE bg_id = $3::uuid),
+		       avatar_bg_compatible($2::uuid, $3::uuid)
+		         OR EXISTS (SELECT 1 FROM cur WHERE character_id = $2::uuid AND bg_id = $3::uuid)
+		FROM (SELECT 1) one
+		LEFT JOIN character_item ci ON ci.id = $2::uuid
+		LEFT JOIN character_category cc ON cc.code = ci.category_code
+		LEFT JOIN bg_item b ON b.id = $3::uuid`, uid, s.CharacterID, s.BgID).
+		Scan(&charFound, &charOK, &charOwned, &category, &catOK, &bgFound, &bgOK, &bgOwned, &compatible)
+	if err != nil {
+		return err
+	}
+	cat := ""
+	if category != nil {
+		cat = *category
+	}
+	base, bg := s.field(SlotBase), s.field(SlotBackground)
+	switch {
+	case !charFound:
+		return &FieldError{Field: base, Code: CodeUnknown}
+	case s.CategoryCode != "" && s.CategoryCode != cat:
+		return &FieldError{Field: "category_code", Code: CodeMismatch}
+	case !catOK && s.CategoryCode != "":
+		return &FieldError{Field: "category_code", Code: CodeUnavailable}
+	case !charOK || !catOK:
+		return &FieldError{Field: base, Code: CodeUnavailable}
+	case !charOwned:
+		return &FieldError{Field: base, Code: CodeLocked}
+	case !bgFound:
+		return &FieldError{Field: bg, Code: CodeUnknown}
+	case !bgOK:
+		return &FieldError{Field: bg, Code: CodeUnavailable}
+	case !bgOwned:
+		return &FieldError{Field: bg, Code: CodeLocked}
+	case !compatible:
+		return &FieldError{Field: bg, Code: CodeIncompatible}
+	}
+	if err := checkParts(ctx, q, uid, s); err != nil {
+		return err
+	}
+	s.CategoryCode = cat
+	return nil
+}
+
+// 파트는 고른 슬롯의 항목이어야 하고, 캐릭터/배경과 같은 규칙으로 쓸 수 있어야 한다
+func checkParts(ctx context.Context, q db.Querier, uid string, s *Selection) error {
+	if len(s.Parts) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(s.Parts))
+	for _, id := range s.Parts {
+		ids = append(ids, id)
+	}
+	rows, err := q.Query(ctx, `
+		WITH cur AS (SELECT * FROM user_avatar WHERE user_id = $1)
+		SELECT p.id::text, p.slot_code,
+		       p.active AND `+catalog.Available("p")+` OR eq.id IS NOT NULL,
+		       `+inventory.Owned(i18n.AvatarPart, "$1", "p")+` OR eq.id IS NOT NULL
+		FROM avatar_part p
+		LEFT JOIN LATERAL (
+			SELECT p.id FROM cur WHERE p.id IN (cur.outfit_id, cur.expression_id, cur.accessory_id)
+		) eq ON true
+		WHERE p.id = ANY($2::uuid[])`, uid, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	type part struct {
+		slot      string
+		ok, owned bool
+	}
+	found := map[string]part{}
+	for rows.Next() {
+		var id string
+		var p part
+		if err := rows.Scan(&id, &p.slot, &p.ok, &p.owned); err != nil {
+			return err
+		}
+		found[id] = p
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, slot := range PartSlots {
+		id, ok := s.Parts[slot]
+		if !ok {
+			continue
+		}
+		p, ok := found[id]
+		switch {
+		case !ok:
+			return &FieldError{Field: s.field(slot), Code: CodeUnknown}
+		case p.slot != slot:
+			return &FieldError{Field: s.field(slot), Code: CodeMismatch}
+		case !p.ok:
+			return &FieldError{Field: s.field(slot), Code: CodeUnavailable}
+		case !p.owned:
+			return &FieldError{Field: s.field(slot), Code: CodeLocked}
+		}
+	}
+	return nil
+}
+
+// 검사와 저장 사이에 항목이 지워지거나 분류가 바뀐 경우의 FK 위반을 필드 오류로
+func (s *Selection) FromPgError(err error) error {
+	var pe *pgconnv5.PgError
+	if !errors.As(err, &pe) || pe.Code != "23503" {
+		return err
+	}
+	switch pe.ConstraintName {
+	case "user_avatar_character_id_fkey":
+		return &FieldError{Field: s.field(SlotBase), Code: CodeUnknown}
+	case "user_avatar_bg_id_fkey":
+		return &FieldError{Field: s.field(SlotBackground), Code: CodeUnknown}
+	case "user_avatar_category_code_fkey", "fk_user_avatar_character_category":
+		return &FieldError{Field: "category_code", Code: CodeMismatch}
+	}
+	for _, slot := range PartSlots {
+		if pe.ConstraintName == "user_avatar_"+slot+"_id_fkey" {
+			return &FieldError{Field: s.field(slot), Code: CodeUnknown}
+		}
+	}
+	return err
+}
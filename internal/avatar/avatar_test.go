@@ -0,0 +1,51 @@
+package avatar
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFromSlots(t *testing.T) {
+	id := func(s string) *string { return &s }
+	const char, bg, hat = "00000000-0000-0000-0000-000000000001", "00000000-0000-0000-0000-000000000002", "00000000-0000-0000-0000-000000000003"
+
+	s, err := FromSlots("", map[string]*string{"base": id(char), "background": id(bg), "accessory": id(hat), "outfit": nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.CharacterID != char || s.BgID != bg || *s.Part("accessory") != hat || s.Part("outfit") != nil || !s.BySlot {
+		t.Fatalf("unexpected selection %+v", s)
+	}
+	// 대문자 uuid 도 받되 소문자로 맞춘다
+	s, err = FromSlots("", map[string]*string{"base": id(char), "background": id(bg), "accessory": id("0000000A-0000-0000-0000-00000000000B")})
+	if err != nil || *s.Part("accessory") != "0000000a-0000-0000-0000-00000000000b" {
+		t.Fatalf("part id must be lowercased: %+v %v", s, err)
+	}
+
+	for _, tt := range []struct {
+		slots       map[string]*string
+		field, code string
+	}{
+		{map[string]*string{"base": id(char)}, "slots.background", CodeRequired},
+		{map[string]*string{"background": id(bg), "base": nil}, "slots.base", CodeRequired},
+		{map[string]*string{"base": id(char), "background": id(bg), "hat": id(hat)}, "slots.hat", CodeUnknown},
+	} {
+		_, err := FromSlots("", tt.slots)
+		var fe *FieldError
+		if !errors.As(err, &fe) || fe.Field != tt.field || fe.Code != tt.code {
+			t.Errorf("%v: got %v, want %s %s", tt.slots, err, tt.field, tt.code)
+		}
+	}
+}
+
+// 예전 형식이면 base/background 오류를 원래 필드 이름으로 알린다
+func TestSelectionField(t *testing.T) {
+	legacy := &Selection{}
+	if legacy.field(SlotBase) != "character_id" || legacy.field(SlotBackground) != "bg_id" || legacy.field("outfit") != "slots.outfit" {
+		t.Fatal("unexpected legacy field names")
+	}
+	bySlot := &Selection{BySlot: true}
+	if bySlot.field(SlotBase) != "slots.base" {
+		t.Fatal("unexpected slot field name")
+	}
+}
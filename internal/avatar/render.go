@@ -0,0 +1,300 @@
+package avatar
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/image/draw"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/media"
+	"github.com/creators-of-happiness/amigo-backend/internal/storage"
+)
+
+// 합성 이미지 한 변(정사각) 픽셀
+var RenderSizes = []int{128, 256, 512, 1024}
+
+const (
+	DefaultRenderSize   = 512
+	DefaultRenderFormat = "png"
+
+	// 합성 방식이 바뀌면 올려서 기존 합성본을 다시 그리게 한다
+	renderVersion = "2"
+)
+
+var (
+	ErrNoAvatar      = errors.New("avatar not set")
+	ErrLayerTooLarge = errors.New("avatar preview image too large")
+)
+
+// 합성된 아바타 이미지(media_asset)
+type Image struct {
+	AssetID     string `json:"asset_id"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
+
+// 사용자 아바타를 그려 저장소에 올리고 avatar_render 에 캐시한다
+type Renderer struct {
+	Pool     *pgxpool.Pool
+	Store    storage.Storage
+	Source   media.Source
+	MaxLayer int // 미리보기 원본 가로/세로 최대 픽셀(압축 폭탄 방지)
+}
+
+func NewRenderer(pool *pgxpool.Pool, store storage.Storage) *Renderer {
+	return &Renderer{
+		Pool:     pool,
+		Store:    store,
+		Source:   media.Source{Store: store, HTTP: &http.Client{Timeout: 5 * time.Second}, MaxBytes: 20 << 20},
+		MaxLayer: 4096,
+	}
+}
+
+// 그릴 층 하나(avatar_slot.z_order 순). 미리보기가 없는 슬롯은 빠진다
+type layer struct {
+	slot             string
+	url              string
+	key              *string
+	anchorX, anchorY float64
+	scale            float64
+}
+
+// uid 의 아바타 합성본. 캐시가 없거나 입력이 바뀌었으면 새로 그린다. 아바타가 없으면 ErrNoAvatar
+func (r *Renderer) Get(ctx context.Context, uid string, size int, format string) (*Image, error) {
+	var source *string
+	var cached Image
+	var cachedID, cachedKey *string
+	err := r.Pool.QueryRow(ctx, `
+		SELECT ar.source, ra.id::text, ra.url, ra.content_type, ra.width, ra.height, ra.storage_key
+		FROM user_avatar ua
+		LEFT JOIN avatar_render ar ON ar.user_id = ua.user_id AND ar.size = $2 AND ar.format = $3
+		LEFT JOIN media_asset ra ON ra.id = ar.asset_id
+		WHERE ua.user_id = $1`, uid, size, format).
+		Scan(&source, &cachedID, &cached.URL, &cached.ContentType, &cached.Width, &cached.Height, &cachedKey)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNoAvatar
+	}
+	if err != nil {
+		return nil, err
+	}
+	layers, err := r.layers(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	want := renderSource(size, format, layers)
+	if cachedID != nil && source != nil && *source == want {
+		cached.AssetID = *cachedID
+		return &cached, nil
+	}
+
+	imgs := make([]Layer, 0, len(layers))
+	for _, l := range layers {
+		img, err := r.load(ctx, l)
+		if err != nil {
+			return nil, fmt.Errorf("%s preview: %w", l.slot, err)
+		}
+		imgs = append(imgs, Layer{Slot: l.slot, Image: img, AnchorX: l.anchorX, AnchorY: l.anchorY, Scale: l.scale})
+	}
+	data, ct, ext, err := Encode(Compose(imgs, size), format)
+	if err != nil {
+		return nil, err
+	}
+	key := "users/" + uid + "/avatar/" + want + ext
+	if err := r.Store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), ct); err != nil {
+		return nil, err
+	}
+	out := &Image{URL: r.Store.URL(key), ContentType: ct, Width: size, Height: size}
+	if err := r.save(ctx, uid, size, format, want, key, len(data), out); err != nil {
+		return nil, err
+	}
+	// 같은 칸의 이전 합성본 객체 정리(실패해도 응답에는 영향 없음)
+	if cachedKey != nil && *cachedKey != key {
+		_ = r.Store.Delete(context.Background(), *cachedKey)
+	}
+	return out, nil
+}
+
+// 고른 항목들의 미리보기를 아래 층부터
+func (r *Renderer) layers(ctx context.Context, uid string) ([]layer, error) {
+	rows, err := r.Pool.Query(ctx, `
+		WITH ua AS (SELECT * FROM user_avatar WHERE user_id = $1),
+		l AS (
+			SELECT 'background' AS slot, b.preview_asset AS asset, 0.5::float8 AS ax, 0.5::float8 AS ay, 1::float8 AS scale
+			FROM ua JOIN bg_item b ON b.id = ua.bg_id
+			UNION ALL
+			SELECT 'base', ci.preview_asset, 0.5, 1, 1
+			FROM ua JOIN character_item ci ON ci.id = ua.character_id
+			UNION ALL
+			SELECT p.slot_code, p.preview_asset, p.anchor_x, p.anchor_y, p.scale
+			FROM ua JOIN avatar_part p ON p.id IN (ua.outfit_id, ua.expression_id, ua.accessory_id)
+		)
+		SELECT l.slot, m.url, m.storage_key, l.ax, l.ay, l.scale
+		FROM l
+		JOIN avatar_slot s ON s.code = l.slot
+		JOIN media_asset m ON m.id = l.asset
+		ORDER BY s.z_order`, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []layer
+	for rows.Next() {
+		var l layer
+		if err := rows.Scan(&l.slot, &l.url, &l.key, &l.anchorX, &l.anchorY, &l.scale); err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+// 합성본 에셋을 만들고(파생본은 필요 없으므로 variant_status='done') 칸을 교체한다.
+// 그사이 아바타가 지워졌으면 FK 위반 대신 ErrNoAvatar
+func (r *Renderer) save(ctx context.Context, uid string, size int, format, source, key string, n int, out *Image) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	err = tx.QueryRow(ctx, `
+		INSERT INTO media_asset (kind, url, storage_key, content_type, width, height, size_bytes, owner_id, variant_status)
+		VALUES ('image', $1, $2, $3, $4, $4, $5, $6, 'done')
+		ON CONFLICT (storage_key) WHERE storage_key IS NOT NULL DO UPDATE SET url = EXCLUDED.url, size_bytes = EXCLUDED.size_bytes
+		RETURNING id::text`, out.URL, key, out.ContentType, size, n, uid).Scan(&out.AssetID)
+	if err != nil {
+		return err
+	}
+	var old *string
+	err = tx.QueryRow(ctx, `
+		WITH prev AS (SELECT asset_id FROM avatar_render WHERE user_id = $1 AND size = $2 AND format = $3)
+		INSERT INTO avatar_render (user_id, size, format, source, asset_id)
+		SELECT $1, $2, $3, $4, $5 FROM user_avatar WHERE user_id = $1
+		ON CONFLICT (user_id, size, format) DO UPDATE SET source = EXCLUDED.source, asset_id = EXCLUDED.asset_id, created_at = now()
+		RETURNING (SELECT asset_id::text FROM prev)`, uid, size, format, source, out.AssetID).Scan(&old)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNoAvatar
+	}
+	if err != nil {
+		return err
+	}
+	if old != nil && *old != out.AssetID {
+		if _, err := tx.Exec(ctx, `DELETE FROM media_asset WHERE id = $1`, *old); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *Renderer) load(ctx context.Context, l layer) (image.Image, error) {
+	data, err := r.Source.Read(ctx, l.url, l.key)
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, media.ErrCorrupt
+	}
+	if cfg.Width > r.MaxLayer || cfg.Height > r.MaxLayer {
+		return nil, ErrLayerTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, media.ErrCorrupt
+	}
+	return img, nil
+}
+
+// 합성 입력의 해시. 저장소 키에도 쓰여 같은 입력이면 같은 객체를 덮어쓴다
+func renderSource(size int, format string, layers []layer) string {
+	h := sha256.New()
+	for _, s := range []string{renderVersion, strconv.Itoa(size), format} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	for _, l := range layers {
+		fmt.Fprintf(h, "%s\x00%s\x00%g\x00%g\x00%g\x00", l.slot, l.url, l.anchorX, l.anchorY, l.scale)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// 합성할 층. 배경/캐릭터는 슬롯으로 자리가 정해지고, 파트만 Anchor/Scale 을 쓴다
+type Layer struct {
+	Slot             string
+	Image            image.Image // nil 이면 건너뛴다
+	AnchorX, AnchorY float64     // 캔버스(0~1)에서 항목 중심
+	Scale            float64     // 캔버스 한 변 대비 항목 긴 변
+}
+
+// size×size 캔버스에 layers 를 순서대로 얹는다. 배경은 가운데 기준으로 꽉 채우고(cover),
+// 캐릭터는 비율 유지로 캔버스 안에 맞춰(contain) 아래 가운데에, 파트는 앵커 위치에 놓는다.
+// 아무것도 없는 곳은 투명하게 남는다
+func Compose(layers []Layer, size int) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for _, l := range layers {
+		if l.Image == nil {
+			continue
+		}
+		b := l.Image.Bounds()
+		switch l.Slot {
+		case SlotBackground:
+			draw.CatmullRom.Scale(dst, dst.Bounds(), l.Image, centerSquare(b), draw.Src, nil)
+		case SlotBase:
+			w, h := fit(b, size)
+			at := image.Rect((size-w)/2, size-h, (size-w)/2+w, size)
+			draw.CatmullRom.Scale(dst, at, l.Image, b, draw.Over, nil)
+		default:
+			w, h := fit(b, max(int(l.Scale*float64(size)+0.5), 1))
+			cx, cy := int(l.AnchorX*float64(size)+0.5), int(l.AnchorY*float64(size)+0.5)
+			at := image.Rect(cx-w/2, cy-h/2, cx-w/2+w, cy-h/2+h)
+			draw.CatmullRom.Scale(dst, at, l.Image, b, draw.Over, nil)
+		}
+	}
+	return dst
+}
+
+// 비율을 유지해 긴 변이 side 가 되는 크기
+func fit(b image.Rectangle, side int) (w, h int) {
+	if b.Dx() >= b.Dy() {
+		return side, max(b.Dy()*side/b.Dx(), 1)
+	}
+	return max(b.Dx()*side/b.Dy(), 1), side
+}
+
+func centerSquare(b image.Rectangle) image.Rectangle {
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+	return image.Rect(x0, y0, x0+side, y0+side)
+}
+
+// png 또는 webp(무손실). content type 과 확장자를 함께 돌려준다
+func Encode(img image.Image, format string) (data []byte, contentType, ext string, err error) {
+	var buf bytes.Buffer
+	switch format {
+	case "png":
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", "", fmt.Errorf("encode png: %w", err)
+		}
+		return buf.Bytes(), "image/png", ".png", nil
+	case "webp":
+		if err := nativewebp.Encode(&buf, img, nil); err != nil {
+			return nil, "", "", fmt.Errorf("encode webp: %w", err)
+		}
+		return buf.Bytes(), "image/webp", ".webp", nil
+	}
+	return nil, "", "", fmt.Errorf("unsupported format %q", format)
+}
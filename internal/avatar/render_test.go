@@ -0,0 +1,124 @@
+package avatar
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+func solid(w, h int, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+// 배경은 캔버스를 채우고, 세로로 긴 캐릭터는 아래 가운데에 놓이며 양옆은 배경이 보인다
+func TestCompose(t *testing.T) {
+	blue := color.NRGBA{0, 0, 255, 255}
+	red := color.NRGBA{255, 0, 0, 255}
+	// 가로 4:3 배경은 가운데만 쓰고, 캐릭터는 1:2
+	out := Compose([]Layer{{Slot: SlotBackground, Image: solid(400, 300, blue)}, {Slot: SlotBase, Image: solid(50, 100, red)}}, 128)
+	if b := out.Bounds(); b.Dx() != 128 || b.Dy() != 128 {
+		t.Fatalf("unexpected bounds %v", b)
+	}
+	for _, tt := range []struct {
+		x, y int
+		want color.NRGBA
+	}{
+		{2, 2, blue},     // 왼쪽 위: 배경
+		{64, 2, red},     // 캐릭터는 세로로 꽉 찬다
+		{64, 126, red},   // 아래 가운데
+		{20, 100, blue},  // 캐릭터(x 32..96) 왼쪽 밖
+		{40, 100, red},   // 캐릭터 안
+		{100, 100, blue}, // 캐릭터 오른쪽 밖
+	} {
+		if got := out.NRGBAAt(tt.x, tt.y); got != tt.want {
+			t.Errorf("(%d,%d): got %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+
+	// 없는 층은 투명
+	if got := Compose([]Layer{{Slot: SlotBase}}, 16).NRGBAAt(8, 8); got.A != 0 {
+		t.Fatalf("expected transparent canvas, got %v", got)
+	}
+}
+
+// 파트는 앵커를 중심으로 긴 변이 Scale 배인 크기로, 순서대로 위에 얹힌다
+func TestCompose_Parts(t *testing.T) {
+	blue := color.NRGBA{0, 0, 255, 255}
+	green := color.NRGBA{0, 255, 0, 255}
+	yellow := color.NRGBA{255, 255, 0, 255}
+	out := Compose([]Layer{
+		{Slot: SlotBackground, Image: solid(10, 10, blue)},
+		// 가로 2:1 모자: 긴 변 0.5*128=64, 중심 (64, 32) → x 32..96, y 16..48
+		{Slot: "accessory", Image: solid(20, 10, green), AnchorX: 0.5, AnchorY: 0.25, Scale: 0.5},
+		// 나중 층이 위: 모자 오른쪽 절반을 덮는 작은 사각형, 중심 (80, 32) → x 72..88, y 24..40
+		{Slot: "accessory", Image: solid(5, 5, yellow), AnchorX: 0.625, AnchorY: 0.25, Scale: 0.125},
+	}, 128)
+	for _, tt := range []struct {
+		x, y int
+		want color.NRGBA
+	}{
+		{40, 20, green},
+		{64, 45, green},
+		{28, 32, blue},   // 왼쪽 밖
+		{64, 52, blue},   // 아래 밖
+		{80, 32, yellow}, // 위 층
+		{92, 32, green},
+	} {
+		if got := out.NRGBAAt(tt.x, tt.y); got != tt.want {
+			t.Errorf("(%d,%d): got %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	img := Compose([]Layer{{Slot: SlotBackground, Image: solid(10, 10, color.NRGBA{0, 128, 0, 255})}}, 32)
+	for _, tt := range []struct{ format, ct, ext string }{
+		{"png", "image/png", ".png"},
+		{"webp", "image/webp", ".webp"},
+	} {
+		data, ct, ext, err := Encode(img, tt.format)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if ct != tt.ct || ext != tt.ext {
+			t.Fatalf("%s: got %s %s", tt.format, ct, ext)
+		}
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil || format != tt.format || cfg.Width != 32 || cfg.Height != 32 {
+			t.Fatalf("%s: decoded %s %dx%d (%v)", tt.format, format, cfg.Width, cfg.Height, err)
+		}
+	}
+	if _, _, _, err := Encode(img, "gif"); err == nil {
+		t.Fatal("expected error for unsupported format")
+	}
+}
+
+// 같은 입력이면 같은 키, 미리보기/크기/형식/층/앵커가 바뀌면 다른 키
+func TestRenderSource(t *testing.T) {
+	cat := layer{slot: SlotBase, url: "https://cdn.example.com/cat.png", anchorX: 0.5, anchorY: 1, scale: 1}
+	sea := layer{slot: SlotBackground, url: "https://cdn.example.com/sea.png", anchorX: 0.5, anchorY: 0.5, scale: 1}
+	hat := layer{slot: "accessory", url: "https://cdn.example.com/hat.png", anchorX: 0.5, anchorY: 0.2, scale: 0.4}
+	base := renderSource(512, "png", []layer{sea, cat, hat})
+	if renderSource(512, "png", []layer{sea, cat, hat}) != base {
+		t.Fatal("expected stable source")
+	}
+	moved := hat
+	moved.anchorY = 0.3
+	for _, other := range []string{
+		renderSource(256, "png", []layer{sea, cat, hat}),
+		renderSource(512, "webp", []layer{sea, cat, hat}),
+		renderSource(512, "png", []layer{sea, cat}),
+		renderSource(512, "png", []layer{sea, cat, moved}),
+		renderSource(512, "png", []layer{cat, sea, hat}),
+	} {
+		if other == base {
+			t.Fatal("expected different source")
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+)
+
+// catalog_version 이 바뀌면 트리거가 보내는 채널
+const Channel = "catalog_changed"
+
+const (
+	// 인증이 필요한 응답이라 공유 캐시에는 두지 않는다
+	CacheControl = "private, max-age=300, must-revalidate"
+	maxEntries   = 1024
+)
+
+// 현재 카탈로그 버전과 마지막 변경 시각
+func Version(ctx context.Context, q db.Querier) (int64, time.Time, error) {
+	var v int64
+	var at time.Time
+	err := q.QueryRow(ctx, `SELECT version, updated_at FROM catalog_version`).Scan(&v, &at)
+	return v, at, err
+}
+
+type entry struct {
+	status      int
+	contentType string
+	body        []byte
+}
+
+// meta 응답 메모리 캐시. LISTEN 중일 때만 버전을 메모리에 들고 있고,
+// 연결이 끊겨 알림을 놓칠 수 있는 동안에는 매 요청 DB 에서 버전을 읽고 본문 캐시는 쓰지 않는다
+type Cache struct {
+	pool *pgxpool.Pool
+	load func(ctx context.Context) (int64, time.Time, error)
+
+	mu        sync.RWMutex
+	listening bool
+	version   int64
+	updatedAt time.Time
+	entries   map[string]entry
+}
+
+func NewCache(pool *pgxpool.Pool) *Cache {
+	c := &Cache{pool: pool, entries: map[string]entry{}}
+	c.load = func(ctx context.Context) (int64, time.Time, error) { return Version(ctx, pool) }
+	return c
+}
+
+// LISTEN 루프. 연결이 끊기면 잠시 뒤 다시 붙는다(ctx 가 끝날 때까지)
+func (c *Cache) Run(ctx context.Context) {
+	for {
+		err := c.listen(ctx)
+		c.setListening(false)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("catalog cache: %v; retrying", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func (c *Cache) listen(ctx context.Context) error {
+	pc, err := c.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// LISTEN 상태의 연결은 풀에 돌려주지 않는다
+	conn := pc.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+	// LISTEN 이후의 버전부터 알림으로 따라간다
+	v, at, err := Version(ctx, conn)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.version, c.updatedAt, c.listening = v, at, true
+	c.entries = map[string]entry{}
+	c.mu.Unlock()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		v, at, ok := parsePayload(n.Payload)
+		if !ok {
+			continue
+		}
+		c.invalidate(v, at)
+	}
+}
+
+// "<version>:<updated_at 유닉스 마이크로초>"
+func parsePayload(s string) (int64, time.Time, bool) {
+	vs, ts, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, time.Time{}, false
+	}
+	v, err1 := strconv.ParseInt(vs, 10, 64)
+	us, err2 := strconv.ParseInt(ts, 10, 64)
+	if err1 != nil || err2 != nil {
+		return 0, time.Time{}, false
+	}
+	return v, time.UnixMicro(us), true
+}
+
+func (c *Cache) setListening(on bool) {
+	c.mu.Lock()
+	c.listening = on
+	c.entries = map[string]entry{}
+	c.mu.Unlock()
+}
+
+// 알림으로 받은 버전이 더 새로우면 본문 캐시를 비운다
+func (c *Cache) invalidate(v int64, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v <= c.version {
+		return
+	}
+	c.version, c.updatedAt = v, at
+	c.entries = map[string]entry{}
+}
+
+func (c *Cache) current(ctx context.Context) (v int64, at time.Time, cached bool, err error) {
+	c.mu.RLock()
+	if c.listening {
+		v, at = c.version, c.updatedAt
+		c.mu.RUnlock()
+		return v, at, true, nil
+	}
+	c.mu.RUnlock()
+	v, at, err = c.load(ctx)
+	return v, at, false, err
+}
+
+func (c *Cache) get(key string, v int64) (entry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.listening || c.version != v {
+		return entry{}, false
+	}
+	e, ok := c.entries[key]
+	return e, ok
+}
+
+func (c *Cache) put(key string, v int64, e entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// 그 사이 버전이 바뀌었으면 오래된 본문이므로 버린다
+	if !c.listening || c.version != v || len(c.entries) >= maxEntries {
+		return
+	}
+	c.entries[key] = e
+}
+
+// 응답 본문을 잡아두는 writer
+type recorder struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+// 200 이 아니면 버전과 관계없는 응답이므로 검증자/캐시 헤더를 떼어낸다
+func (r *recorder) WriteHeader(code int) {
+	if code != http.StatusOK {
+		h := r.Header()
+		h.Del("ETag")
+		h.Del("Last-Modified")
+		h.Del("Cache-Control")
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.buf.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *recorder) WriteString(s string) (int, error) {
+	r.buf.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+// 카탈로그 GET 응답에 버전 기반 강한 ETag/Last-Modified/Cache-Control 을 붙이고
+// 조건부 요청이면 304, 같은 버전의 같은 요청이면 메모리에서 바로 응답한다.
+// 언어별로 본문이 다르므로 Locale 미들웨어 뒤에 둘 것
+func (c *Cache) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.Method != http.MethodGet {
+			ctx.Next()
+			return
+		}
+		v, at, cached, err := c.current(ctx.Request.Context())
+		if err != nil {
+			// 버전을 모르면 캐시 없이 처리
+			ctx.Next()
+			return
+		}
+		key := ctx.GetString("locale") + " " + ctx.Request.URL.RequestURI()
+		etag := ETag(v, key)
+		h := ctx.Writer.Header()
+		h.Set("ETag", etag)
+		h.Set("Last-Modified", at.UTC().Format(http.TimeFormat))
+		h.Set("Cache-Control", CacheControl)
+
+		if notModified(ctx.Request, etag, at) {
+			ctx.AbortWithStatus(http.StatusNotModified)
+			return
+		}
+		if cached {
+			if e, ok := c.get(key, v); ok {
+				ctx.Data(e.status, e.contentType, e.body)
+				ctx.Abort()
+				return
+			}
+		}
+
+		rec := &recorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = rec
+		ctx.Next()
+		if cached && rec.Status() == http.StatusOK {
+			c.put(key, v, entry{status: rec.Status(), contentType: rec.Header().Get("Content-Type"), body: rec.buf.Bytes()})
+		}
+	}
+}
+
+// 같은 버전·언어·요청이면 본문이 바이트 단위로 같다
+func ETag(version int64, key string) string {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return fmt.Sprintf(`"%d-%x"`, version, h.Sum64())
+}
+
+// If-None-Match 가 있으면 그것만, 없으면 If-Modified-Since 로 판단(RFC 9110 13.2.2)
+func notModified(r *http.Request, etag string, at time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, t := range strings.Split(inm, ",") {
+			// If-None-Match 는 약한 비교
+			t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+			if t == "*" || t == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		if t, err := http.ParseTime(ims); err == nil {
+			return !at.Truncate(time.Second).After(t)
+		}
+	}
+	return false
+}
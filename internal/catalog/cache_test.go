@@ -0,0 +1,117 @@
+package catalog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestCache(v int64, at time.Time, listening bool) *Cache {
+	c := &Cache{entries: map[string]entry{}, listening: listening, version: v, updatedAt: at}
+	c.load = func(context.Context) (int64, time.Time, error) { return c.version, c.updatedAt, nil }
+	return c
+}
+
+func newRouter(c *Cache, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(ctx *gin.Context) { ctx.Set("locale", ctx.GetHeader("Accept-Language")) }, c.Middleware())
+	r.GET("/items", func(ctx *gin.Context) {
+		*calls++
+		ctx.JSON(http.StatusOK, gin.H{"items": []string{"a"}})
+	})
+	r.GET("/missing", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "nope"})
+	})
+	return r
+}
+
+func get(r http.Handler, path string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_CachesUntilInvalidated(t *testing.T) {
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	c := newTestCache(7, at, true)
+	calls := 0
+	r := newRouter(c, &calls)
+
+	w1 := get(r, "/items", nil)
+	w2 := get(r, "/items", nil)
+	if w1.Code != 200 || w2.Code != 200 || calls != 1 {
+		t.Fatalf("second request must be served from cache: codes=%d,%d calls=%d", w1.Code, w2.Code, calls)
+	}
+	if w1.Body.String() != w2.Body.String() || w2.Header().Get("Content-Type") == "" {
+		t.Fatalf("cached response differs: %q vs %q", w1.Body.String(), w2.Body.String())
+	}
+	etag := w1.Header().Get("ETag")
+	if etag == "" || w1.Header().Get("Cache-Control") != CacheControl || w1.Header().Get("Last-Modified") != at.Format(http.TimeFormat) {
+		t.Fatalf("missing cache headers: %v", w1.Header())
+	}
+
+	if w := get(r, "/items", map[string]string{"If-None-Match": etag}); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("expected 304, got %d", w.Code)
+	}
+	if w := get(r, "/items", map[string]string{"If-Modified-Since": at.Format(http.TimeFormat)}); w.Code != http.StatusNotModified {
+		t.Fatalf("If-Modified-Since: expected 304, got %d", w.Code)
+	}
+	// 언어가 다르면 다른 표현
+	if w := get(r, "/items", map[string]string{"Accept-Language": "en", "If-None-Match": etag}); w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Fatalf("locale must change the ETag: %d %s", w.Code, w.Header().Get("ETag"))
+	}
+
+	// 이전 버전 알림은 무시, 새 버전이면 비운다
+	c.invalidate(6, at)
+	get(r, "/items", nil)
+	if calls != 2 { // 위 en 요청 1회 포함
+		t.Fatalf("stale notification must not clear cache: calls=%d", calls)
+	}
+	c.invalidate(8, at.Add(time.Hour))
+	w := get(r, "/items", map[string]string{"If-None-Match": etag})
+	if w.Code != http.StatusOK || calls != 3 || w.Header().Get("ETag") == etag {
+		t.Fatalf("after invalidation: code=%d calls=%d etag=%s", w.Code, calls, w.Header().Get("ETag"))
+	}
+}
+
+func TestMiddleware_NotListening(t *testing.T) {
+	c := newTestCache(3, time.Now(), false)
+	calls := 0
+	r := newRouter(c, &calls)
+	w := get(r, "/items", nil)
+	get(r, "/items", nil)
+	if calls != 2 {
+		t.Fatalf("without LISTEN responses must not be cached: calls=%d", calls)
+	}
+	if w := get(r, "/items", map[string]string{"If-None-Match": `W/` + w.Header().Get("ETag")}); w.Code != http.StatusNotModified {
+		t.Fatalf("weak If-None-Match: expected 304, got %d", w.Code)
+	}
+}
+
+func TestMiddleware_ErrorHasNoValidators(t *testing.T) {
+	c := newTestCache(1, time.Now(), true)
+	calls := 0
+	w := get(newRouter(c, &calls), "/missing", nil)
+	if w.Code != http.StatusNotFound || w.Header().Get("ETag") != "" || w.Header().Get("Cache-Control") != "" {
+		t.Fatalf("error response must not carry cache headers: %d %v", w.Code, w.Header())
+	}
+}
+
+func TestParsePayload(t *testing.T) {
+	v, at, ok := parsePayload("42:1735787045000000")
+	if !ok || v != 42 || !at.Equal(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Fatalf("got %d %v %v", v, at, ok)
+	}
+	if _, _, ok := parsePayload("42"); ok {
+		t.Fatal("payload without time must be rejected")
+	}
+}
@@ -0,0 +1,344 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 관리자 API 로 고칠 수 있는 카탈로그 테이블 정의.
+// 테이블/컬럼 이름은 여기 적힌 것만 SQL 에 들어간다
+
+type Kind int
+
+const (
+	Text Kind = iota
+	Int
+	Float
+	UUID
+	URL
+	Bool
+	Time // RFC 3339
+	Tags // 문자열 배열(각각 code 형식, 중복 불가)
+)
+
+var (
+	codeRe = regexp.MustCompile(`^[a-z0-9_]{1,40}$`)
+	uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+type Field struct {
+	Name      string
+	Kind      Kind
+	Required  bool    // 생성 때 반드시 있어야 한다
+	Nullable  bool    // null 로 비울 수 있다
+	Immutable bool    // 생성 뒤에는 바꿀 수 없다
+	MaxLen    int     // Text/URL 글자 수, Tags 개수
+	Min, Max  float64 // Int/Float 범위. 둘 다 0 이면 검사하지 않는다
+	Pattern   *regexp.Regexp
+	OneOf     []string
+}
+
+type Entity struct {
+	Name    string // URL 경로이자 테이블 이름
+	Key     string // 키 컬럼
+	KeyKind Kind   // Int(SERIAL)·UUID 는 DB 가 만들고, Text 는 입력 필드로 받는다
+	Fields  []Field
+	Disable bool   // active 컬럼으로 숨길 수 있다(없으면 비활성화 불가)
+	Scope   string // 관리 대상 행 조건(SQL, 별칭 t)
+	Order   string
+}
+
+var ErrUnknownEntity = errors.New("unknown catalog entity")
+
+// 입력 검증 실패. Field 가 비면 본문 전체의 문제
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return e.Reason
+	}
+	return e.Field + ": " + e.Reason
+}
+
+func codeField(name string) Field {
+	return Field{Name: name, Kind: Text, Required: true, Immutable: true, Pattern: codeRe}
+}
+
+func nameField() Field {
+	return Field{Name: "name", Kind: Text, Required: true, MaxLen: 100}
+}
+
+// 캐릭터/배경/파트 공통(0021, 0025, 0026). 생략하면 DB 기본값
+func itemFields() []Field {
+	return []Field{
+		{Name: "preview_asset", Kind: UUID, Nullable: true},
+		{Name: "tags", Kind: Tags, MaxLen: MaxTags},
+		{Name: "rarity", Kind: Text, OneOf: Rarities},
+		{Name: "sort_weight", Kind: Int, Min: -1_000_000, Max: 1_000_000},
+		{Name: "available_from", Kind: Time, Nullable: true},
+		{Name: "available_until", Kind: Time, Nullable: true},
+		{Name: "is_default", Kind: Bool},
+		{Name: "locked", Kind: Bool},
+		{Name: "unlock_source", Kind: Text, Nullable: true, OneOf: UnlockSources}, // locked 일 때만
+		{Name: "price", Kind: Int, Nullable: true, Min: 1, Max: MaxPrice},         // unlock_source=purchase 일 때만
+	}
+}
+
+var entities = []*Entity{
+	{
+		Name: "region", Key: "id", KeyKind: Int, Disable: true, Order: "t.level, t.id",
+		Fields: []Field{
+			{Name: "code", Kind: Text, Nullable: true, MaxLen: 20, Pattern: regexp.MustCompile(`^[0-9A-Za-z_-]+$`)},
+			nameField(),
+			{Name: "parent_id", Kind: Int, Nullable: true, Min: 1, Max: math.MaxInt32},
+			{Name: "latitude", Kind: Float, Nullable: true, Min: -90, Max: 90},
+			{Name: "longitude", Kind: Float, Nullable: true, Min: -180, Max: 180},
+		},
+	},
+	{
+		Name: "job_category", Key: "code", KeyKind: Text, Disable: true, Order: "t.code",
+		Fields: []Field{codeField("code"), nameField()},
+	},
+	{
+		Name: "character_category", Key: "code", KeyKind: Text, Disable: true, Order: "t.code",
+		Fields: []Field{codeField("code"), nameField()},
+	},
+	{
+		Name: "character_item", Key: "id", KeyKind: UUID, Disable: true, Order: "t.category_code, t.name",
+		Fields: append([]Field{
+			{Name: "category_code", Kind: Text, Required: true, Pattern: codeRe},
+			nameField(),
+		}, itemFields()...),
+	},
+	{
+		Name: "bg_item", Key: "id", KeyKind: UUID, Disable: true, Order: "t.name",
+		Fields: append([]Field{nameField()}, itemFields()...),
+	},
+	{
+		// 옷/표정/장신구 슬롯 항목. 슬롯을 바꾸면 이미 고른 사용자의 열이 어긋나므로 바꿀 수 없다
+		Name: "avatar_part", Key: "id", KeyKind: UUID, Disable: true, Order: "t.slot_code, t.name",
+		Fields: append([]Field{
+			{Name: "slot_code", Kind: Text, Required: true, Immutable: true, OneOf: PartSlots},
+			nameField(),
+			{Name: "anchor_x", Kind: Float, Min: 0, Max: 1},
+			{Name: "anchor_y", Kind: Float, Min: 0, Max: 1},
+			{Name: "scale", Kind: Float, Min: 0.01, Max: 1},
+		}, itemFields()...),
+	},
+	{
+		Name: "pref_type", Key: "code", KeyKind: Text, Disable: true, Order: "t.code",
+		Fields: []Field{codeField("code"), nameField()},
+	},
+	{
+		Name: "pref_item", Key: "id", KeyKind: UUID, Disable: true, Order: "t.type_code, t.name",
+		Fields: []Field{{Name: "type_code", Kind: Text, Required: true, Pattern: codeRe}, nameField()},
+	},
+	{
+		// 캐릭터↔배경 호환 규칙(avatar_bg_compatible). 캐릭터 조건을 비우면 모든 캐릭터
+		Name: "avatar_compat_rule", Key: "id", KeyKind: Int, Disable: true, Order: "t.id",
+		Fields: []Field{
+			{Name: "character_category", Kind: Text, Nullable: true, Pattern: codeRe},
+			{Name: "character_tag", Kind: Text, Nullable: true, Pattern: codeRe},
+			{Name: "bg_tag", Kind: Text, Required: true, Pattern: codeRe},
+			{Name: "effect", Kind: Text, Required: true, OneOf: RuleEffects},
+			{Name: "note", Kind: Text, Nullable: true, MaxLen: 200},
+		},
+	},
+	{
+		// 카탈로그 에셋만(사용자 업로드는 owner_id 가 있다). 미리보기가 참조하므로 지우지 않는다
+		Name: "media_asset", Key: "id", KeyKind: UUID, Scope: "t.owner_id IS NULL", Order: "t.created_at, t.id",
+		Fields: []Field{
+			{Name: "kind", Kind: Text, Required: true, OneOf: []string{"image"}},
+			{Name: "url", Kind: URL, Required: true, MaxLen: 2048},
+			{Name: "content_type", Kind: Text, Nullable: true, MaxLen: 100},
+			{Name: "width", Kind: Int, Nullable: true, Min: 1, Max: 20000},
+			{Name: "height", Kind: Int, Nullable: true, Min: 1, Max: 20000},
+		},
+	},
+}
+
+func Entities() []*Entity { return entities }
+
+func Lookup(name string) (*Entity, error) {
+	for _, e := range entities {
+		if e.Name == name {
+			return e, nil
+		}
+	}
+	return nil, ErrUnknownEntity
+}
+
+// 키 문자열이 키 컬럼 형식에 맞는지(틀리면 그런 행은 없다)
+func (e *Entity) ValidKey(key string) bool {
+	switch e.KeyKind {
+	case Int:
+		n, err := strconv.Atoi(key)
+		return err == nil && n > 0 && n <= math.MaxInt32 && strconv.Itoa(n) == key
+	case UUID:
+		return uuidRe.MatchString(key)
+	default:
+		return codeRe.MatchString(key)
+	}
+}
+
+func (e *Entity) field(name string) (Field, bool) {
+	for _, f := range e.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// JSON 본문을 컬럼 값으로 검증·변환. create 면 Required 필드가 모두 있어야 하고,
+// 아니면 준 필드만 바꾸되 Immutable 필드는 받지 않는다
+func (e *Entity) Values(body []byte, create bool) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var in map[string]any
+	if err := dec.Decode(&in); err != nil || in == nil {
+		return nil, &FieldError{Reason: "body must be a JSON object"}
+	}
+	out := map[string]any{}
+	for _, k := range slices.Sorted(maps.Keys(in)) {
+		v := in[k]
+		f, ok := e.field(k)
+		if !ok {
+			return nil, &FieldError{Field: k, Reason: "unknown field"}
+		}
+		if f.Immutable && !create {
+			return nil, &FieldError{Field: k, Reason: "cannot be changed"}
+		}
+		val, err := f.convert(v)
+		if err != nil {
+			return nil, err
+		}
+		out[k] = val
+	}
+	if create {
+		for _, f := range e.Fields {
+			if _, ok := out[f.Name]; f.Required && !ok {
+				return nil, &FieldError{Field: f.Name, Reason: "required"}
+			}
+		}
+	} else if len(out) == 0 {
+		return nil, &FieldError{Reason: "nothing to update"}
+	}
+	return out, nil
+}
+
+func (f Field) convert(v any) (any, error) {
+	bad := func(reason string) error { return &FieldError{Field: f.Name, Reason: reason} }
+	if v == nil {
+		if !f.Nullable {
+			return nil, bad("must not be null")
+		}
+		return nil, nil
+	}
+	switch f.Kind {
+	case Int, Float:
+		num, ok := v.(json.Number)
+		if !ok {
+			return nil, bad("must be a number")
+		}
+		x, err := num.Float64()
+		if err != nil || math.IsInf(x, 0) {
+			return nil, bad("must be a number")
+		}
+		if (f.Min != 0 || f.Max != 0) && (x < f.Min || x > f.Max) {
+			return nil, bad(fmt.Sprintf("must be between %g and %g", f.Min, f.Max))
+		}
+		if f.Kind == Float {
+			return x, nil
+		}
+		n, err := num.Int64()
+		if err != nil {
+			return nil, bad("must be an integer")
+		}
+		return n, nil
+	case Bool:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, bad("must be a boolean")
+		}
+		return b, nil
+	case Tags:
+		return f.tags(v)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return nil, bad("must be a string")
+	}
+	s = strings.TrimSpace(s)
+	if s == "" {
+		if !f.Nullable {
+			return nil, bad("must not be empty")
+		}
+		return nil, nil
+	}
+	if f.MaxLen > 0 && utf8.RuneCountInString(s) > f.MaxLen {
+		return nil, bad(fmt.Sprintf("must be at most %d characters", f.MaxLen))
+	}
+	switch f.Kind {
+	case UUID:
+		if !uuidRe.MatchString(s) {
+			return nil, bad("must be a UUID")
+		}
+	case URL:
+		u, err := url.Parse(s)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, bad("must be an http(s) URL")
+		}
+	case Time:
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, bad("must be an RFC 3339 timestamp")
+		}
+		return t, nil
+	}
+	if f.Pattern != nil && !f.Pattern.MatchString(s) {
+		return nil, bad("has an invalid format")
+	}
+	if len(f.OneOf) > 0 && !slices.Contains(f.OneOf, s) {
+		return nil, bad("must be one of " + strings.Join(f.OneOf, ", "))
+	}
+	return s, nil
+}
+
+func (f Field) tags(v any) ([]string, error) {
+	bad := func(reason string) error { return &FieldError{Field: f.Name, Reason: reason} }
+	list, ok := v.([]any)
+	if !ok {
+		return nil, bad("must be an array of strings")
+	}
+	if len(list) > f.MaxLen {
+		return nil, bad(fmt.Sprintf("must have at most %d items", f.MaxLen))
+	}
+	out := []string{}
+	for _, x := range list {
+		s, ok := x.(string)
+		if !ok || !codeRe.MatchString(s) {
+			return nil, bad("items must match " + codeRe.String())
+		}
+		if slices.Contains(out, s) {
+			return nil, bad("duplicate " + s)
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
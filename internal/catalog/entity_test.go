@@ -0,0 +1,119 @@
+package catalog
+
+import (
+	"errors"
+	"testing"
+)
+
+func mustEntity(t *testing.T, name string) *Entity {
+	t.Helper()
+	e, err := Lookup(name)
+	if err != nil {
+		t.Fatalf("lookup %s: %v", name, err)
+	}
+	return e
+}
+
+func TestLookup_Unknown(t *testing.T) {
+	if _, err := Lookup("app_users"); !errors.Is(err, ErrUnknownEntity) {
+		t.Fatalf("expected ErrUnknownEntity, got %v", err)
+	}
+}
+
+func TestValues(t *testing.T) {
+	tests := []struct {
+		entity string
+		body   string
+		create bool
+		field  string // 비면 성공, "*" 면 본문 전체 오류
+	}{
+		{"job_category", `{"code":"designer","name":"Designer"}`, true, ""},
+		{"job_category", `{"name":"Designer"}`, true, "code"},
+		{"job_category", `{"code":"Bad Code","name":"x"}`, true, "code"},
+		{"job_category", `{"code":"designer"}`, false, "code"}, // 키는 바꿀 수 없다
+		{"job_category", `{"name":"  "}`, false, "name"},
+		{"job_category", `{"name":null}`, false, "name"},
+		{"job_category", `{"label":"x"}`, false, "label"},
+		{"job_category", `{}`, false, "*"},
+		{"job_category", `[1]`, true, "*"},
+		{"region", `{"name":"Gangnam","parent_id":1,"latitude":37.5,"longitude":127.0}`, true, ""},
+		{"region", `{"name":"x","latitude":91}`, true, "latitude"},
+		{"region", `{"parent_id":1.5}`, false, "parent_id"},
+		{"region", `{"parent_id":"1"}`, false, "parent_id"},
+		{"region", `{"parent_id":null,"code":null}`, false, ""},
+		{"character_item", `{"category_code":"animal","name":"Cat","preview_asset":"not-a-uuid"}`, true, "preview_asset"},
+		{"bg_item", `{"name":"Snow","tags":["winter","event_2026"],"rarity":"epic","sort_weight":-5,"available_from":"2026-12-01T00:00:00+09:00","available_until":null,"is_default":true}`, true, ""},
+		{"bg_item", `{"tags":["Winter"]}`, false, "tags"},
+		{"bg_item", `{"tags":["winter","winter"]}`, false, "tags"},
+		{"bg_item", `{"tags":"winter"}`, false, "tags"},
+		{"bg_item", `{"rarity":"mythic"}`, false, "rarity"},
+		{"bg_item", `{"available_from":"2026-12-01"}`, false, "available_from"},
+		{"bg_item", `{"is_default":"yes"}`, false, "is_default"},
+		{"character_item", `{"sort_weight":null}`, false, "sort_weight"},
+		{"bg_item", `{"locked":true,"unlock_source":"purchase"}`, false, ""},
+		{"bg_item", `{"unlock_source":"lottery"}`, false, "unlock_source"},
+		{"bg_item", `{"locked":"yes"}`, false, "locked"},
+		{"bg_item", `{"locked":true,"unlock_source":"purchase","price":500}`, false, ""},
+		{"bg_item", `{"price":0}`, false, "price"},
+		{"bg_item", `{"price":null}`, false, ""},
+		{"avatar_compat_rule", `{"character_category":"animal","bg_tag":"night","effect":"deny"}`, true, ""},
+		{"avatar_compat_rule", `{"character_tag":"winter","bg_tag":"snow","effect":"allow","note":null}`, true, ""},
+		{"avatar_compat_rule", `{"bg_tag":"night","effect":"block"}`, true, "effect"},
+		{"avatar_compat_rule", `{"effect":"deny"}`, true, "bg_tag"},
+		{"avatar_compat_rule", `{"character_tag":"Night Owl"}`, false, "character_tag"},
+		{"avatar_part", `{"slot_code":"accessory","name":"Red Hat","anchor_x":0.5,"anchor_y":0.15,"scale":0.4,"tags":["hat"]}`, true, ""},
+		{"avatar_part", `{"slot_code":"base","name":"Cat"}`, true, "slot_code"},
+		{"avatar_part", `{"name":"Red Hat"}`, true, "slot_code"},
+		{"avatar_part", `{"slot_code":"outfit"}`, false, "slot_code"}, // 슬롯은 바꿀 수 없다
+		{"avatar_part", `{"anchor_y":1.2}`, false, "anchor_y"},
+		{"avatar_part", `{"scale":0}`, false, "scale"},
+		{"media_asset", `{"kind":"image","url":"ftp://example.com/a.png"}`, true, "url"},
+		{"media_asset", `{"kind":"video","url":"https://example.com/a.png"}`, true, "kind"},
+		{"media_asset", `{"kind":"image","url":"https://example.com/a.png","width":0}`, true, "width"},
+	}
+	for _, tt := range tests {
+		vals, err := mustEntity(t, tt.entity).Values([]byte(tt.body), tt.create)
+		var fe *FieldError
+		switch {
+		case tt.field == "":
+			if err != nil {
+				t.Errorf("%s %s: unexpected error %v", tt.entity, tt.body, err)
+			}
+		case !errors.As(err, &fe):
+			t.Errorf("%s %s: expected FieldError, got %v (vals=%v)", tt.entity, tt.body, err, vals)
+		case fe.Field != tt.field && (tt.field != "*" || fe.Field != ""):
+			t.Errorf("%s %s: expected field %q, got %q", tt.entity, tt.body, tt.field, fe.Field)
+		}
+	}
+}
+
+func TestValues_Converts(t *testing.T) {
+	vals, err := mustEntity(t, "region").Values([]byte(`{"name":" Gangnam ","parent_id":12,"latitude":37.5}`), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vals["name"] != "Gangnam" || vals["parent_id"] != int64(12) || vals["latitude"] != 37.5 {
+		t.Fatalf("unexpected values: %#v", vals)
+	}
+}
+
+func TestValidKey(t *testing.T) {
+	tests := []struct {
+		entity, key string
+		want        bool
+	}{
+		{"region", "12", true},
+		{"region", "012", false},
+		{"region", "0", false},
+		{"region", "abc", false},
+		{"character_item", "00000000-0000-0000-0000-000000000000", true},
+		{"character_item", "12", false},
+		{"job_category", "developer", true},
+		{"job_category", "x'; DROP", false},
+	}
+	for _, tt := range tests {
+		if got := mustEntity(t, tt.entity).ValidKey(tt.key); got != tt.want {
+			t.Errorf("%s %q: got %v, want %v", tt.entity, tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,458 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/i18n"
+)
+
+// 카탈로그 정의 파일(YAML/JSON, cmd/catalog). DB id 대신 자연 키로 행을 찾는다:
+// 분류/선호 유형은 code, 캐릭터는 (category, name), 배경은 name, 파트는 (slot, name), 선호 항목은 (type, name),
+// 호환 규칙은 (character_category, character_tag, bg_tag, effect), 에셋은 url.
+// 그래서 캐릭터/배경/파트/선호 항목의 이름을 바꾸면 새 행이 되고, 이전 행은 -prune 으로 숨긴다.
+// 지역은 행정구역 코드 기준인 cmd/regions 로 적재한다
+type File struct {
+	Media               []Media       `json:"media,omitempty" yaml:"media,omitempty"`
+	JobCategories       []Coded       `json:"job_categories,omitempty" yaml:"job_categories,omitempty"`
+	CharacterCategories []Coded       `json:"character_categories,omitempty" yaml:"character_categories,omitempty"`
+	Characters          []CharDef     `json:"characters,omitempty" yaml:"characters,omitempty"`
+	Backgrounds         []BgDef       `json:"backgrounds,omitempty" yaml:"backgrounds,omitempty"`
+	AvatarParts         []PartDef     `json:"avatar_parts,omitempty" yaml:"avatar_parts,omitempty"`
+	CompatRules         []RuleDef     `json:"compat_rules,omitempty" yaml:"compat_rules,omitempty"`
+	PrefTypes           []PrefTypeDef `json:"pref_types,omitempty" yaml:"pref_types,omitempty"`
+}
+
+// locale → 이름
+type Translations map[string]string
+
+// 카탈로그 에셋(owner_id 없음). 미리보기에서 url 로만 참조한 에셋은 목록에 없어도 만든다
+type Media struct {
+	URL         string `json:"url" yaml:"url"`
+	ContentType string `json:"content_type,omitempty" yaml:"content_type,omitempty"`
+	Width       int    `json:"width,omitempty" yaml:"width,omitempty"`
+	Height      int    `json:"height,omitempty" yaml:"height,omitempty"`
+}
+
+type Coded struct {
+	Code         string       `json:"code" yaml:"code"`
+	Name         string       `json:"name" yaml:"name"`
+	Disabled     bool         `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	Translations Translations `json:"translations,omitempty" yaml:"translations,omitempty"`
+}
+
+type CharDef struct {
+	Category     string `json:"category" yaml:"category"`
+	Name         string `json:"name" yaml:"name"`
+	Preview      string `json:"preview,omitempty" yaml:"preview,omitempty"` // media url
+	Disabled     bool   `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	ItemMeta     `yaml:",inline"`
+	Translations Translations `json:"translations,omitempty" yaml:"translations,omitempty"`
+}
+
+type BgDef struct {
+	Name         string `json:"name" yaml:"name"`
+	Preview      string `json:"preview,omitempty" yaml:"preview,omitempty"`
+	Disabled     bool   `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	ItemMeta     `yaml:",inline"`
+	Translations Translations `json:"translations,omitempty" yaml:"translations,omitempty"`
+}
+
+// 옷/표정/장신구 슬롯 항목. 배치를 비우면 DB 기본값(중앙, scale 1)
+type PartDef struct {
+	Slot         string   `json:"slot" yaml:"slot"`
+	Name         string   `json:"name" yaml:"name"`
+	Preview      string   `json:"preview,omitempty" yaml:"preview,omitempty"`
+	AnchorX      *float32 `json:"anchor_x,omitempty" yaml:"anchor_x,omitempty"`
+	AnchorY      *float32 `json:"anchor_y,omitempty" yaml:"anchor_y,omitempty"`
+	Scale        *float32 `json:"scale,omitempty" yaml:"scale,omitempty"`
+	Disabled     bool     `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	ItemMeta     `yaml:",inline"`
+	Translations Translations `json:"translations,omitempty" yaml:"translations,omitempty"`
+}
+
+// 파트 배치(REAL 열이라 float32 로 비교한다)
+type placement struct{ anchorX, anchorY, scale float32 }
+
+func (p PartDef) placement() placement {
+	pl := placement{0.5, 0.5, 1}
+	if p.AnchorX != nil {
+		pl.anchorX = *p.AnchorX
+	}
+	if p.AnchorY != nil {
+		pl.anchorY = *p.AnchorY
+	}
+	if p.Scale != nil {
+		pl.scale = *p.Scale
+	}
+	return pl
+}
+
+// 캐릭터↔배경 호환 규칙. 캐릭터 조건을 비우면 모든 캐릭터
+type RuleDef struct {
+	CharacterCategory string `json:"character_category,omitempty" yaml:"character_category,omitempty"`
+	CharacterTag      string `json:"character_tag,omitempty" yaml:"character_tag,omitempty"`
+	BgTag             string `json:"bg_tag" yaml:"bg_tag"`
+	Effect            string `json:"effect" yaml:"effect"`
+	Note              string `json:"note,omitempty" yaml:"note,omitempty"`
+	Disabled          bool   `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+}
+
+// 사람이 읽는 자연 키(비운 조건은 *)
+func (r RuleDef) key() string {
+	or := func(s string) string {
+		if s == "" {
+			return "*"
+		}
+		return s
+	}
+	return or(r.CharacterCategory) + "/" + or(r.CharacterTag) + " " + r.Effect + " " + r.BgTag
+}
+
+// 캐릭터/배경/파트 속성. 비우면 DB 기본값(태그 없음, common, 0, 기간 제한 없음, 잠기지 않음, 팔지 않음)
+type ItemMeta struct {
+	Tags           []string   `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Rarity         string     `json:"rarity,omitempty" yaml:"rarity,omitempty"`
+	SortWeight     int        `json:"sort_weight,omitempty" yaml:"sort_weight,omitempty"`
+	AvailableFrom  *time.Time `json:"available_from,omitempty" yaml:"available_from,omitempty"`
+	AvailableUntil *time.Time `json:"available_until,omitempty" yaml:"available_until,omitempty"`
+	Default        bool       `json:"is_default,omitempty" yaml:"is_default,omitempty"`
+	Locked         bool       `json:"locked,omitempty" yaml:"locked,omitempty"`
+	UnlockSource   string     `json:"unlock_source,omitempty" yaml:"unlock_source,omitempty"`
+	Price          int        `json:"price,omitempty" yaml:"price,omitempty"`
+}
+
+func (m ItemMeta) rarity() string {
+	if m.Rarity == "" {
+		return "common"
+	}
+	return m.Rarity
+}
+
+// o 와 다른 속성 이름들
+func (m ItemMeta) changed(o ItemMeta) []string {
+	sameTime := func(a, b *time.Time) bool { return a == nil && b == nil || a != nil && b != nil && a.Equal(*b) }
+	var out []string
+	if !slices.Equal(m.Tags, o.Tags) {
+		out = append(out, "tags")
+	}
+	if m.rarity() != o.rarity() {
+		out = append(out, "rarity")
+	}
+	if m.SortWeight != o.SortWeight {
+		out = append(out, "sort_weight")
+	}
+	if !sameTime(m.AvailableFrom, o.AvailableFrom) {
+		out = append(out, "available_from")
+	}
+	if !sameTime(m.AvailableUntil, o.AvailableUntil) {
+		out = append(out, "available_until")
+	}
+	if m.Default != o.Default {
+		out = append(out, "is_default")
+	}
+	if m.Locked != o.Locked {
+		out = append(out, "locked")
+	}
+	if m.UnlockSource != o.UnlockSource {
+		out = append(out, "unlock_source")
+	}
+	if m.Price != o.Price {
+		out = append(out, "price")
+	}
+	return out
+}
+
+func (m ItemMeta) validate() error {
+	if len(m.Tags) > MaxTags {
+		return fmt.Errorf("at most %d tags", MaxTags)
+	}
+	for i, t := range m.Tags {
+		if !ValidTag(t) {
+			return fmt.Errorf("invalid tag %q", t)
+		}
+		if slices.Contains(m.Tags[:i], t) {
+			return fmt.Errorf("duplicate tag %q", t)
+		}
+	}
+	if m.Rarity != "" && !slices.Contains(Rarities, m.Rarity) {
+		return fmt.Errorf("rarity must be one of %s", strings.Join(Rarities, ", "))
+	}
+	if m.SortWeight < -1_000_000 || m.SortWeight > 1_000_000 {
+		return fmt.Errorf("sort_weight out of range")
+	}
+	if m.AvailableFrom != nil && m.AvailableUntil != nil && !m.AvailableUntil.After(*m.AvailableFrom) {
+		return fmt.Errorf("available_until must be after available_from")
+	}
+	if m.UnlockSource != "" && !slices.Contains(UnlockSources, m.UnlockSource) {
+		return fmt.Errorf("unlock_source must be one of %s", strings.Join(UnlockSources, ", "))
+	}
+	if m.UnlockSource != "" && !m.Locked {
+		return fmt.Errorf("unlock_source requires locked")
+	}
+	if m.Price < 0 || m.Price > MaxPrice {
+		return fmt.Errorf("price must be between 1 and %d", MaxPrice)
+	}
+	if m.Price > 0 && m.UnlockSource != "purchase" {
+		return fmt.Errorf("price requires unlock_source purchase")
+	}
+	return nil
+}
+
+type PrefTypeDef struct {
+	Code     string        `json:"code" yaml:"code"`
+	Name     string        `json:"name" yaml:"name"`
+	Disabled bool          `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	Items    []PrefItemDef `json:"items,omitempty" yaml:"items,omitempty"`
+}
+
+type PrefItemDef struct {
+	Name         string       `json:"name" yaml:"name"`
+	Disabled     bool         `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	Translations Translations `json:"translations,omitempty" yaml:"translations,omitempty"`
+}
+
+// 파일 확장자로 형식 결정(.json 이면 JSON, 나머지는 YAML)
+func FormatOf(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return "json"
+	}
+	return "yaml"
+}
+
+// 모르는 키가 있으면 오류(오타로 조용히 빠지는 것을 막는다). 검증까지 한다
+func Decode(r io.Reader, format string) (File, error) {
+	var f File
+	var err error
+	if format == "json" {
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&f)
+	} else {
+		dec := yaml.NewDecoder(r)
+		dec.KnownFields(true)
+		if err = dec.Decode(&f); err == io.EOF {
+			err = nil
+		}
+	}
+	if err != nil {
+		return File{}, err
+	}
+	return f, f.Validate()
+}
+
+func Encode(w io.Writer, f File, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(f)
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(f); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// 관리자 API 와 같은 규칙(Entity 정의)으로 검증하고 자연 키 중복을 막는다
+func (f File) Validate() error {
+	fail := func(where string, format string, args ...any) error {
+		return fmt.Errorf("%s: %s", where, fmt.Sprintf(format, args...))
+	}
+	checkName := func(where, name string) error {
+		if strings.TrimSpace(name) != name || name == "" || utf8.RuneCountInString(name) > 100 {
+			return fail(where, "name must be 1..100 characters without surrounding spaces")
+		}
+		return nil
+	}
+	checkURL := func(where, s string) error {
+		u, err := url.Parse(s)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fail(where, "%q is not an http(s) URL", s)
+		}
+		return nil
+	}
+	checkTr := func(where string, tr Translations) error {
+		for loc, name := range tr {
+			if !slices.Contains(i18n.Supported, loc) {
+				return fail(where, "unsupported locale %q", loc)
+			}
+			if err := checkName(where+"."+loc, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	seen := map[string]bool{}
+	dup := func(where, key string) error {
+		if seen[key] {
+			return fail(where, "duplicate %s", key)
+		}
+		seen[key] = true
+		return nil
+	}
+
+	for i, m := range f.Media {
+		where := fmt.Sprintf("media[%d]", i)
+		if err := checkURL(where, m.URL); err != nil {
+			return err
+		}
+		if m.Width < 0 || m.Height < 0 {
+			return fail(where, "width/height must be positive")
+		}
+		if err := dup(where, "media "+m.URL); err != nil {
+			return err
+		}
+	}
+	coded := func(section string, items []Coded) error {
+		for i, c := range items {
+			where := fmt.Sprintf("%s[%d]", section, i)
+			if !codeRe.MatchString(c.Code) {
+				return fail(where, "invalid code %q", c.Code)
+			}
+			if err := checkName(where, c.Name); err != nil {
+				return err
+			}
+			if err := checkTr(where, c.Translations); err != nil {
+				return err
+			}
+			if err := dup(where, section+" "+c.Code); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := coded("job_categories", f.JobCategories); err != nil {
+		return err
+	}
+	if err := coded("character_categories", f.CharacterCategories); err != nil {
+		return err
+	}
+	for i, c := range f.Characters {
+		where := fmt.Sprintf("characters[%d]", i)
+		if !codeRe.MatchString(c.Category) {
+			return fail(where, "invalid category %q", c.Category)
+		}
+		if err := checkName(where, c.Name); err != nil {
+			return err
+		}
+		if c.Preview != "" {
+			if err := checkURL(where, c.Preview); err != nil {
+				return err
+			}
+		}
+		if err := c.ItemMeta.validate(); err != nil {
+			return fail(where, "%v", err)
+		}
+		if err := checkTr(where, c.Translations); err != nil {
+			return err
+		}
+		if err := dup(where, "character "+c.Category+"/"+c.Name); err != nil {
+			return err
+		}
+	}
+	for i, b := range f.Backgrounds {
+		where := fmt.Sprintf("backgrounds[%d]", i)
+		if err := checkName(where, b.Name); err != nil {
+			return err
+		}
+		if b.Preview != "" {
+			if err := checkURL(where, b.Preview); err != nil {
+				return err
+			}
+		}
+		if err := b.ItemMeta.validate(); err != nil {
+			return fail(where, "%v", err)
+		}
+		if err := checkTr(where, b.Translations); err != nil {
+			return err
+		}
+		if err := dup(where, "background "+b.Name); err != nil {
+			return err
+		}
+	}
+	for i, p := range f.AvatarParts {
+		where := fmt.Sprintf("avatar_parts[%d]", i)
+		if !slices.Contains(PartSlots, p.Slot) {
+			return fail(where, "slot must be one of %s", strings.Join(PartSlots, ", "))
+		}
+		if err := checkName(where, p.Name); err != nil {
+			return err
+		}
+		if p.Preview != "" {
+			if err := checkURL(where, p.Preview); err != nil {
+				return err
+			}
+		}
+		if pl := p.placement(); pl.anchorX < 0 || pl.anchorX > 1 || pl.anchorY < 0 || pl.anchorY > 1 {
+			return fail(where, "anchor_x/anchor_y must be between 0 and 1")
+		} else if pl.scale < 0.01 || pl.scale > 1 {
+			return fail(where, "scale must be between 0.01 and 1")
+		}
+		if err := p.ItemMeta.validate(); err != nil {
+			return fail(where, "%v", err)
+		}
+		if err := checkTr(where, p.Translations); err != nil {
+			return err
+		}
+		if err := dup(where, "avatar_part "+p.Slot+"/"+p.Name); err != nil {
+			return err
+		}
+	}
+	for i, r := range f.CompatRules {
+		where := fmt.Sprintf("compat_rules[%d]", i)
+		if r.CharacterCategory != "" && !codeRe.MatchString(r.CharacterCategory) {
+			return fail(where, "invalid character_category %q", r.CharacterCategory)
+		}
+		if r.CharacterTag != "" && !ValidTag(r.CharacterTag) {
+			return fail(where, "invalid character_tag %q", r.CharacterTag)
+		}
+		if !ValidTag(r.BgTag) {
+			return fail(where, "invalid bg_tag %q", r.BgTag)
+		}
+		if !slices.Contains(RuleEffects, r.Effect) {
+			return fail(where, "effect must be one of %s", strings.Join(RuleEffects, ", "))
+		}
+		if utf8.RuneCountInString(r.Note) > 200 {
+			return fail(where, "note must be at most 200 characters")
+		}
+		if err := dup(where, "compat_rule "+r.key()); err != nil {
+			return err
+		}
+	}
+	for i, p := range f.PrefTypes {
+		where := fmt.Sprintf("pref_types[%d]", i)
+		if !codeRe.MatchString(p.Code) {
+			return fail(where, "invalid code %q", p.Code)
+		}
+		if err := checkName(where, p.Name); err != nil {
+			return err
+		}
+		if err := dup(where, "pref_type "+p.Code); err != nil {
+			return err
+		}
+		for j, it := range p.Items {
+			where := fmt.Sprintf("%s.items[%d]", where, j)
+			if err := checkName(where, it.Name); err != nil {
+				return err
+			}
+			if err := checkTr(where, it.Translations); err != nil {
+				return err
+			}
+			if err := dup(where, "pref_item "+p.Code+"/"+it.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
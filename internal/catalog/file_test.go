@@ -0,0 +1,253 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestDecode_SeedFile(t *testing.T) {
+	r, err := os.Open("../../db/catalog/catalog.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	f, err := Decode(r, "yaml")
+	if err != nil {
+		t.Fatalf("seed file invalid: %v", err)
+	}
+	if len(f.JobCategories) == 0 || len(f.Characters) == 0 || f.Characters[0].Translations["ko"] == "" {
+		t.Fatalf("unexpected seed: %+v", f)
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	tests := []struct{ name, format, body, want string }{
+		{"unknown key", "yaml", "job_categories:\n  - code: dev\n    nmae: Developer\n", "nmae"},
+		{"unknown key json", "json", `{"job_categories":[{"code":"dev","name":"Developer","x":1}]}`, "unknown field"},
+		{"bad code", "yaml", "job_categories:\n  - code: Dev\n    name: Developer\n", "invalid code"},
+		{"duplicate", "yaml", "backgrounds:\n  - name: Beach\n  - name: Beach\n", "duplicate"},
+		{"locale", "yaml", "character_categories:\n  - code: basic\n    name: Basic\n    translations: {fr: Base}\n", "unsupported locale"},
+		{"preview", "yaml", "characters:\n  - category: basic\n    name: Cat\n    preview: cat.png\n", "not an http(s) URL"},
+		{"rarity", "yaml", "backgrounds:\n  - name: Beach\n    rarity: mythic\n", "rarity must be one of"},
+		{"window", "yaml", "backgrounds:\n  - name: Beach\n    available_from: 2026-12-01T00:00:00Z\n    available_until: 2026-11-01T00:00:00Z\n", "available_until"},
+		{"unlock", "yaml", "backgrounds:\n  - name: Beach\n    locked: true\n    unlock_source: lottery\n", "unlock_source must be one of"},
+		{"unlock unlocked", "yaml", "backgrounds:\n  - name: Beach\n    unlock_source: purchase\n", "requires locked"},
+		{"price", "yaml", "backgrounds:\n  - name: Beach\n    locked: true\n    unlock_source: purchase\n    price: 2000000\n", "price must be between"},
+		{"price source", "yaml", "backgrounds:\n  - name: Beach\n    locked: true\n    unlock_source: admin\n    price: 100\n", "requires unlock_source purchase"},
+		{"tag", "json", `{"backgrounds":[{"name":"Beach","tags":["summer","summer"]}]}`, "duplicate tag"},
+		{"slot", "yaml", "avatar_parts:\n  - slot: base\n    name: Cat\n", "slot must be one of"},
+		{"anchor", "yaml", "avatar_parts:\n  - slot: accessory\n    name: Hat\n    anchor_y: 1.2\n", "anchor_x/anchor_y"},
+		{"scale", "json", `{"avatar_parts":[{"slot":"outfit","name":"Coat","scale":0}]}`, "scale must be between"},
+		{"part duplicate", "yaml", "avatar_parts:\n  - slot: outfit\n    name: Coat\n  - slot: outfit\n    name: Coat\n", "duplicate"},
+		{"effect", "yaml", "compat_rules:\n  - bg_tag: night\n    effect: block\n", "effect must be one of"},
+		{"bg_tag", "yaml", "compat_rules:\n  - effect: deny\n", "invalid bg_tag"},
+		{"rule duplicate", "yaml", "compat_rules:\n  - bg_tag: night\n    effect: deny\n    note: a\n  - bg_tag: night\n    effect: deny\n", "duplicate"},
+		{"padded name", "json", `{"pref_types":[{"code":"hobby","name":"Hobby","items":[{"name":" Hiking"}]}]}`, "pref_types[0].items[0]"},
+	}
+	for _, tt := range tests {
+		_, err := Decode(strings.NewReader(tt.body), tt.format)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func sample() File {
+	from := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+	return File{
+		Media:               []Media{{URL: "https://cdn.example.com/cat.png", ContentType: "image/png", Width: 256, Height: 256}},
+		JobCategories:       []Coded{{Code: "dev", Name: "Developer", Translations: Translations{"ko": "개발자"}}},
+		CharacterCategories: []Coded{{Code: "animal", Name: "Animal"}},
+		Characters: []CharDef{
+			{Category: "animal", Name: "Cat", Preview: "https://cdn.example.com/cat.png", Translations: Translations{"ko": "고양이"},
+				ItemMeta: ItemMeta{Tags: []string{"cute", "winter"}, Rarity: "rare", SortWeight: -1, AvailableFrom: &from, Default: true}},
+			{Category: "animal", Name: "Dog", Preview: "https://cdn.example.com/dog.png", Disabled: true},
+		},
+		Backgrounds: []BgDef{{Name: "Beach"}},
+		AvatarParts: []PartDef{{Slot: "accessory", Name: "Red Hat", Preview: "https://cdn.example.com/hat.png",
+			AnchorY: ptr[float32](0.15), Scale: ptr[float32](0.4), ItemMeta: ItemMeta{Tags: []string{"hat"}}, Translations: Translations{"ko": "빨간 모자"}}},
+		CompatRules: []RuleDef{{CharacterCategory: "animal", BgTag: "night", Effect: "deny", Note: "animals sleep"}},
+		PrefTypes:   []PrefTypeDef{{Code: "hobby", Name: "Hobby", Items: []PrefItemDef{{Name: "Hiking", Translations: Translations{"ko": "등산"}}}}},
+	}
+}
+
+func TestEncode_RoundTrip(t *testing.T) {
+	for _, format := range []string{"yaml", "json"} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, sample(), format); err != nil {
+			t.Fatal(err)
+		}
+		got, err := Decode(&buf, format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if !reflect.DeepEqual(got, sample()) {
+			t.Fatalf("%s round trip mismatch:\n%+v\n%+v", format, got, sample())
+		}
+	}
+}
+
+func ops(changes []Change) []string {
+	out := make([]string, len(changes))
+	for i, c := range changes {
+		out[i] = c.Op + " " + c.Entity + " " + c.Key
+	}
+	return out
+}
+
+func TestDiff_FromEmpty(t *testing.T) {
+	got := ops(Diff(sample(), File{}, false))
+	want := []string{
+		"create media_asset https://cdn.example.com/cat.png",
+		"create media_asset https://cdn.example.com/dog.png", // 미리보기로만 참조
+		"create media_asset https://cdn.example.com/hat.png",
+		"create job_category dev",
+		"translate job_category dev",
+		"create character_category animal",
+		"create pref_type hobby",
+		"create character_item animal/Cat",
+		"translate character_item animal/Cat",
+		"create character_item animal/Dog",
+		"create bg_item Beach",
+		"create avatar_part accessory/Red Hat",
+		"translate avatar_part accessory/Red Hat",
+		"create pref_item hobby/Hiking",
+		"translate pref_item hobby/Hiking",
+		"create avatar_compat_rule animal/* deny night",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDiff_Idempotent(t *testing.T) {
+	if got := Diff(sample(), sample(), true); len(got) != 0 {
+		t.Fatalf("expected no changes, got %v", ops(got))
+	}
+}
+
+func TestDiff_Changes(t *testing.T) {
+	have := sample()
+	have.JobCategories = append(have.JobCategories, Coded{Code: "sales", Name: "Sales", Translations: Translations{"ko": "영업"}})
+	have.Characters[0].Translations["en"] = "Kitty"
+	have.AvatarParts = append(have.AvatarParts, PartDef{Slot: "outfit", Name: "Coat"})
+	have.CompatRules = append(have.CompatRules, RuleDef{BgTag: "rain", Effect: "deny"})
+
+	want := sample()
+	want.Media[0].Width = 512
+	want.JobCategories[0].Name = "Engineer"
+	want.JobCategories[0].Translations["ko"] = "엔지니어"
+	want.Characters[0].Preview = ""
+	want.Characters[1].Disabled = false
+	want.Backgrounds[0].Disabled = true
+	want.Backgrounds[0].Locked, want.Backgrounds[0].UnlockSource, want.Backgrounds[0].Price = true, "purchase", 300
+	want.Characters[1].Rarity = "epic"
+	want.AvatarParts[0].AnchorY = nil
+	want.CompatRules[0].Note = ""
+	want.CompatRules = append(want.CompatRules, RuleDef{CharacterTag: "winter", BgTag: "snow", Effect: "allow"})
+
+	changes := Diff(want, have, false)
+	got := ops(changes)
+	expect := []string{
+		"update media_asset https://cdn.example.com/cat.png",
+		"update job_category dev",
+		"translate job_category dev",
+		"update character_item animal/Cat",
+		"update character_item animal/Dog",
+		"enable character_item animal/Dog",
+		"update bg_item Beach",
+		"disable bg_item Beach",
+		"update avatar_part accessory/Red Hat",
+		"update avatar_compat_rule animal/* deny night",
+		"create avatar_compat_rule */winter allow snow",
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Fatalf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(expect, "\n"))
+	}
+	if d := changes[4].Detail; d != "rarity" {
+		t.Fatalf("expected rarity detail, got %q", d)
+	}
+	if d := changes[6].Detail; d != "locked, unlock_source, price" {
+		t.Fatalf("expected lock detail, got %q", d)
+	}
+	if d := changes[8].Detail; d != "placement (0.5, 0.15) x0.4 → (0.5, 0.5) x0.4" {
+		t.Fatalf("expected placement detail, got %q", d)
+	}
+
+	// 기본값을 적지 않은 것과 기본값을 적은 것은 같다
+	same := sample()
+	same.Backgrounds[0].Rarity = "common"
+	same.AvatarParts[0].AnchorX = ptr[float32](0.5)
+	if got := Diff(same, sample(), false); len(got) != 0 {
+		t.Fatalf("explicit defaults must not change anything, got %v", ops(got))
+	}
+
+	// prune: 파일에 없는 행은 숨기고 없는 번역은 지운다
+	got = ops(Diff(want, have, true))
+	for _, s := range []string{"disable job_category sales", "untranslate character_item animal/Cat",
+		"disable avatar_part outfit/Coat", "disable avatar_compat_rule */* deny rain"} {
+		if !strings.Contains(strings.Join(got, "\n"), s) {
+			t.Fatalf("prune: missing %q in %v", s, got)
+		}
+	}
+}
+
+// 실행된 문장과 인자를 기록
+type execLog struct {
+	sqls []string
+	args [][]any
+}
+
+func (r *execLog) Exec(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	r.sqls = append(r.sqls, sql)
+	r.args = append(r.args, args)
+	return pgconn.CommandTag{}, nil
+}
+
+func (r *execLog) Query(context.Context, string, ...any) (pgx.Rows, error) { panic("unexpected Query") }
+func (r *execLog) QueryRow(context.Context, string, ...any) pgx.Row        { panic("unexpected QueryRow") }
+
+// 모든 변경 문장의 자리표시자가 인자 수와 맞는지
+func TestApply_Placeholders(t *testing.T) {
+	have := sample()
+	have.JobCategories = append(have.JobCategories, Coded{Code: "sales", Name: "Sales", Translations: Translations{"en": "Sales"}})
+	want := sample()
+	want.JobCategories[0].Name = "Engineer"
+	want.Characters[0].Preview = "https://cdn.example.com/cat2.png"
+	want.Characters[0].Tags = nil
+	want.AvatarParts[0].Scale = nil
+	want.CompatRules[0].Note = "changed"
+	have.CompatRules = append(have.CompatRules, RuleDef{CharacterTag: "winter", BgTag: "snow", Effect: "allow"})
+	changes := append(Diff(sample(), File{}, false), Diff(want, have, true)...)
+
+	var r execLog
+	if err := Apply(context.Background(), &r, changes); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.sqls) != len(changes) {
+		t.Fatalf("expected %d statements, got %d", len(changes), len(r.sqls))
+	}
+	ph := regexp.MustCompile(`\$(\d+)`)
+	for i, sql := range r.sqls {
+		maxN := 0
+		for _, m := range ph.FindAllStringSubmatch(sql, -1) {
+			n, _ := strconv.Atoi(m[1])
+			maxN = max(maxN, n)
+		}
+		if maxN != len(r.args[i]) {
+			t.Errorf("%s: %d placeholders, %d args\n%s", changes[i], maxN, len(r.args[i]), sql)
+		}
+	}
+}
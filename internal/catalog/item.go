@@ -0,0 +1,53 @@
+package catalog
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// 캐릭터/배경/파트 희귀도(낮은 것부터)
+var Rarities = []string{"common", "rare", "epic", "legendary"}
+
+// 잠긴 항목을 얻는 경로(unlock_source, user_inventory.source)
+var UnlockSources = []string{"onboarding", "achievement", "purchase", "admin"}
+
+// avatar_part 슬롯(base/background 는 캐릭터/배경)
+var PartSlots = []string{"outfit", "expression", "accessory"}
+
+// avatar_compat_rule.effect
+var RuleEffects = []string{"allow", "deny"}
+
+// 항목 가격(코인) 상한(chk_*_price)
+const MaxPrice = 1_000_000
+
+// 항목당 태그 수 상한(chk_*_tags)
+const MaxTags = 20
+
+// 태그는 code 와 같은 형식
+func ValidTag(s string) bool { return codeRe.MatchString(s) }
+
+// 지금 노출 기간 안인지(SQL, alias 는 character_item/bg_item/avatar_part 별칭). 기간이 비면 제한 없음
+func Available(alias string) string {
+	return `((` + alias + `.available_from IS NULL OR ` + alias + `.available_from <= now()) AND (` +
+		alias + `.available_until IS NULL OR now() < ` + alias + `.available_until))`
+}
+
+// 노출 기간 경계를 지나면 카탈로그 버전을 올려 meta 캐시/ETag 를 무효화한다(ctx 가 끝날 때까지)
+func WatchWindows(ctx context.Context, pool *pgxpool.Pool, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		var bumped bool
+		if err := pool.QueryRow(ctx, `SELECT bump_catalog_version_for_windows()`).Scan(&bumped); err != nil && ctx.Err() == nil {
+			log.Printf("catalog windows: %v", err)
+		}
+	}
+}
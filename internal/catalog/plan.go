@@ -0,0 +1,716 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+	"github.com/creators-of-happiness/amigo-backend/internal/i18n"
+)
+
+// 정의 파일과 DB 의 차이. 바뀌는 행에만 문장을 실행하므로(버전 트리거는 문장마다 돈다)
+// 같은 파일을 다시 적재하면 아무것도 바뀌지 않는다
+
+const (
+	OpCreate      = "create"
+	OpUpdate      = "update"
+	OpDisable     = "disable"
+	OpEnable      = "enable"
+	OpTranslate   = "translate"
+	OpUntranslate = "untranslate"
+)
+
+type Change struct {
+	Op     string
+	Entity string // 테이블 이름
+	Key    string // 자연 키(사람이 읽는 용도)
+	Detail string
+
+	keys   []any // 자연 키 값(table.keys 순서)
+	row    any   // create/update 할 정의
+	locale string
+	name   string
+}
+
+func (c Change) String() string {
+	s := fmt.Sprintf("%-11s %-18s %s", c.Op, c.Entity, c.Key)
+	if c.Detail != "" {
+		s += "  (" + c.Detail + ")"
+	}
+	return s
+}
+
+// 자연 키로 행을 찾는 방법
+type table struct {
+	keys     []string // 자연 키 컬럼
+	keyCol   string   // translation.entity_key 로 쓰는 식
+	scope    string
+	nullKeys bool // 자연 키가 NULL 일 수 있다(빈 문자열 = NULL)
+}
+
+var tables = map[string]table{
+	"media_asset":        {keys: []string{"url"}, keyCol: "id::text", scope: "owner_id IS NULL"},
+	"job_category":       {keys: []string{"code"}, keyCol: "code"},
+	"character_category": {keys: []string{"code"}, keyCol: "code"},
+	"pref_type":          {keys: []string{"code"}, keyCol: "code"},
+	"character_item":     {keys: []string{"category_code", "name"}, keyCol: "id::text"},
+	"bg_item":            {keys: []string{"name"}, keyCol: "id::text"},
+	"avatar_part":        {keys: []string{"slot_code", "name"}, keyCol: "id::text"},
+	"avatar_compat_rule": {keys: []string{"character_category", "character_tag", "bg_tag", "effect"}, nullKeys: true},
+	"pref_item":          {keys: []string{"type_code", "name"}, keyCol: "id::text"},
+}
+
+// $first 부터 자연 키 조건
+func (t table) where(first int) string {
+	conds := make([]string, 0, len(t.keys)+1)
+	for i, k := range t.keys {
+		if t.nullKeys {
+			conds = append(conds, k+" IS NOT DISTINCT FROM NULLIF($"+strconv.Itoa(first+i)+", '')")
+		} else {
+			conds = append(conds, k+" = $"+strconv.Itoa(first+i))
+		}
+	}
+	if t.scope != "" {
+		conds = append(conds, t.scope)
+	}
+	return strings.Join(conds, " AND ")
+}
+
+// 미리보기 url 로 카탈로그 에셋 id(같은 url 이 여럿이면 가장 오래된 것)
+func previewID(param string) string {
+	return `(SELECT id FROM media_asset WHERE url = ` + param + ` AND owner_id IS NULL ORDER BY created_at, id LIMIT 1)`
+}
+
+// want 로 맞추기 위한 변경 목록. prune 이면 파일에 없는 행은 숨기고 파일에 없는 번역은 지운다.
+// 에셋은 숨기지 않는다
+func Diff(want, have File, prune bool) []Change {
+	var out []Change
+
+	// 에셋: 목록에 있는 것 + 미리보기로만 참조한 것
+	haveMedia := map[string]Media{}
+	for _, m := range have.Media {
+		haveMedia[m.URL] = m
+	}
+	listed := map[string]bool{}
+	for _, m := range want.Media {
+		listed[m.URL] = true
+		h, ok := haveMedia[m.URL]
+		switch {
+		case !ok:
+			out = append(out, Change{Op: OpCreate, Entity: "media_asset", Key: m.URL, keys: []any{m.URL}, row: m})
+			haveMedia[m.URL] = m
+		case h != m:
+			out = append(out, Change{Op: OpUpdate, Entity: "media_asset", Key: m.URL, Detail: mediaDetail(h, m), keys: []any{m.URL}, row: m})
+		}
+	}
+	var previews []string
+	for _, c := range want.Characters {
+		previews = append(previews, c.Preview)
+	}
+	for _, b := range want.Backgrounds {
+		previews = append(previews, b.Preview)
+	}
+	for _, p := range want.AvatarParts {
+		previews = append(previews, p.Preview)
+	}
+	// 현재 미리보기가 가리키는 에셋도 이미 있는 것
+	exists := map[string]bool{}
+	for _, c := range have.Characters {
+		exists[c.Preview] = true
+	}
+	for _, b := range have.Backgrounds {
+		exists[b.Preview] = true
+	}
+	for _, p := range have.AvatarParts {
+		exists[p.Preview] = true
+	}
+	for _, u := range previews {
+		if _, ok := haveMedia[u]; u != "" && !ok && !listed[u] && !exists[u] {
+			m := Media{URL: u}
+			out = append(out, Change{Op: OpCreate, Entity: "media_asset", Key: u, keys: []any{u}, row: m})
+			haveMedia[u] = m
+		}
+	}
+
+	out = append(out, diffCoded("job_category", want.JobCategories, have.JobCategories, prune)...)
+	out = append(out, diffCoded("character_category", want.CharacterCategories, have.CharacterCategories, prune)...)
+
+	var wantTypes, haveTypes []Coded
+	for _, p := range want.PrefTypes {
+		wantTypes = append(wantTypes, Coded{Code: p.Code, Name: p.Name, Disabled: p.Disabled})
+	}
+	for _, p := range have.PrefTypes {
+		haveTypes = append(haveTypes, Coded{Code: p.Code, Name: p.Name, Disabled: p.Disabled})
+	}
+	out = append(out, diffCoded("pref_type", wantTypes, haveTypes, prune)...)
+
+	type item struct {
+		keys     []any
+		preview  *string // 미리보기/속성이 없는 항목(선호 항목)은 nil
+		meta     *ItemMeta
+		place    *placement // 파트만
+		disabled bool
+		tr       Translations
+	}
+	diffItems := func(entity string, want, have map[string]item, order []string, haveOrder []string) {
+		for _, k := range order {
+			w := want[k]
+			h, ok := have[k]
+			switch {
+			case !ok:
+				out = append(out, Change{Op: OpCreate, Entity: entity, Key: k, keys: w.keys, row: itemRow{w.preview, w.meta, w.place, !w.disabled}})
+				out = append(out, diffTr(entity, k, w.keys, w.tr, nil, false)...)
+				continue
+			case w.preview != nil:
+				var detail []string
+				if *w.preview != *h.preview {
+					detail = append(detail, fmt.Sprintf("preview %q → %q", *h.preview, *w.preview))
+				}
+				if w.place != nil && *w.place != *h.place {
+					detail = append(detail, fmt.Sprintf("placement (%g, %g) x%g → (%g, %g) x%g",
+						h.place.anchorX, h.place.anchorY, h.place.scale, w.place.anchorX, w.place.anchorY, w.place.scale))
+				}
+				detail = append(detail, w.meta.changed(*h.meta)...)
+				if len(detail) > 0 {
+					out = append(out, Change{Op: OpUpdate, Entity: entity, Key: k,
+						Detail: strings.Join(detail, ", "), keys: w.keys, row: itemRow{w.preview, w.meta, w.place, !w.disabled}})
+				}
+			}
+			if w.disabled != h.disabled {
+				out = append(out, toggle(entity, k, w.keys, w.disabled))
+			}
+			out = append(out, diffTr(entity, k, w.keys, w.tr, h.tr, prune)...)
+		}
+		if !prune {
+			return
+		}
+		for _, k := range haveOrder {
+			if h := have[k]; want[k].keys == nil && !h.disabled {
+				out = append(out, toggle(entity, k, h.keys, true))
+			}
+		}
+	}
+	collectChars := func(items []CharDef) (map[string]item, []string) {
+		m := map[string]item{}
+		var order []string
+		for _, c := range items {
+			k := c.Category + "/" + c.Name
+			p := c.Preview
+			m[k] = item{keys: []any{c.Category, c.Name}, preview: &p, meta: &c.ItemMeta, disabled: c.Disabled, tr: c.Translations}
+			order = append(order, k)
+		}
+		return m, order
+	}
+	collectBgs := func(items []BgDef) (map[string]item, []string) {
+		m := map[string]item{}
+		var order []string
+		for _, b := range items {
+			p := b.Preview
+			m[b.Name] = item{keys: []any{b.Name}, preview: &p, meta: &b.ItemMeta, disabled: b.Disabled, tr: b.Translations}
+			order = append(order, b.Name)
+		}
+		return m, order
+	}
+	collectParts := func(items []PartDef) (map[string]item, []string) {
+		m := map[string]item{}
+		var order []string
+		for _, p := range items {
+			k := p.Slot + "/" + p.Name
+			pv, pl := p.Preview, p.placement()
+			m[k] = item{keys: []any{p.Slot, p.Name}, preview: &pv, meta: &p.ItemMeta, place: &pl, disabled: p.Disabled, tr: p.Translations}
+			order = append(order, k)
+		}
+		return m, order
+	}
+	collectPrefs := func(types []PrefTypeDef) (map[string]item, []string) {
+		m := map[string]item{}
+		var order []string
+		for _, p := range types {
+			for _, it := range p.Items {
+				k := p.Code + "/" + it.Name
+				m[k] = item{keys: []any{p.Code, it.Name}, disabled: it.Disabled, tr: it.Translations}
+				order = append(order, k)
+			}
+		}
+		return m, order
+	}
+	wc, wo := collectChars(want.Characters)
+	hc, ho := collectChars(have.Characters)
+	diffItems("character_item", wc, hc, wo, ho)
+	wb, wo := collectBgs(want.Backgrounds)
+	hb, ho := collectBgs(have.Backgrounds)
+	diffItems("bg_item", wb, hb, wo, ho)
+	wa, wo := collectParts(want.AvatarParts)
+	ha, ho := collectParts(have.AvatarParts)
+	diffItems("avatar_part", wa, ha, wo, ho)
+	wp, wo := collectPrefs(want.PrefTypes)
+	hp, ho := collectPrefs(have.PrefTypes)
+	diffItems("pref_item", wp, hp, wo, ho)
+	out = append(out, diffRules(want.CompatRules, have.CompatRules, prune)...)
+	return out
+}
+
+// 호환 규칙은 조건 전체가 자연 키라 note 와 숨김만 바뀐다
+func diffRules(want, have []RuleDef, prune bool) []Change {
+	const entity = "avatar_compat_rule"
+	keys := func(r RuleDef) []any { return []any{r.CharacterCategory, r.CharacterTag, r.BgTag, r.Effect} }
+	var out []Change
+	haveBy := map[string]RuleDef{}
+	for _, h := range have {
+		haveBy[h.key()] = h
+	}
+	wanted := map[string]bool{}
+	for _, w := range want {
+		k := w.key()
+		wanted[k] = true
+		h, ok := haveBy[k]
+		if !ok {
+			out = append(out, Change{Op: OpCreate, Entity: entity, Key: k, keys: keys(w), row: w})
+			continue
+		}
+		if w.Note != h.Note {
+			out = append(out, Change{Op: OpUpdate, Entity: entity, Key: k,
+				Detail: fmt.Sprintf("note %q → %q", h.Note, w.Note), keys: keys(w), row: w})
+		}
+		if w.Disabled != h.Disabled {
+			out = append(out, toggle(entity, k, keys(w), w.Disabled))
+		}
+	}
+	if prune {
+		for _, h := range have {
+			if !wanted[h.key()] && !h.Disabled {
+				out = append(out, toggle(entity, h.key(), keys(h), true))
+			}
+		}
+	}
+	return out
+}
+
+func diffCoded(entity string, want, have []Coded, prune bool) []Change {
+	var out []Change
+	haveBy := map[string]Coded{}
+	for _, h := range have {
+		haveBy[h.Code] = h
+	}
+	wanted := map[string]bool{}
+	for _, w := range want {
+		wanted[w.Code] = true
+		keys := []any{w.Code}
+		h, ok := haveBy[w.Code]
+		if !ok {
+			out = append(out, Change{Op: OpCreate, Entity: entity, Key: w.Code, keys: keys, row: w})
+			out = append(out, diffTr(entity, w.Code, keys, w.Translations, nil, false)...)
+			continue
+		}
+		if w.Name != h.Name {
+			out = append(out, Change{Op: OpUpdate, Entity: entity, Key: w.Code,
+				Detail: fmt.Sprintf("name %q → %q", h.Name, w.Name), keys: keys, row: w})
+		}
+		if w.Disabled != h.Disabled {
+			out = append(out, toggle(entity, w.Code, keys, w.Disabled))
+		}
+		out = append(out, diffTr(entity, w.Code, keys, w.Translations, h.Translations, prune)...)
+	}
+	if prune {
+		for _, h := range have {
+			if !wanted[h.Code] && !h.Disabled {
+				out = append(out, toggle(entity, h.Code, []any{h.Code}, true))
+			}
+		}
+	}
+	return out
+}
+
+// pref_type 은 번역 대상이 아니다
+func diffTr(entity, key string, keys []any, want, have Translations, prune bool) []Change {
+	if entity == "pref_type" {
+		return nil
+	}
+	var out []Change
+	for _, loc := range i18n.Supported {
+		w, inWant := want[loc]
+		h, inHave := have[loc]
+		switch {
+		case inWant && (!inHave || w != h):
+			out = append(out, Change{Op: OpTranslate, Entity: entity, Key: key, Detail: fmt.Sprintf("%s %q", loc, w),
+				keys: keys, locale: loc, name: w})
+		case !inWant && inHave && prune:
+			out = append(out, Change{Op: OpUntranslate, Entity: entity, Key: key, Detail: loc, keys: keys, locale: loc})
+		}
+	}
+	return out
+}
+
+// 캐릭터/배경/파트/선호 항목 행
+type itemRow struct {
+	preview *string
+	meta    *ItemMeta
+	place   *placement
+	active  bool
+}
+
+// 속성 컬럼과 값(itemRow.meta 가 있을 때)
+var itemMetaCols = []string{"tags", "rarity", "sort_weight", "available_from", "available_until", "is_default", "locked", "unlock_source", "price"}
+
+func (m ItemMeta) args() []any {
+	tags := m.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	var unlock *string
+	if m.UnlockSource != "" {
+		unlock = &m.UnlockSource
+	}
+	var price *int
+	if m.Price > 0 {
+		price = &m.Price
+	}
+	return []any{tags, m.rarity(), m.SortWeight, m.AvailableFrom, m.AvailableUntil, m.Default, m.Locked, unlock, price}
+}
+
+// 배치 컬럼과 값(itemRow.place 가 있을 때)
+var placementCols = []string{"anchor_x", "anchor_y", "scale"}
+
+func (p placement) args() []any { return []any{p.anchorX, p.anchorY, p.scale} }
+
+func toggle(entity, key string, keys []any, disabled bool) Change {
+	if disabled {
+		return Change{Op: OpDisable, Entity: entity, Key: key, keys: keys}
+	}
+	return Change{Op: OpEnable, Entity: entity, Key: key, keys: keys}
+}
+
+func mediaDetail(h, w Media) string {
+	var parts []string
+	if h.ContentType != w.ContentType {
+		parts = append(parts, fmt.Sprintf("content_type %q → %q", h.ContentType, w.ContentType))
+	}
+	if h.Width != w.Width || h.Height != w.Height {
+		parts = append(parts, fmt.Sprintf("size %dx%d → %dx%d", h.Width, h.Height, w.Width, w.Height))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// 변경을 순서대로 실행. 호출자가 트랜잭션과 history.SetActor 를 건다(catalog_audit 행위자)
+func Apply(ctx context.Context, q db.Querier, changes []Change) error {
+	for _, c := range changes {
+		if err := c.apply(ctx, q); err != nil {
+			return fmt.Errorf("%s: %w", strings.TrimSpace(c.String()), err)
+		}
+	}
+	return nil
+}
+
+func (c Change) apply(ctx context.Context, q db.Querier) error {
+	t := tables[c.Entity]
+	n := len(c.keys)
+	next := "$" + strconv.Itoa(n+1)
+	var sql string
+	args := slices.Clone(c.keys)
+
+	switch c.Op {
+	case OpCreate:
+		switch row := c.row.(type) {
+		case Media:
+			sql = `INSERT INTO media_asset (kind, url, content_type, width, height)
+				VALUES ('image', $1, NULLIF($2, ''), NULLIF($3, 0), NULLIF($4, 0))`
+			args = append(args, row.ContentType, row.Width, row.Height)
+		case Coded:
+			sql = `INSERT INTO ` + c.Entity + ` (code, name, active) VALUES ($1, $2, $3)`
+			args = append(args, row.Name, !row.Disabled)
+		case RuleDef:
+			sql = `INSERT INTO avatar_compat_rule (character_category, character_tag, bg_tag, effect, note, active)
+				VALUES (NULLIF($1, ''), NULLIF($2, ''), $3, $4, NULLIF($5, ''), $6)`
+			args = append(args, row.Note, !row.Disabled)
+		case itemRow:
+			cols := slices.Clone(t.keys)
+			params := make([]string, n)
+			for i := range params {
+				params[i] = "$" + strconv.Itoa(i+1)
+			}
+			cols = append(cols, "active")
+			params = append(params, next)
+			args = append(args, row.active)
+			if row.preview != nil {
+				cols = append(cols, "preview_asset")
+				params = append(params, previewID("$"+strconv.Itoa(len(args)+1)))
+				args = append(args, *row.preview)
+			}
+			if row.meta != nil {
+				for i, v := range row.meta.args() {
+					cols = append(cols, itemMetaCols[i])
+					args = append(args, v)
+					params = append(params, "$"+strconv.Itoa(len(args)))
+				}
+			}
+			if row.place != nil {
+				for i, v := range row.place.args() {
+					cols = append(cols, placementCols[i])
+					args = append(args, v)
+					params = append(params, "$"+strconv.Itoa(len(args)))
+				}
+			}
+			sql = `INSERT INTO ` + c.Entity + ` (` + strings.Join(cols, ", ") + `) VALUES (` + strings.Join(params, ", ") + `)`
+		}
+	case OpUpdate:
+		switch row := c.row.(type) {
+		case Media:
+			sql = `UPDATE media_asset SET content_type = NULLIF($2, ''), width = NULLIF($3, 0), height = NULLIF($4, 0) WHERE ` + t.where(1)
+			args = append(args, row.ContentType, row.Width, row.Height)
+		case Coded:
+			sql = `UPDATE ` + c.Entity + ` SET name = ` + next + ` WHERE ` + t.where(1)
+			args = append(args, row.Name)
+		case RuleDef:
+			sql = `UPDATE avatar_compat_rule SET note = NULLIF(` + next + `, '') WHERE ` + t.where(1)
+			args = append(args, row.Note)
+		case itemRow:
+			sets := []string{`preview_asset = ` + previewID(next)}
+			args = append(args, *row.preview)
+			for i, v := range row.meta.args() {
+				args = append(args, v)
+				sets = append(sets, itemMetaCols[i]+` = $`+strconv.Itoa(len(args)))
+			}
+			if row.place != nil {
+				for i, v := range row.place.args() {
+					args = append(args, v)
+					sets = append(sets, placementCols[i]+` = $`+strconv.Itoa(len(args)))
+				}
+			}
+			sql = `UPDATE ` + c.Entity + ` SET ` + strings.Join(sets, ", ") + ` WHERE ` + t.where(1)
+		}
+	case OpDisable, OpEnable:
+		sql = `UPDATE ` + c.Entity + ` SET active = ` + next + ` WHERE ` + t.where(1)
+		args = append(args, c.Op == OpEnable)
+	case OpTranslate:
+		e, l, nm := "$"+strconv.Itoa(n+1), "$"+strconv.Itoa(n+2), "$"+strconv.Itoa(n+3)
+		sql = `INSERT INTO translation (entity, entity_key, locale, name)
+			SELECT ` + e + `, ` + t.keyCol + `, ` + l + `, ` + nm + ` FROM ` + c.Entity + ` WHERE ` + t.where(1) + `
+			ON CONFLICT (entity, entity_key, locale) DO UPDATE SET name = EXCLUDED.name`
+		args = append(args, c.Entity, c.locale, c.name)
+	case OpUntranslate:
+		e, l := "$"+strconv.Itoa(n+1), "$"+strconv.Itoa(n+2)
+		sql = `DELETE FROM translation WHERE entity = ` + e + ` AND locale = ` + l + `
+			AND entity_key IN (SELECT ` + t.keyCol + ` FROM ` + c.Entity + ` WHERE ` + t.where(1) + `)`
+		args = append(args, c.Entity, c.locale)
+	}
+	if sql == "" {
+		return fmt.Errorf("unsupported change")
+	}
+	_, err := q.Exec(ctx, sql, args...)
+	return err
+}
+
+// 현재 카탈로그를 정의 파일 모양으로(자연 키 순으로 정렬해 리뷰 diff 가 안정적이다)
+func Export(ctx context.Context, q db.Querier) (File, error) {
+	var f File
+	tr := func(entity, keyExpr string) string {
+		return `(SELECT jsonb_object_agg(tr.locale, tr.name) FROM translation tr
+			WHERE tr.entity = '` + entity + `' AND tr.entity_key = ` + keyExpr + `)`
+	}
+
+	rows, err := q.Query(ctx, `
+		SELECT DISTINCT ON (url) url, COALESCE(content_type, ''), COALESCE(width, 0), COALESCE(height, 0)
+		FROM media_asset WHERE owner_id IS NULL ORDER BY url, created_at, id`)
+	if err != nil {
+		return f, err
+	}
+	for rows.Next() {
+		var m Media
+		if err := rows.Scan(&m.URL, &m.ContentType, &m.Width, &m.Height); err != nil {
+			rows.Close()
+			return f, err
+		}
+		f.Media = append(f.Media, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return f, err
+	}
+
+	coded := func(entity string, withTr bool) ([]Coded, error) {
+		trExpr := "NULL::jsonb"
+		if withTr {
+			trExpr = tr(entity, "t.code")
+		}
+		rows, err := q.Query(ctx, `SELECT t.code, t.name, NOT t.active, `+trExpr+` FROM `+entity+` t ORDER BY t.code`)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		var out []Coded
+		for rows.Next() {
+			var c Coded
+			if err := rows.Scan(&c.Code, &c.Name, &c.Disabled, &c.Translations); err != nil {
+				return nil, err
+			}
+			out = append(out, c)
+		}
+		return out, rows.Err()
+	}
+	if f.JobCategories, err = coded("job_category", true); err != nil {
+		return f, err
+	}
+	if f.CharacterCategories, err = coded("character_category", true); err != nil {
+		return f, err
+	}
+	types, err := coded("pref_type", false)
+	if err != nil {
+		return f, err
+	}
+
+	// 분류가 없는 캐릭터/선호 항목은 자연 키가 없어 파일로 다룰 수 없다
+	rows, err = q.Query(ctx, `
+		SELECT t.category_code, t.name, COALESCE(m.url, ''), NOT t.active, `+ItemAttrCols("t")+`, `+tr("character_item", "t.id::text")+`
+		FROM character_item t
+		LEFT JOIN media_asset m ON m.id = t.preview_asset
+		WHERE t.category_code IS NOT NULL
+		ORDER BY t.category_code, t.name`)
+	if err != nil {
+		return f, err
+	}
+	for rows.Next() {
+		var c CharDef
+		var a ItemAttrs
+		if err := rows.Scan(append([]any{&c.Category, &c.Name, &c.Preview, &c.Disabled}, append(a.Dest(), &c.Translations)...)...); err != nil {
+			rows.Close()
+			return f, err
+		}
+		c.ItemMeta = a.meta()
+		f.Characters = append(f.Characters, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return f, err
+	}
+
+	rows, err = q.Query(ctx, `
+		SELECT t.name, COALESCE(m.url, ''), NOT t.active, `+ItemAttrCols("t")+`, `+tr("bg_item", "t.id::text")+`
+		FROM bg_item t
+		LEFT JOIN media_asset m ON m.id = t.preview_asset
+		ORDER BY t.name`)
+	if err != nil {
+		return f, err
+	}
+	for rows.Next() {
+		var b BgDef
+		var a ItemAttrs
+		if err := rows.Scan(append([]any{&b.Name, &b.Preview, &b.Disabled}, append(a.Dest(), &b.Translations)...)...); err != nil {
+			rows.Close()
+			return f, err
+		}
+		b.ItemMeta = a.meta()
+		f.Backgrounds = append(f.Backgrounds, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return f, err
+	}
+
+	rows, err = q.Query(ctx, `
+		SELECT t.slot_code, t.name, COALESCE(m.url, ''), t.anchor_x, t.anchor_y, t.scale, NOT t.active, `+ItemAttrCols("t")+`, `+tr("avatar_part", "t.id::text")+`
+		FROM avatar_part t
+		LEFT JOIN media_asset m ON m.id = t.preview_asset
+		ORDER BY t.slot_code, t.name`)
+	if err != nil {
+		return f, err
+	}
+	for rows.Next() {
+		var p PartDef
+		var pl placement
+		var a ItemAttrs
+		if err := rows.Scan(append([]any{&p.Slot, &p.Name, &p.Preview, &pl.anchorX, &pl.anchorY, &pl.scale, &p.Disabled},
+			append(a.Dest(), &p.Translations)...)...); err != nil {
+			rows.Close()
+			return f, err
+		}
+		p.AnchorX, p.AnchorY, p.Scale = pl.unlessDefault()
+		p.ItemMeta = a.meta()
+		f.AvatarParts = append(f.AvatarParts, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return f, err
+	}
+
+	// 같은 조건의 규칙이 여럿이면 가장 오래된 것만(파일에서는 하나로 다룬다)
+	rows, err = q.Query(ctx, `
+		SELECT DISTINCT ON (k.cat, k.tag, t.bg_tag, t.effect) k.cat, k.tag, t.bg_tag, t.effect, COALESCE(t.note, ''), NOT t.active
+		FROM avatar_compat_rule t
+		CROSS JOIN LATERAL (SELECT COALESCE(t.character_category, '') AS cat, COALESCE(t.character_tag, '') AS tag) k
+		ORDER BY k.cat, k.tag, t.bg_tag, t.effect, t.id`)
+	if err != nil {
+		return f, err
+	}
+	for rows.Next() {
+		var r RuleDef
+		if err := rows.Scan(&r.CharacterCategory, &r.CharacterTag, &r.BgTag, &r.Effect, &r.Note, &r.Disabled); err != nil {
+			rows.Close()
+			return f, err
+		}
+		f.CompatRules = append(f.CompatRules, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return f, err
+	}
+
+	rows, err = q.Query(ctx, `
+		SELECT DISTINCT ON (t.type_code, t.name) t.type_code, t.name, NOT t.active, `+tr("pref_item", "t.id::text")+`
+		FROM pref_item t WHERE t.type_code IS NOT NULL
+		ORDER BY t.type_code, t.name, t.id`)
+	if err != nil {
+		return f, err
+	}
+	items := map[string][]PrefItemDef{}
+	for rows.Next() {
+		var code string
+		var it PrefItemDef
+		if err := rows.Scan(&code, &it.Name, &it.Disabled, &it.Translations); err != nil {
+			rows.Close()
+			return f, err
+		}
+		items[code] = append(items[code], it)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return f, err
+	}
+	for _, t := range types {
+		f.PrefTypes = append(f.PrefTypes, PrefTypeDef{Code: t.Code, Name: t.Name, Disabled: t.Disabled, Items: items[t.Code]})
+	}
+	return f, nil
+}
+
+// 파일에는 기본값을 적지 않는다
+func (p placement) unlessDefault() (x, y, scale *float32) {
+	if p.anchorX != 0.5 {
+		x = &p.anchorX
+	}
+	if p.anchorY != 0.5 {
+		y = &p.anchorY
+	}
+	if p.scale != 1 {
+		scale = &p.scale
+	}
+	return x, y, scale
+}
+
+func (a ItemAttrs) meta() ItemMeta {
+	m := ItemMeta{SortWeight: a.SortWeight, AvailableFrom: a.AvailableFrom, AvailableUntil: a.AvailableUntil, Default: a.IsDefault, Locked: a.Locked}
+	if a.UnlockSource != nil {
+		m.UnlockSource = *a.UnlockSource
+	}
+	if a.Price != nil {
+		m.Price = *a.Price
+	}
+	if len(a.Tags) > 0 {
+		m.Tags = a.Tags
+	}
+	if a.Rarity != "common" {
+		m.Rarity = a.Rarity
+	}
+	return m
+}
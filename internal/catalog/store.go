@@ -0,0 +1,274 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+	"github.com/creators-of-happiness/amigo-backend/internal/region"
+)
+
+// 관리자 카탈로그 CRUD. 쓰기는 호출자가 history.SetActor 를 건 트랜잭션 안에서 부른다(catalog_audit 행위자)
+
+var (
+	ErrNotFound          = errors.New("catalog row not found")
+	ErrCannotDisable     = errors.New("this catalog entity cannot be disabled")
+	ErrHasActiveChildren = errors.New("region has active sub-regions; disable them first")
+	ErrParentInactive    = errors.New("parent region is disabled; enable it first")
+)
+
+// 응답과 감사 기록에는 동기화용 catalog_rev 를 뺀다
+const rowJSON = `to_jsonb(t) - 'catalog_rev'`
+
+func (e *Entity) keyParam() string {
+	switch e.KeyKind {
+	case Int:
+		return "$1::int"
+	case UUID:
+		return "$1::uuid"
+	default:
+		return "$1"
+	}
+}
+
+func (e *Entity) where() string {
+	w := "t." + e.Key + " = " + e.keyParam()
+	if e.Scope != "" {
+		w += " AND " + e.Scope
+	}
+	return w
+}
+
+func (e *Entity) active() string {
+	if e.Disable {
+		return "t.active"
+	}
+	return "true"
+}
+
+// active 가 nil 이면 비활성 행도 함께
+func (e *Entity) List(ctx context.Context, q db.Querier, active *bool, limit int) ([]json.RawMessage, error) {
+	where := "($1::bool IS NULL OR " + e.active() + " = $1)"
+	if e.Scope != "" {
+		where += " AND " + e.Scope
+	}
+	rows, err := q.Query(ctx, `SELECT `+rowJSON+` FROM `+e.Name+` t WHERE `+where+` ORDER BY `+e.Order+` LIMIT $2`, active, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []json.RawMessage{}
+	for rows.Next() {
+		var r json.RawMessage
+		if err := rows.Scan(&r); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func (e *Entity) Get(ctx context.Context, q db.Querier, key string) (json.RawMessage, error) {
+	if !e.ValidKey(key) {
+		return nil, ErrNotFound
+	}
+	var r json.RawMessage
+	err := q.QueryRow(ctx, `SELECT `+rowJSON+` FROM `+e.Name+` t WHERE `+e.where(), key).Scan(&r)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return r, err
+}
+
+// vals 는 Values(body, true) 결과
+func (e *Entity) Create(ctx context.Context, q db.Querier, vals map[string]any) (json.RawMessage, error) {
+	if err := e.prepare(ctx, q, "", vals); err != nil {
+		return nil, err
+	}
+	cols := slices.Sorted(maps.Keys(vals))
+	params := make([]string, len(cols))
+	args := make([]any, len(cols))
+	for i, c := range cols {
+		params[i] = "$" + strconv.Itoa(i+1)
+		args[i] = vals[c]
+	}
+	var r json.RawMessage
+	err := q.QueryRow(ctx, `INSERT INTO `+e.Name+` AS t (`+strings.Join(cols, ", ")+`)
+		VALUES (`+strings.Join(params, ", ")+`) RETURNING `+rowJSON, args...).Scan(&r)
+	return r, err
+}
+
+// vals 는 Values(body, false) 결과. 준 컬럼만 바꾼다
+func (e *Entity) Update(ctx context.Context, q db.Querier, key string, vals map[string]any) (json.RawMessage, error) {
+	if !e.ValidKey(key) {
+		return nil, ErrNotFound
+	}
+	if err := e.prepare(ctx, q, key, vals); err != nil {
+		return nil, err
+	}
+	cols := slices.Sorted(maps.Keys(vals))
+	sets := make([]string, len(cols))
+	args := []any{key}
+	for i, c := range cols {
+		sets[i] = c + " = $" + strconv.Itoa(i+2)
+		args = append(args, vals[c])
+	}
+	var r json.RawMessage
+	err := q.QueryRow(ctx, `UPDATE `+e.Name+` AS t SET `+strings.Join(sets, ", ")+`
+		WHERE `+e.where()+` RETURNING `+rowJSON, args...).Scan(&r)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return r, err
+}
+
+// 지우는 대신 숨긴다(이미 고른 사용자의 참조는 그대로). 이미 그 상태면 바꾸지 않고 현재 행
+func (e *Entity) SetActive(ctx context.Context, q db.Querier, key string, active bool) (json.RawMessage, error) {
+	if !e.Disable {
+		return nil, ErrCannotDisable
+	}
+	if !e.ValidKey(key) {
+		return nil, ErrNotFound
+	}
+	if e.Name == "region" {
+		if err := checkRegionActive(ctx, q, key, active); err != nil {
+			return nil, err
+		}
+	}
+	var r json.RawMessage
+	err := q.QueryRow(ctx, `UPDATE `+e.Name+` AS t SET active = $2
+		WHERE `+e.where()+` AND t.active <> $2 RETURNING `+rowJSON, key, active).Scan(&r)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return e.Get(ctx, q, key)
+	}
+	return r, err
+}
+
+// 테이블별 파생 컬럼/제약. key 가 비면 생성
+func (e *Entity) prepare(ctx context.Context, q db.Querier, key string, vals map[string]any) error {
+	switch e.Name {
+	case "region":
+		return prepareRegion(ctx, q, key, vals)
+	case "media_asset":
+		// 원본이 바뀌면 파생본을 다시 만든다
+		if _, ok := vals["url"]; ok && key != "" {
+			vals["variant_status"] = "pending"
+			vals["variant_attempts"] = 0
+			vals["variant_error"] = nil
+		}
+	}
+	return nil
+}
+
+// level 은 부모에서 정한다. 하위 지역이 있는 지역은 옮기지 않는다(하위 단계가 어긋난다)
+func prepareRegion(ctx context.Context, q db.Querier, key string, vals map[string]any) error {
+	pid, ok := vals["parent_id"]
+	if !ok {
+		if key == "" {
+			vals["level"] = region.LevelProvince
+		}
+		return nil
+	}
+	if key != "" {
+		var cur *int64
+		var hasChildren bool
+		err := q.QueryRow(ctx, `
+			SELECT parent_id, EXISTS (SELECT 1 FROM region ch WHERE ch.parent_id = r.id)
+			FROM region r WHERE id = $1::int`, key).Scan(&cur, &hasChildren)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		} else if err != nil {
+			return err
+		}
+		if pid == nil && cur == nil || pid != nil && cur != nil && pid.(int64) == *cur {
+			return nil
+		}
+		if hasChildren {
+			return &FieldError{Field: "parent_id", Reason: "region has sub-regions; move them first"}
+		}
+		if pid != nil && strconv.FormatInt(pid.(int64), 10) == key {
+			return &FieldError{Field: "parent_id", Reason: "region cannot be its own parent"}
+		}
+	}
+	if pid == nil {
+		vals["level"] = region.LevelProvince
+		return nil
+	}
+	var level int
+	var active bool
+	err := q.QueryRow(ctx, `SELECT level, active FROM region WHERE id = $1`, pid).Scan(&level, &active)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &FieldError{Field: "parent_id", Reason: "unknown region"}
+	} else if err != nil {
+		return err
+	}
+	if !active {
+		return &FieldError{Field: "parent_id", Reason: "parent region is disabled"}
+	}
+	if level >= region.MaxLevel {
+		return &FieldError{Field: "parent_id", Reason: "regions are at most " + strconv.Itoa(region.MaxLevel) + " levels deep"}
+	}
+	vals["level"] = level + 1
+	return nil
+}
+
+// 숨긴 지역 아래에 보이는 지역이 남지 않도록
+func checkRegionActive(ctx context.Context, q db.Querier, key string, active bool) error {
+	var blocked bool
+	sql := `SELECT EXISTS (SELECT 1 FROM region WHERE parent_id = $1::int AND active)`
+	if active {
+		sql = `SELECT EXISTS (SELECT 1 FROM region r JOIN region p ON p.id = r.parent_id WHERE r.id = $1::int AND NOT p.active)`
+	}
+	if err := q.QueryRow(ctx, sql, key).Scan(&blocked); err != nil {
+		return err
+	}
+	switch {
+	case blocked && active:
+		return ErrParentInactive
+	case blocked:
+		return ErrHasActiveChildren
+	}
+	return nil
+}
+
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	Entity    string          `json:"entity"`
+	Key       string          `json:"key"`
+	Action    string          `json:"action"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	ActorID   *string         `json:"actor_id"`
+	Source    string          `json:"source"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// 최신순 감사 기록. entity/key 가 비면 전체, beforeID>0 이면 그보다 오래된 것만
+func Audit(ctx context.Context, q db.Querier, entity, key string, beforeID int64, limit int) ([]AuditEntry, error) {
+	rows, err := q.Query(ctx, `
+		SELECT id, entity, entity_key, action, before, after, actor_id::text, source, created_at
+		FROM catalog_audit
+		WHERE ($1 = '' OR entity = $1) AND ($2 = '' OR entity_key = $2) AND ($3 = 0 OR id < $3)
+		ORDER BY id DESC LIMIT $4`, entity, key, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []AuditEntry{}
+	for rows.Next() {
+		var a AuditEntry
+		if err := rows.Scan(&a.ID, &a.Entity, &a.Key, &a.Action, &a.Before, &a.After, &a.ActorID, &a.Source, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,252 @@
+package catalog
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+	"github.com/creators-of-happiness/amigo-backend/internal/i18n"
+	"github.com/creators-of-happiness/amigo-backend/internal/region"
+)
+
+// since 이후 바뀐 행과 지워진 키
+type Changes[T any] struct {
+	Upserted []T      `json:"upserted"`
+	Deleted  []string `json:"deleted"`
+}
+
+type Category struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// 캐릭터/배경/파트 공통 속성. 노출 기간 밖의 항목도 보내므로 클라이언트가 기간으로 거른다
+type ItemAttrs struct {
+	Tags           []string   `json:"tags"`
+	Rarity         string     `json:"rarity"`
+	SortWeight     int        `json:"sort_weight"`
+	AvailableFrom  *time.Time `json:"available_from"`
+	AvailableUntil *time.Time `json:"available_until"`
+	IsDefault      bool       `json:"is_default"`
+	Locked         bool       `json:"locked"`
+	UnlockSource   *string    `json:"unlock_source"`
+	Price          *int       `json:"price"`
+}
+
+// ItemAttrs 열(alias 는 character_item/bg_item/avatar_part 별칭)
+func ItemAttrCols(alias string) string {
+	return alias + ".tags, " + alias + ".rarity, " + alias + ".sort_weight, " +
+		alias + ".available_from, " + alias + ".available_until, " + alias + ".is_default, " +
+		alias + ".locked, " + alias + ".unlock_source, " + alias + ".price"
+}
+
+// Scan 대상(ItemAttrCols 순서)
+func (a *ItemAttrs) Dest() []any {
+	return []any{&a.Tags, &a.Rarity, &a.SortWeight, &a.AvailableFrom, &a.AvailableUntil, &a.IsDefault, &a.Locked, &a.UnlockSource, &a.Price}
+}
+
+type Character struct {
+	ID              string         `json:"id"`
+	CategoryCode    string         `json:"category_code"`
+	Name            string         `json:"name"`
+	PreviewURL      *string        `json:"preview_url"`
+	PreviewVariants map[string]any `json:"preview_variants"`
+	ItemAttrs
+}
+
+type Background struct {
+	ID              string         `json:"id"`
+	Name            string         `json:"name"`
+	PreviewURL      *string        `json:"preview_url"`
+	PreviewVariants map[string]any `json:"preview_variants"`
+	ItemAttrs
+}
+
+// 옷/표정/장신구 슬롯 항목. 캔버스(0~1)의 (AnchorX, AnchorY) 에 항목 중심을 두고,
+// 긴 변을 캔버스 한 변의 Scale 배로 그린다
+type Part struct {
+	ID              string         `json:"id"`
+	SlotCode        string         `json:"slot_code"`
+	Name            string         `json:"name"`
+	PreviewURL      *string        `json:"preview_url"`
+	PreviewVariants map[string]any `json:"preview_variants"`
+	AnchorX         float64        `json:"anchor_x"`
+	AnchorY         float64        `json:"anchor_y"`
+	Scale           float64        `json:"scale"`
+	ItemAttrs
+}
+
+// 클라이언트는 Version 을 저장해 두었다가 다음 요청의 since 로 보낸다.
+// Full 이면 로컬 사본을 비우고 Upserted 로 다시 채워야 한다
+type Bundle struct {
+	Version             int64                  `json:"version"`
+	Since               int64                  `json:"since"`
+	Full                bool                   `json:"full"`
+	Locale              string                 `json:"locale"`
+	Regions             Changes[region.Region] `json:"regions"`
+	JobCategories       Changes[Category]      `json:"job_categories"`
+	CharacterCategories Changes[Category]      `json:"character_categories"`
+	Characters          Changes[Character]     `json:"characters"`
+	Backgrounds         Changes[Background]    `json:"backgrounds"`
+	AvatarParts         Changes[Part]          `json:"avatar_parts"`
+}
+
+// 미리보기 파생본 jsonb 식. sync 번들과 meta 목록이 같은 모양으로 내려준다
+func PreviewVariants(assetExpr string) string {
+	return `(
+		SELECT jsonb_object_agg(v.name, jsonb_build_object(
+			'url', v.url, 'width', v.width, 'height', v.height, 'content_type', v.content_type))
+		FROM media_asset_variant v WHERE v.asset_id = ` + assetExpr + `)`
+}
+
+// since 이후의 변경분. since 가 0 이거나 현재 버전보다 크면(다른 DB 등) 전체를 보낸다.
+// 한 스냅숏 안에서 읽어 Version 과 행이 어긋나지 않는다
+func Delta(ctx context.Context, pool *pgxpool.Pool, since int64, locale string) (Bundle, error) {
+	b := Bundle{Locale: locale}
+	err := pgx.BeginTxFunc(ctx, pool, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		v, _, err := Version(ctx, tx)
+		if err != nil {
+			return err
+		}
+		b.Version = v
+		if since <= 0 || since > v {
+			since = 0
+			b.Full = true
+		}
+		b.Since = since
+
+		if b.Regions.Upserted, err = collect(ctx, tx, `
+			SELECT id, code, `+i18n.Name(i18n.Region, "id", "name", "$2")+`, parent_id, level, latitude::float8, longitude::float8
+			FROM region WHERE catalog_rev > $1 AND active ORDER BY level, id`, since, locale,
+			func(row pgx.Rows) (region.Region, error) {
+				var r region.Region
+				err := row.Scan(&r.ID, &r.Code, &r.Name, &r.ParentID, &r.Level, &r.Latitude, &r.Longitude)
+				return r, err
+			}); err != nil {
+			return err
+		}
+		category := func(row pgx.Rows) (Category, error) {
+			var c Category
+			err := row.Scan(&c.Code, &c.Name)
+			return c, err
+		}
+		if b.JobCategories.Upserted, err = collect(ctx, tx, `
+			SELECT code, `+i18n.Name(i18n.JobCategory, "code", "name", "$2")+`
+			FROM job_category WHERE catalog_rev > $1 AND active ORDER BY code`, since, locale, category); err != nil {
+			return err
+		}
+		if b.CharacterCategories.Upserted, err = collect(ctx, tx, `
+			SELECT code, `+i18n.Name(i18n.CharacterCategory, "code", "name", "$2")+`
+			FROM character_category WHERE catalog_rev > $1 AND active ORDER BY code`, since, locale, category); err != nil {
+			return err
+		}
+		if b.Characters.Upserted, err = collect(ctx, tx, `
+			SELECT i.id::text, i.category_code, `+i18n.Name(i18n.CharacterItem, "i.id", "i.name", "$2")+`, m.url, `+
+			PreviewVariants("i.preview_asset")+`, `+ItemAttrCols("i")+`
+			FROM character_item i
+			LEFT JOIN media_asset m ON m.id = i.preview_asset
+			WHERE i.catalog_rev > $1 AND i.active ORDER BY i.id`, since, locale,
+			func(row pgx.Rows) (Character, error) {
+				var c Character
+				err := row.Scan(append([]any{&c.ID, &c.CategoryCode, &c.Name, &c.PreviewURL, &c.PreviewVariants}, c.Dest()...)...)
+				return c, err
+			}); err != nil {
+			return err
+		}
+		if b.Backgrounds.Upserted, err = collect(ctx, tx, `
+			SELECT b.id::text, `+i18n.Name(i18n.BgItem, "b.id", "b.name", "$2")+`, m.url, `+
+			PreviewVariants("b.preview_asset")+`, `+ItemAttrCols("b")+`
+			FROM bg_item b
+			LEFT JOIN media_asset m ON m.id = b.preview_asset
+			WHERE b.catalog_rev > $1 AND b.active ORDER BY b.id`, since, locale,
+			func(row pgx.Rows) (Background, error) {
+				var bg Background
+				err := row.Scan(append([]any{&bg.ID, &bg.Name, &bg.PreviewURL, &bg.PreviewVariants}, bg.Dest()...)...)
+				return bg, err
+			}); err != nil {
+			return err
+		}
+		if b.AvatarParts.Upserted, err = collect(ctx, tx, `
+			SELECT p.id::text, p.slot_code, `+i18n.Name(i18n.AvatarPart, "p.id", "p.name", "$2")+`, m.url, `+
+			PreviewVariants("p.preview_asset")+`, p.anchor_x::float8, p.anchor_y::float8, p.scale::float8, `+ItemAttrCols("p")+`
+			FROM avatar_part p
+			LEFT JOIN media_asset m ON m.id = p.preview_asset
+			WHERE p.catalog_rev > $1 AND p.active ORDER BY p.id`, since, locale,
+			func(row pgx.Rows) (Part, error) {
+				var p Part
+				err := row.Scan(append([]any{&p.ID, &p.SlotCode, &p.Name, &p.PreviewURL, &p.PreviewVariants,
+					&p.AnchorX, &p.AnchorY, &p.Scale}, p.Dest()...)...)
+				return p, err
+			}); err != nil {
+			return err
+		}
+
+		deleted, err := tombstones(ctx, tx, since)
+		if err != nil {
+			return err
+		}
+		b.Regions.Deleted = deleted[i18n.Region]
+		b.JobCategories.Deleted = deleted[i18n.JobCategory]
+		b.CharacterCategories.Deleted = deleted[i18n.CharacterCategory]
+		b.Characters.Deleted = deleted[i18n.CharacterItem]
+		b.Backgrounds.Deleted = deleted[i18n.BgItem]
+		b.AvatarParts.Deleted = deleted[i18n.AvatarPart]
+		for _, d := range []*[]string{&b.Regions.Deleted, &b.JobCategories.Deleted, &b.CharacterCategories.Deleted,
+			&b.Characters.Deleted, &b.Backgrounds.Deleted, &b.AvatarParts.Deleted} {
+			if *d == nil {
+				*d = []string{}
+			}
+		}
+		return nil
+	})
+	return b, err
+}
+
+func collect[T any](ctx context.Context, q db.Querier, sql string, since int64, locale string, scan func(pgx.Rows) (T, error)) ([]T, error) {
+	rows, err := q.Query(ctx, sql, since, locale)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []T{}
+	for rows.Next() {
+		v, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
+
+// 지웠거나 숨긴(active=false) 키. 전체 동기화면 알릴 필요가 없다
+func tombstones(ctx context.Context, q db.Querier, since int64) (map[string][]string, error) {
+	out := map[string][]string{}
+	if since == 0 {
+		return out, nil
+	}
+	rows, err := q.Query(ctx, `
+		SELECT entity, entity_key FROM catalog_tombstone WHERE catalog_rev > $1
+		UNION ALL SELECT 'region', id::text FROM region WHERE catalog_rev > $1 AND NOT active
+		UNION ALL SELECT 'job_category', code FROM job_category WHERE catalog_rev > $1 AND NOT active
+		UNION ALL SELECT 'character_category', code FROM character_category WHERE catalog_rev > $1 AND NOT active
+		UNION ALL SELECT 'character_item', id::text FROM character_item WHERE catalog_rev > $1 AND NOT active
+		UNION ALL SELECT 'bg_item', id::text FROM bg_item WHERE catalog_rev > $1 AND NOT active
+		UNION ALL SELECT 'avatar_part', id::text FROM avatar_part WHERE catalog_rev > $1 AND NOT active
+		ORDER BY 1, 2`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entity, key string
+		if err := rows.Scan(&entity, &key); err != nil {
+			return nil, err
+		}
+		out[entity] = append(out[entity], key)
+	}
+	return out, rows.Err()
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
 )
 
 type Config struct {
@@ -12,6 +13,49 @@ type Config struct {
 	OTPFixedCode      string
 	OTPExpiresMinutes int
 	AccessTokenTTLHrs int
+
+	// 객체 저장소(업로드 이미지)
+	StorageBackend   string // local | s3
+	StorageLocalDir  string
+	StoragePublicURL string // 저장된 객체를 내려줄 때 사용할 URL prefix
+	S3Endpoint       string
+	S3Region         string
+	S3Bucket         string
+	S3AccessKey      string
+	S3SecretKey      string
+	S3PathStyle      bool
+
+	// 업로드 제한
+	UploadMaxMB         int
+	UploadMaxDimension  int
+	UploadMinDimension  int
+	UploadURLTTLMinutes int
+
+	// 서버가 원본을 내려받아도 되는 외부 이미지 호스트(카탈로그 미리보기 URL). 비우면 외부 URL 은 가져오지 않는다
+	MediaFetchHosts []string
+
+	// 사용자당 갤러리 사진 수 상한
+	PhotoMaxCount int
+
+	// 닉네임 변경 쿨다운(일). 처음 설정은 제외
+	NicknameCooldownDays int
+
+	// 가입 가능 최소 나이(만 나이)
+	MinAge int
+
+	// 이 시간보다 오래된 위치는 주변 기능에서 제외
+	LocationStaleHours int
+
+	// 금칙어 목록 파일(비우면 내장 목록)
+	ModerationWordlist string
+
+	// 관리자(운영/심사) 사용자 ID 목록
+	AdminUserIDs []string
+
+	// 얼굴 사진 자동 분류기
+	FaceClassifier       string  // fake
+	FaceAutoApproveScore float64 // 이 점수 이상이면 자동 승인(0 이면 비활성)
+	FaceAutoRejectScore  float64 // 이 점수 미만이면 자동 반려(0 이면 비활성)
 }
 
 func FromEnv() Config {
@@ -22,6 +66,38 @@ func FromEnv() Config {
 		OTPFixedCode:      getenv("OTP_FIXED_CODE", "000000"),
 		OTPExpiresMinutes: mustAtoi(getenv("OTP_EXPIRES_MINUTES", "5")),
 		AccessTokenTTLHrs: mustAtoi(getenv("ACCESS_TOKEN_TTL_HOURS", "24")),
+
+		StorageBackend:   getenv("STORAGE_BACKEND", "local"),
+		StorageLocalDir:  getenv("STORAGE_LOCAL_DIR", "./data/media"),
+		StoragePublicURL: getenv("STORAGE_PUBLIC_URL", "http://localhost:8080/media"),
+		S3Endpoint:       getenv("S3_ENDPOINT", ""),
+		S3Region:         getenv("S3_REGION", "us-east-1"),
+		S3Bucket:         getenv("S3_BUCKET", ""),
+		S3AccessKey:      getenv("S3_ACCESS_KEY", ""),
+		S3SecretKey:      getenv("S3_SECRET_KEY", ""),
+		S3PathStyle:      getenv("S3_PATH_STYLE", "true") == "true",
+
+		UploadMaxMB:         mustAtoi(getenv("UPLOAD_MAX_MB", "10")),
+		UploadMaxDimension:  mustAtoi(getenv("UPLOAD_MAX_DIMENSION", "4096")),
+		UploadMinDimension:  mustAtoi(getenv("UPLOAD_MIN_DIMENSION", "200")),
+		UploadURLTTLMinutes: mustAtoi(getenv("UPLOAD_URL_TTL_MINUTES", "15")),
+
+		MediaFetchHosts: splitList(getenv("MEDIA_FETCH_HOSTS", "")),
+
+		PhotoMaxCount: mustAtoi(getenv("PHOTO_MAX_COUNT", "6")),
+
+		NicknameCooldownDays: mustAtoi(getenv("NICKNAME_COOLDOWN_DAYS", "30")),
+
+		MinAge:             mustAtoi(getenv("MIN_AGE", "18")),
+		LocationStaleHours: mustAtoi(getenv("LOCATION_STALE_HOURS", "72")),
+
+		ModerationWordlist: os.Getenv("MODERATION_WORDLIST"),
+
+		AdminUserIDs: splitList(getenv("ADMIN_USER_IDS", "")),
+
+		FaceClassifier:       getenv("FACE_CLASSIFIER", "fake"),
+		FaceAutoApproveScore: mustAtof(getenv("FACE_AUTO_APPROVE_SCORE", "0")),
+		FaceAutoRejectScore:  mustAtof(getenv("FACE_AUTO_REJECT_SCORE", "0")),
 	}
 }
 
@@ -40,3 +116,23 @@ func mustAtoi(s string) int {
 	}
 	return v
 }
+
+func mustAtof(s string) float64 {
+	var v float64
+	_, err := fmt.Sscanf(s, "%g", &v)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// 콤마 구분 목록(공백/빈 항목 제거)
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+package facereview
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+type Verdict string
+
+const (
+	VerdictApprove Verdict = "approve"
+	VerdictReject  Verdict = "reject"
+	VerdictReview  Verdict = "review" // 사람 심사 필요
+)
+
+type Result struct {
+	Label   string
+	Score   float64 // 0~1, 높을수록 "적합한 얼굴 사진"에 가까움
+	Verdict Verdict
+	Reason  string // 반려 시 사용자에게 보여줄 사유
+}
+
+// 자동 분류기 확장 지점(외부 비전 API 등). 실패 시 사람 심사로 넘어간다
+type Classifier interface {
+	Classify(ctx context.Context, img []byte) (Result, error)
+}
+
+// 개발/테스트용 결정적 분류기: 이미지 바이트 해시로 점수를 만들고 임계값으로 판정.
+// 임계값이 0 이면 해당 자동 판정은 꺼지며, 제로값은 항상 사람 심사로 보낸다
+type Fake struct {
+	ApproveAbove float64
+	RejectBelow  float64
+}
+
+func (f Fake) Classify(ctx context.Context, img []byte) (Result, error) {
+	if len(img) == 0 {
+		return Result{Label: "unclassified", Verdict: VerdictReview}, nil
+	}
+	sum := sha256.Sum256(img)
+	score := float64(binary.BigEndian.Uint16(sum[:2])) / 65535
+	res := Result{Label: "face", Score: score, Verdict: VerdictReview}
+	switch {
+	case f.ApproveAbove > 0 && score >= f.ApproveAbove:
+		res.Verdict = VerdictApprove
+	case f.RejectBelow > 0 && score < f.RejectBelow:
+		res.Label = "no_face"
+		res.Verdict = VerdictReject
+		res.Reason = fmt.Sprintf("face not detected (score %.2f)", score)
+	}
+	return res, nil
+}
+
+// FACE_CLASSIFIER 설정값으로 구현체 선택
+func NewClassifier(name string, approveAbove, rejectBelow float64) (Classifier, error) {
+	switch name {
+	case "", "fake":
+		return Fake{ApproveAbove: approveAbove, RejectBelow: rejectBelow}, nil
+	}
+	return nil, fmt.Errorf("facereview: unknown classifier %q", name)
+}
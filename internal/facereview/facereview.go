@@ -0,0 +1,279 @@
+package facereview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/gallery"
+	"github.com/creators-of-happiness/amigo-backend/internal/history"
+	"github.com/creators-of-happiness/amigo-backend/internal/notify"
+	"github.com/creators-of-happiness/amigo-backend/internal/storage"
+)
+
+// user_face_upload.status 상태 머신
+//
+//	uploaded → pending_review → approved | rejected
+const (
+	StatusUploaded      = "uploaded"
+	StatusPendingReview = "pending_review"
+	StatusApproved      = "approved"
+	StatusRejected      = "rejected"
+)
+
+// 심사 주체(user_face_upload.review_source)
+const (
+	SourceAdmin      = "admin"
+	SourceClassifier = "classifier"
+)
+
+// 승인 시 반영 위치(user_face_upload.target)
+const (
+	TargetPrimary = "primary"
+	TargetGallery = "gallery"
+)
+
+var transitions = map[string][]string{
+	StatusUploaded:      {StatusPendingReview},
+	StatusPendingReview: {StatusApproved, StatusRejected},
+}
+
+// 새 대표 사진이 올라와 심사하지 않고 닫은 업로드의 반려 사유
+const ReasonSuperseded = "superseded by a newer upload"
+
+var (
+	ErrNotFound          = errors.New("face upload not found")
+	ErrInvalidTransition = errors.New("invalid review status transition")
+)
+
+func CanTransition(from, to string) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+type Upload struct {
+	ID              string     `json:"id"`
+	UserID          string     `json:"user_id"`
+	AssetID         *string    `json:"asset_id"`
+	URL             string     `json:"url"`
+	Status          string     `json:"status"`
+	Target          string     `json:"target"`
+	RejectReason    *string    `json:"reject_reason"`
+	ClassifierLabel *string    `json:"classifier_label"`
+	ClassifierScore *float64   `json:"classifier_score"`
+	ReviewSource    *string    `json:"review_source"`
+	ReviewedBy      *string    `json:"reviewed_by"`
+	ReviewedAt      *time.Time `json:"reviewed_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+const uploadColumns = `id, user_id, asset_id, url, status, target, reject_reason, classifier_label,
+	classifier_score, review_source, reviewed_by, reviewed_at, created_at`
+
+func scanUpload(row pgx.Row) (Upload, error) {
+	var u Upload
+	err := row.Scan(&u.ID, &u.UserID, &u.AssetID, &u.URL, &u.Status, &u.Target, &u.RejectReason, &u.ClassifierLabel,
+		&u.ClassifierScore, &u.ReviewSource, &u.ReviewedBy, &u.ReviewedAt, &u.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return u, ErrNotFound
+	}
+	return u, err
+}
+
+// 얼굴 사진 심사 서비스. 승인된 사진만 갤러리(user_photo)에 들어가고,
+// 대표 사진이 user_profile.profile_image_id 가 된다
+type Service struct {
+	Pool       *pgxpool.Pool
+	Classifier Classifier
+	Store      storage.Storage // 교체된 대표 사진 객체를 지울 저장소. nil 이면 객체는 남긴다
+	GalleryMax int             // 갤러리 사진 수 상한(PHOTO_MAX_COUNT). 0 이면 제한 없음
+}
+
+// 업로드 등록 → 자동 분류기 → 심사 대기(또는 분류기 확신 시 자동 승인/반려).
+// img 는 분류기 입력(외부 URL 등록처럼 바이트가 없으면 nil). 승인되면 대표 사진을 교체한다
+func (s *Service) Submit(ctx context.Context, uid, assetID, url string, img []byte) (Upload, error) {
+	return s.submit(ctx, uid, assetID, url, img, TargetPrimary)
+}
+
+// Submit 과 같지만 승인되면 갤러리 맨 뒤에 추가한다
+func (s *Service) SubmitToGallery(ctx context.Context, uid, assetID, url string, img []byte) (Upload, error) {
+	return s.submit(ctx, uid, assetID, url, img, TargetGallery)
+}
+
+func (s *Service) submit(ctx context.Context, uid, assetID, url string, img []byte, target string) (Upload, error) {
+	var id string
+	err := s.Pool.QueryRow(ctx, `
+		INSERT INTO user_face_upload (user_id, url, asset_id, status, target, created_at, updated_at)
+		VALUES ($1, $2, $3, 'uploaded', $4, now(), now()) RETURNING id`, uid, url, assetID, target).Scan(&id)
+	if err != nil {
+		return Upload{}, err
+	}
+	// 대표 사진은 최신 업로드만 심사한다. 늦게 승인된 예전 업로드가 새 사진을 덮어쓰지 않도록 대기 중인 것을 닫는다
+	if target == TargetPrimary {
+		_, err = s.Pool.Exec(ctx, `
+			UPDATE user_face_upload
+			SET status='rejected', reject_reason=$3, reviewed_at=now(), updated_at=now()
+			WHERE user_id=$1 AND id<>$2 AND target='primary' AND status IN ('uploaded','pending_review')`,
+			uid, id, ReasonSuperseded)
+		if err != nil {
+			return Upload{}, err
+		}
+	}
+
+	res := Result{Label: "unclassified", Verdict: VerdictReview}
+	if s.Classifier != nil {
+		r, err := s.Classifier.Classify(ctx, img)
+		if err != nil {
+			// 분류기 장애는 사람 심사로 넘긴다
+			r = Result{Label: "classifier_error", Verdict: VerdictReview}
+		}
+		res = r
+	}
+	_, err = s.Pool.Exec(ctx, `
+		UPDATE user_face_upload
+		SET status='pending_review', classifier_label=$2, classifier_score=$3, updated_at=now()
+		WHERE id=$1 AND status='uploaded'`, id, res.Label, res.Score)
+	if err != nil {
+		return Upload{}, err
+	}
+
+	switch res.Verdict {
+	case VerdictApprove:
+		up, err := s.transition(ctx, id, StatusApproved, SourceClassifier, "", "")
+		if errors.Is(err, gallery.ErrFull) {
+			// 갤러리가 그새 찼으면 사람 심사로 남긴다
+			return s.Get(ctx, id)
+		}
+		return up, err
+	case VerdictReject:
+		return s.transition(ctx, id, StatusRejected, SourceClassifier, "", res.Reason)
+	}
+	return s.Get(ctx, id)
+}
+
+func (s *Service) Get(ctx context.Context, id string) (Upload, error) {
+	return scanUpload(s.Pool.QueryRow(ctx, `SELECT `+uploadColumns+` FROM user_face_upload WHERE id=$1`, id))
+}
+
+// 관리자 심사 대기열(오래된 순)
+func (s *Service) Queue(ctx context.Context, status string, limit int) ([]Upload, error) {
+	return s.list(ctx, `
+		SELECT `+uploadColumns+` FROM user_face_upload
+		WHERE status=$1 ORDER BY created_at LIMIT $2`, status, limit)
+}
+
+func (s *Service) list(ctx context.Context, sql string, args ...any) ([]Upload, error) {
+	rows, err := s.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Upload{}
+	for rows.Next() {
+		u, err := scanUpload(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+// 사용자 본인의 업로드 이력(최신순)
+func (s *Service) ListByUser(ctx context.Context, uid string, limit int) ([]Upload, error) {
+	return s.list(ctx, `
+		SELECT `+uploadColumns+` FROM user_face_upload
+		WHERE user_id=$1 ORDER BY created_at DESC LIMIT $2`, uid, limit)
+}
+
+func (s *Service) Approve(ctx context.Context, id, reviewerID string) (Upload, error) {
+	return s.transition(ctx, id, StatusApproved, SourceAdmin, reviewerID, "")
+}
+
+func (s *Service) Reject(ctx context.Context, id, reviewerID, reason string) (Upload, error) {
+	return s.transition(ctx, id, StatusRejected, SourceAdmin, reviewerID, reason)
+}
+
+// 상태 전이 + (승인 시) 프로필 사진 반영 + 사용자 알림을 한 트랜잭션으로 처리
+func (s *Service) transition(ctx context.Context, id, to, source, reviewerID, reason string) (Upload, error) {
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		return Upload{}, err
+	}
+	defer tx.Rollback(ctx)
+	// 프로필 사진 반영이 심사자 이름으로 이력에 남도록
+	if err := history.SetActor(ctx, tx, history.Actor{ID: reviewerID, Source: source}); err != nil {
+		return Upload{}, err
+	}
+
+	var from, uid, target string
+	var assetID *string
+	var newer bool // 이 업로드보다 나중에 올린 대표 사진이 이미 승인됐다
+	err = tx.QueryRow(ctx, `
+		SELECT f.status, f.user_id, f.asset_id, f.target,
+		       EXISTS (SELECT 1 FROM user_face_upload n
+		               WHERE n.user_id=f.user_id AND n.target='primary' AND n.status='approved' AND n.created_at > f.created_at)
+		FROM user_face_upload f WHERE f.id=$1 FOR UPDATE`, id).
+		Scan(&from, &uid, &assetID, &target, &newer)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Upload{}, ErrNotFound
+	}
+	if err != nil {
+		return Upload{}, err
+	}
+	if !CanTransition(from, to) {
+		return Upload{}, fmt.Errorf("%w: %s → %s", ErrInvalidTransition, from, to)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE user_face_upload
+		SET status=$2, reject_reason=NULLIF($3,''), review_source=$4, reviewed_by=NULLIF($5,'')::uuid,
+		    reviewed_at=now(), updated_at=now()
+		WHERE id=$1`, id, to, reason, source, reviewerID)
+	if err != nil {
+		return Upload{}, err
+	}
+
+	kind := notify.KindFaceRejected
+	var replaced []string // 교체된 대표 사진의 저장소 키
+	if to == StatusApproved {
+		kind = notify.KindFaceApproved
+		if assetID != nil {
+			switch {
+			case target == TargetGallery:
+				err = gallery.Append(ctx, tx, uid, *assetID, s.GalleryMax)
+			case !newer:
+				replaced, err = gallery.ReplacePrimary(ctx, tx, uid, *assetID)
+			}
+			if err != nil {
+				return Upload{}, err
+			}
+		}
+	}
+	payload := map[string]any{"upload_id": id, "asset_id": assetID}
+	if reason != "" {
+		payload["reason"] = reason
+	}
+	if err := notify.Send(ctx, tx, uid, kind, payload); err != nil {
+		return Upload{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Upload{}, err
+	}
+	// 커밋된 뒤에만 객체를 지운다(실패해도 응답에는 영향 없음)
+	if s.Store != nil {
+		for _, key := range replaced {
+			_ = s.Store.Delete(context.Background(), key)
+		}
+	}
+	return s.Get(ctx, id)
+}
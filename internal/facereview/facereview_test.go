@@ -0,0 +1,57 @@
+package facereview
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCanTransition(t *testing.T) {
+	cases := []struct {
+		from, to string
+		ok       bool
+	}{
+		{StatusUploaded, StatusPendingReview, true},
+		{StatusPendingReview, StatusApproved, true},
+		{StatusPendingReview, StatusRejected, true},
+		{StatusUploaded, StatusApproved, false},
+		{StatusApproved, StatusRejected, false},
+		{StatusRejected, StatusApproved, false},
+		{StatusRejected, StatusPendingReview, false},
+	}
+	for _, tc := range cases {
+		if got := CanTransition(tc.from, tc.to); got != tc.ok {
+			t.Errorf("%s → %s: want %v, got %v", tc.from, tc.to, tc.ok, got)
+		}
+	}
+}
+
+// 같은 입력이면 같은 점수/판정, 제로값은 항상 사람 심사
+func TestFake_Deterministic(t *testing.T) {
+	ctx := context.Background()
+	img := []byte("some image bytes")
+
+	a, _ := Fake{}.Classify(ctx, img)
+	b, _ := Fake{}.Classify(ctx, img)
+	if a != b {
+		t.Fatalf("non-deterministic: %+v vs %+v", a, b)
+	}
+	if a.Verdict != VerdictReview {
+		t.Fatalf("zero Fake must defer to review, got %s", a.Verdict)
+	}
+
+	if r, _ := (Fake{ApproveAbove: a.Score}).Classify(ctx, img); r.Verdict != VerdictApprove {
+		t.Fatalf("expected approve at score %.3f, got %s", a.Score, r.Verdict)
+	}
+	if r, _ := (Fake{RejectBelow: a.Score + 0.001}).Classify(ctx, img); r.Verdict != VerdictReject || r.Reason == "" {
+		t.Fatalf("expected reject with reason, got %+v", r)
+	}
+	if r, _ := (Fake{ApproveAbove: 0.5}).Classify(ctx, nil); r.Verdict != VerdictReview {
+		t.Fatalf("empty input must defer to review, got %s", r.Verdict)
+	}
+}
+
+func TestNewClassifier_Unknown(t *testing.T) {
+	if _, err := NewClassifier("nope", 0, 0); err == nil {
+		t.Fatal("expected error for unknown classifier")
+	}
+}
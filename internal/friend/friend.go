@@ -0,0 +1,147 @@
+// Package friend 는 친구 신청(user_friend_request)과 친구 관계(user_friend)다.
+// 친구는 공개 범위 'friends' 판정(privacy.RelationOf)에 쓴다
+package friend
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+)
+
+// 신청 결과
+const (
+	StatusRequested = "requested" // 상대의 수락을 기다림
+	StatusFriends   = "friends"
+)
+
+var (
+	ErrSelf        = errors.New("cannot befriend yourself")
+	ErrUnknownUser = errors.New("user not found")
+)
+
+// 친구 또는 신청 한 건. CreatedAt 은 친구가 된(신청한) 시각
+type Entry struct {
+	UserID    string    `json:"user_id"`
+	Nickname  *string   `json:"nickname"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// 친구 신청. 상대가 이미 나에게 신청했으면 수락으로 보고 바로 친구가 된다.
+// 이미 친구거나 이미 신청했으면 아무것도 바꾸지 않고 현재 상태와 false
+func Ask(ctx context.Context, pool *pgxpool.Pool, uid, other string) (string, bool, error) {
+	if uid == other {
+		return "", false, ErrSelf
+	}
+	var status string
+	var created bool
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		// 두 사람 행을 같은 순서로 잠가 서로 동시에 신청해도 한쪽은 수락이 된다
+		var n int
+		if err := tx.QueryRow(ctx, `
+			SELECT count(*) FROM (SELECT id FROM app_users WHERE id IN ($1, $2) ORDER BY id FOR NO KEY UPDATE) u`,
+			uid, other).Scan(&n); err != nil {
+			return err
+		}
+		if n < 2 {
+			return ErrUnknownUser
+		}
+		var friends, asked, incoming bool
+		if err := tx.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM user_friend WHERE user_id=$1 AND friend_id=$2),
+			       EXISTS (SELECT 1 FROM user_friend_request WHERE from_id=$1 AND to_id=$2),
+			       EXISTS (SELECT 1 FROM user_friend_request WHERE from_id=$2 AND to_id=$1)`,
+			uid, other).Scan(&friends, &asked, &incoming); err != nil {
+			return err
+		}
+		switch {
+		case friends:
+			status = StatusFriends
+		case asked:
+			status = StatusRequested
+		case incoming:
+			if _, err := tx.Exec(ctx, `DELETE FROM user_friend_request WHERE from_id=$2 AND to_id=$1`, uid, other); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(ctx, `INSERT INTO user_friend (user_id, friend_id) VALUES ($1,$2), ($2,$1)`, uid, other); err != nil {
+				return err
+			}
+			status, created = StatusFriends, true
+		default:
+			if _, err := tx.Exec(ctx, `INSERT INTO user_friend_request (from_id, to_id) VALUES ($1,$2)`, uid, other); err != nil {
+				return err
+			}
+			status, created = StatusRequested, true
+		}
+		return nil
+	})
+	return status, created, err
+}
+
+// 친구 끊기, 보낸 신청 취소, 받은 신청 거절을 한 번에: 두 사람 사이의 관계와 신청을 모두 지운다
+func Remove(ctx context.Context, pool *pgxpool.Pool, uid, other string) (bool, error) {
+	var removed bool
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		a, err := tx.Exec(ctx, `
+			DELETE FROM user_friend WHERE (user_id=$1 AND friend_id=$2) OR (user_id=$2 AND friend_id=$1)`, uid, other)
+		if err != nil {
+			return err
+		}
+		b, err := tx.Exec(ctx, `
+			DELETE FROM user_friend_request WHERE (from_id=$1 AND to_id=$2) OR (from_id=$2 AND to_id=$1)`, uid, other)
+		if err != nil {
+			return err
+		}
+		removed = a.RowsAffected()+b.RowsAffected() > 0
+		return nil
+	})
+	return removed, err
+}
+
+// 친구 목록(최근에 친구가 된 순)
+func List(ctx context.Context, q db.Querier, uid string) ([]Entry, error) {
+	return list(ctx, q, `
+		SELECT f.friend_id::text, u.nickname, f.created_at
+		FROM user_friend f JOIN app_users u ON u.id = f.friend_id
+		WHERE f.user_id=$1 ORDER BY f.created_at DESC, f.friend_id`, uid)
+}
+
+// 받은 신청(incoming)과 보낸 신청(outgoing), 최근 것부터
+func Requests(ctx context.Context, q db.Querier, uid string) (incoming, outgoing []Entry, err error) {
+	incoming, err = list(ctx, q, `
+		SELECT r.from_id::text, u.nickname, r.created_at
+		FROM user_friend_request r JOIN app_users u ON u.id = r.from_id
+		WHERE r.to_id=$1 ORDER BY r.created_at DESC, r.from_id`, uid)
+	if err != nil {
+		return nil, nil, err
+	}
+	outgoing, err = list(ctx, q, `
+		SELECT r.to_id::text, u.nickname, r.created_at
+		FROM user_friend_request r JOIN app_users u ON u.id = r.to_id
+		WHERE r.from_id=$1 ORDER BY r.created_at DESC, r.to_id`, uid)
+	if err != nil {
+		return nil, nil, err
+	}
+	return incoming, outgoing, nil
+}
+
+func list(ctx context.Context, q db.Querier, sql string, args ...any) ([]Entry, error) {
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Entry{}
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.UserID, &e.Nickname, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
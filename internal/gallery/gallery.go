@@ -0,0 +1,257 @@
+package gallery
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+	"github.com/creators-of-happiness/amigo-backend/internal/media"
+)
+
+var (
+	ErrNotFound = errors.New("photo not found")
+	ErrFull     = errors.New("photo gallery is full")
+	ErrBadOrder = errors.New("order must list every photo exactly once")
+)
+
+type Photo struct {
+	ID        string    `json:"id"`
+	AssetID   string    `json:"asset_id"`
+	URL       string    `json:"url"`
+	Position  int       `json:"position"`
+	IsPrimary bool      `json:"is_primary"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Variants map[string]media.VariantInfo `json:"variants"`
+}
+
+// 표시 순서대로(파생 이미지 포함)
+func List(ctx context.Context, q db.Querier, uid string) ([]Photo, error) {
+	rows, err := q.Query(ctx, `
+		SELECT p.id, p.asset_id::text, ma.url, p.position, p.is_primary, p.created_at
+		FROM user_photo p
+		JOIN media_asset ma ON ma.id = p.asset_id
+		WHERE p.user_id=$1
+		ORDER BY p.position`, uid)
+	if err != nil {
+		return nil, err
+	}
+	out := []Photo{}
+	byAsset := map[string]*Photo{}
+	for rows.Next() {
+		var p Photo
+		if err := rows.Scan(&p.ID, &p.AssetID, &p.URL, &p.Position, &p.IsPrimary, &p.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		p.Variants = map[string]media.VariantInfo{}
+		out = append(out, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return out, nil
+	}
+
+	ids := make([]string, len(out))
+	for i := range out {
+		ids[i] = out[i].AssetID
+		byAsset[out[i].AssetID] = &out[i]
+	}
+	vrows, err := q.Query(ctx, `
+		SELECT asset_id::text, name, url, width, height, content_type
+		FROM media_asset_variant WHERE asset_id = ANY($1::uuid[])`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer vrows.Close()
+	for vrows.Next() {
+		var assetID, name string
+		var v media.VariantInfo
+		if err := vrows.Scan(&assetID, &name, &v.URL, &v.Width, &v.Height, &v.ContentType); err != nil {
+			return nil, err
+		}
+		if p := byAsset[assetID]; p != nil {
+			p.Variants[name] = v
+		}
+	}
+	return out, vrows.Err()
+}
+
+// 갤러리 사진 수 + 갤러리로 올려 심사 대기 중인 수 + 아직 바이트를 올리지 않은 유효한 업로드 세션 수
+// (모두 승인되면 자리를 차지한다)
+func Reserved(ctx context.Context, q db.Querier, uid string) (int, error) {
+	var n int
+	err := q.QueryRow(ctx, `
+		SELECT (SELECT count(*) FROM user_photo WHERE user_id=$1)
+		     + (SELECT count(*) FROM user_face_upload WHERE user_id=$1 AND target='gallery' AND status IN ('uploaded','pending_review'))
+		     + (SELECT count(*) FROM media_upload WHERE user_id=$1 AND purpose='gallery_photo' AND status='pending' AND expires_at > now())`,
+		uid).Scan(&n)
+	return n, err
+}
+
+// 맨 뒤에 추가. 첫 사진이면 대표 사진이 된다. 이미 limit 장이면 ErrFull(0 이면 제한 없음).
+// 동시에 승인돼도 상한을 넘지 않도록 사용자 행을 잠그고 센다(q 는 트랜잭션이어야 한다)
+func Append(ctx context.Context, q db.Querier, uid, assetID string, limit int) error {
+	var n int
+	err := q.QueryRow(ctx, `
+		SELECT (SELECT count(*) FROM user_photo WHERE user_id=$1 AND asset_id<>$2)
+		FROM app_users WHERE id=$1 FOR NO KEY UPDATE`, uid, assetID).Scan(&n)
+	if err != nil {
+		return err
+	}
+	if limit > 0 && n >= limit {
+		return ErrFull
+	}
+	_, err = q.Exec(ctx, `
+		INSERT INTO user_photo (user_id, asset_id, position, is_primary)
+		SELECT $1, $2,
+		       COALESCE((SELECT max(position) + 1 FROM user_photo WHERE user_id=$1), 0),
+		       NOT EXISTS (SELECT 1 FROM user_photo WHERE user_id=$1 AND is_primary)
+		ON CONFLICT (user_id, asset_id) DO NOTHING`, uid, assetID)
+	if err != nil {
+		return err
+	}
+	return syncProfileImage(ctx, q, uid)
+}
+
+// 대표 사진 교체(기존 단일 사진 API). 대표 사진 자리의 사진을 바꾸고, 없으면 맨 앞에 추가.
+// 밀려난 예전 대표 사진의 저장소 키(원본과 파생본)를 돌려준다. 호출자가 커밋한 뒤 객체를 지운다
+func ReplacePrimary(ctx context.Context, q db.Querier, uid, assetID string) ([]string, error) {
+	// 이미 갤러리에 있는 사진이면 대표로만 지정
+	var id string
+	err := q.QueryRow(ctx, `SELECT id FROM user_photo WHERE user_id=$1 AND asset_id=$2`, uid, assetID).Scan(&id)
+	if err == nil {
+		return nil, SetPrimary(ctx, q, uid, id)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	// 대표 자리의 예전 사진 에셋은 더 쓰이지 않으므로 지운다(파생본 행은 FK 로 함께)
+	var old *string
+	err = q.QueryRow(ctx, `
+		WITH prev AS (SELECT asset_id FROM user_photo WHERE user_id=$1 AND is_primary FOR UPDATE)
+		UPDATE user_photo SET asset_id=$2, created_at=now() WHERE user_id=$1 AND is_primary
+		RETURNING (SELECT asset_id::text FROM prev)`, uid, assetID).Scan(&old)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	var keys []string
+	if old != nil {
+		if keys, err = storageKeys(ctx, q, *old); err != nil {
+			return nil, err
+		}
+		if _, err := q.Exec(ctx, `DELETE FROM media_asset WHERE id=$1`, *old); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := q.Exec(ctx, `UPDATE user_photo SET position=position+1 WHERE user_id=$1`, uid); err != nil {
+			return nil, err
+		}
+		if _, err := q.Exec(ctx, `
+			INSERT INTO user_photo (user_id, asset_id, position, is_primary)
+			VALUES ($1, $2, 0, true)`, uid, assetID); err != nil {
+			return nil, err
+		}
+	}
+	return keys, syncProfileImage(ctx, q, uid)
+}
+
+// 에셋 원본과 파생본의 저장소 키(외부 URL 로만 등록된 원본은 키가 없다)
+func storageKeys(ctx context.Context, q db.Querier, assetID string) ([]string, error) {
+	rows, err := q.Query(ctx, `
+		SELECT storage_key FROM media_asset WHERE id=$1 AND storage_key IS NOT NULL
+		UNION ALL
+		SELECT storage_key FROM media_asset_variant WHERE asset_id=$1`, assetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func SetPrimary(ctx context.Context, q db.Querier, uid, photoID string) error {
+	var exists bool
+	if err := q.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM user_photo WHERE id=$2 AND user_id=$1)`, uid, photoID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	// 부분 유니크 인덱스는 행마다 검사하므로 먼저 모두 내린다
+	if _, err := q.Exec(ctx, `UPDATE user_photo SET is_primary=false WHERE user_id=$1 AND is_primary`, uid); err != nil {
+		return err
+	}
+	if _, err := q.Exec(ctx, `UPDATE user_photo SET is_primary=true WHERE id=$2 AND user_id=$1`, uid, photoID); err != nil {
+		return err
+	}
+	return syncProfileImage(ctx, q, uid)
+}
+
+// ids 순서로 position 재배치. 모든 사진을 한 번씩 나열해야 한다
+func Reorder(ctx context.Context, q db.Querier, uid string, ids []string) error {
+	var total, matched int
+	err := q.QueryRow(ctx, `
+		SELECT (SELECT count(*) FROM user_photo WHERE user_id=$1),
+		       (SELECT count(DISTINCT p.id) FROM user_photo p WHERE p.user_id=$1 AND p.id = ANY($2::uuid[]))`,
+		uid, ids).Scan(&total, &matched)
+	if err != nil {
+		return err
+	}
+	if total != len(ids) || matched != len(ids) {
+		return ErrBadOrder
+	}
+	_, err = q.Exec(ctx, `
+		UPDATE user_photo p SET position = v.ord - 1
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS v(id, ord)
+		WHERE p.id = v.id AND p.user_id=$1`, uid, ids)
+	return err
+}
+
+// 삭제 후 빈자리를 당기고, 대표 사진이었으면 맨 앞 사진이 대표가 된다
+func Delete(ctx context.Context, q db.Querier, uid, photoID string) error {
+	var pos int
+	var primary bool
+	err := q.QueryRow(ctx, `DELETE FROM user_photo WHERE id=$2 AND user_id=$1 RETURNING position, is_primary`, uid, photoID).
+		Scan(&pos, &primary)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if _, err := q.Exec(ctx, `UPDATE user_photo SET position=position-1 WHERE user_id=$1 AND position > $2`, uid, pos); err != nil {
+		return err
+	}
+	if primary {
+		if _, err := q.Exec(ctx, `
+			UPDATE user_photo SET is_primary=true
+			WHERE id = (SELECT id FROM user_photo WHERE user_id=$1 ORDER BY position LIMIT 1)`, uid); err != nil {
+			return err
+		}
+	}
+	return syncProfileImage(ctx, q, uid)
+}
+
+// 대표 사진을 user_profile.profile_image_id 에 반영(없으면 NULL)
+func syncProfileImage(ctx context.Context, q db.Querier, uid string) error {
+	_, err := q.Exec(ctx, `
+		INSERT INTO user_profile (user_id, profile_image_id, created_at, updated_at)
+		VALUES ($1, (SELECT asset_id FROM user_photo WHERE user_id=$1 AND is_primary), now(), now())
+		ON CONFLICT (user_id) DO UPDATE SET profile_image_id=EXCLUDED.profile_image_id, updated_at=now()
+		WHERE user_profile.profile_image_id IS DISTINCT FROM EXCLUDED.profile_image_id`, uid)
+	return err
+}
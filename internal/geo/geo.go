@@ -0,0 +1,86 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+const earthRadiusKm = 6371.0088
+
+var ErrOutOfRange = errors.New("latitude must be -90..90 and longitude -180..180")
+
+func Validate(lat, lng float64) error {
+	if math.IsNaN(lat) || math.IsNaN(lng) || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return ErrOutOfRange
+	}
+	return nil
+}
+
+// 두 좌표 사이 대원 거리(km)
+func Haversine(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat, dLng := rad(lat2-lat1), rad(lng2-lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(rad(lat1))*math.Cos(rad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func rad(d float64) float64 { return d * math.Pi / 180 }
+
+// 중심에서 km 안의 점을 모두 포함하는 위경도 사각형(인덱스 범위 검색용).
+// 극점이나 날짜변경선에 걸치면 경도는 전체 범위
+func BoundingBox(lat, lng, km float64) (minLat, maxLat, minLng, maxLng float64) {
+	dLat := km / earthRadiusKm * 180 / math.Pi
+	minLat, maxLat = math.Max(lat-dLat, -90), math.Min(lat+dLat, 90)
+	if minLat == -90 || maxLat == 90 {
+		return minLat, maxLat, -180, 180
+	}
+	dLng := dLat / math.Cos(rad(lat))
+	minLng, maxLng = lng-dLng, lng+dLng
+	if minLng < -180 || maxLng > 180 {
+		return minLat, maxLat, -180, 180
+	}
+	return minLat, maxLat, minLng, maxLng
+}
+
+// 소수점 decimals 자리로 반올림(2자리 ≈ 1.1km). 저장 좌표의 정밀도를 낮춰 위치 노출을 줄인다
+func Coarsen(v float64, decimals int) float64 {
+	p := math.Pow(10, float64(decimals))
+	return math.Round(v*p) / p
+}
+
+const base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// 지오해시(precision 글자). 5 ≈ 4.9km, 6 ≈ 1.2km 격자
+func Encode(lat, lng float64, precision int) string {
+	latLo, latHi := -90.0, 90.0
+	lngLo, lngHi := -180.0, 180.0
+	var b strings.Builder
+	bit, ch, even := 0, 0, true
+	for b.Len() < precision {
+		if even {
+			mid := (lngLo + lngHi) / 2
+			if lng >= mid {
+				ch = ch<<1 | 1
+				lngLo = mid
+			} else {
+				ch <<= 1
+				lngHi = mid
+			}
+		} else {
+			mid := (latLo + latHi) / 2
+			if lat >= mid {
+				ch = ch<<1 | 1
+				latLo = mid
+			} else {
+				ch <<= 1
+				latHi = mid
+			}
+		}
+		even = !even
+		if bit++; bit == 5 {
+			b.WriteByte(base32[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return b.String()
+}
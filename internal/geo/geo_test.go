@@ -0,0 +1,66 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	cases := []struct {
+		lat, lng float64
+		n        int
+		want     string
+	}{
+		{57.64911, 10.40744, 11, "u4pruydqqvj"}, // 위키백과 예제
+		{37.5665, 126.9780, 6, "wydm9q"},        // 서울시청
+		{35.1796, 129.0756, 5, "wy7b1"},         // 부산
+	}
+	for _, tc := range cases {
+		if got := Encode(tc.lat, tc.lng, tc.n); got != tc.want {
+			t.Errorf("Encode(%v, %v, %d) = %q, want %q", tc.lat, tc.lng, tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestHaversine(t *testing.T) {
+	// 서울 ↔ 부산 약 325km
+	d := Haversine(37.5665, 126.9780, 35.1796, 129.0756)
+	if math.Abs(d-325) > 5 {
+		t.Fatalf("Seoul-Busan = %.1f km", d)
+	}
+	if Haversine(37.5, 127, 37.5, 127) != 0 {
+		t.Fatal("same point must be 0")
+	}
+}
+
+func TestCoarsen(t *testing.T) {
+	if got := Coarsen(37.566535, 2); got != 37.57 {
+		t.Fatalf("got %v", got)
+	}
+	if got := Coarsen(-126.974, 2); got != -126.97 {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if Validate(37.5, 127) != nil || Validate(91, 0) == nil || Validate(0, -181) == nil || Validate(math.NaN(), 0) == nil {
+		t.Fatal("unexpected validation result")
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	lat, lng := 37.5665, 126.9780
+	minLat, maxLat, minLng, maxLng := BoundingBox(lat, lng, 10)
+	// 상자 네 변의 중점은 중심에서 10km 이상 떨어져 있어야 한다
+	for _, p := range [][2]float64{{minLat, lng}, {maxLat, lng}, {lat, minLng}, {lat, maxLng}} {
+		if d := Haversine(lat, lng, p[0], p[1]); d < 10-1e-6 {
+			t.Fatalf("edge %v only %.3f km away", p, d)
+		}
+	}
+	if _, _, minLng, maxLng := BoundingBox(89.99, 0, 10); minLng != -180 || maxLng != 180 {
+		t.Fatal("near pole must span all longitudes")
+	}
+	if _, _, minLng, maxLng := BoundingBox(0, 179.99, 10); minLng != -180 || maxLng != 180 {
+		t.Fatal("across antimeridian must span all longitudes")
+	}
+}
@@ -0,0 +1,208 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/config"
+	"github.com/creators-of-happiness/amigo-backend/internal/facereview"
+	"github.com/creators-of-happiness/amigo-backend/internal/gallery"
+	"github.com/creators-of-happiness/amigo-backend/internal/history"
+	"github.com/creators-of-happiness/amigo-backend/internal/middleware"
+	"github.com/creators-of-happiness/amigo-backend/internal/util"
+)
+
+func Register(v1 *gin.RouterGroup, pool *pgxpool.Pool, cfg config.Config, reviewer *facereview.Service) {
+	adm := v1.Group("/admin", middleware.Auth(cfg.AuthSecret), middleware.Admin(cfg.AdminUserIDs))
+
+	// 얼굴 사진 심사 대기열(기본 pending_review, 오래된 순)
+	adm.GET("/face-uploads", func(c *gin.Context) {
+		status := c.DefaultQuery("status", facereview.StatusPendingReview)
+		switch status {
+		case facereview.StatusUploaded, facereview.StatusPendingReview, facereview.StatusApproved, facereview.StatusRejected:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 || limit > 200 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be 1..200"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		items, err := reviewer.Queue(ctx, status, limit)
+		if err != nil {
+			writeReviewError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items})
+	})
+
+	type uploadURI struct {
+		ID string `uri:"id" binding:"required,uuid"`
+	}
+
+	adm.POST("/face-uploads/:id/approve", func(c *gin.Context) {
+		var uri uploadURI
+		if err := c.ShouldBindUri(&uri); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		up, err := reviewer.Approve(ctx, uri.ID, c.GetString("uid"))
+		if err != nil {
+			writeReviewError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, up)
+	})
+
+	adm.POST("/face-uploads/:id/reject", func(c *gin.Context) {
+		var uri uploadURI
+		if err := c.ShouldBindUri(&uri); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var in struct {
+			Reason string `json:"reason" binding:"required,max=500"`
+		}
+		if err := c.ShouldBindJSON(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		up, err := reviewer.Reject(ctx, uri.ID, c.GetString("uid"), in.Reason)
+		if err != nil {
+			writeReviewError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, up)
+	})
+
+	registerUsers(adm, pool)
+	registerCatalog(adm, pool)
+	registerInventory(adm, pool)
+	registerWallet(adm, pool)
+}
+
+func registerUsers(adm *gin.RouterGroup, pool *pgxpool.Pool) {
+	type userURI struct {
+		ID string `uri:"id" binding:"required,uuid"`
+	}
+
+	// 사용자 프로필 변경 이력(최신순, before=이전 페이지 마지막 id)
+	adm.GET("/users/:id/history", func(c *gin.Context) {
+		var uri userURI
+		if err := c.ShouldBindUri(&uri); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+		if err != nil || limit < 1 || limit > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be 1..500"})
+			return
+		}
+		before, err := strconv.ParseInt(c.DefaultQuery("before", "0"), 10, 64)
+		if err != nil || before < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid before"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		items, err := history.List(ctx, pool, uri.ID, before, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items})
+	})
+
+	// 특정 시점(at, RFC3339)의 프로필. 생략하면 현재
+	adm.GET("/users/:id/profile", func(c *gin.Context) {
+		var uri userURI
+		if err := c.ShouldBindUri(&uri); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		at := time.Now()
+		if v := c.Query("at"); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "at must be RFC3339"})
+				return
+			}
+			at = t
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		snap, err := history.AsOf(ctx, pool, uri.ID, at)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(snap) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found at that time"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"user_id": uri.ID, "at": at, "profile": snap})
+	})
+
+	// 고객지원: 잠긴 생년월일 정정(이력에 관리자로 남는다)
+	adm.PATCH("/users/:id/birthdate", func(c *gin.Context) {
+		var uri userURI
+		if err := c.ShouldBindUri(&uri); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var in struct {
+			Birthdate string `json:"birthdate" binding:"required,datetime=2006-01-02"`
+		}
+		if err := c.ShouldBindJSON(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		ct, err := history.Exec(ctx, pool, history.Actor{ID: c.GetString("uid"), Source: history.SourceAdmin},
+			`UPDATE user_profile SET birth_date=$2::date, updated_at=now() WHERE user_id=$1`, uri.ID, in.Birthdate)
+		if err != nil {
+			if util.IsCheckViolation(err) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if ct.RowsAffected() == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "profile not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+}
+
+func writeReviewError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, facereview.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, facereview.ErrInvalidTransition), errors.Is(err, gallery.ErrFull):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case util.IsClientInputError(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
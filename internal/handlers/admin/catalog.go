@@ -0,0 +1,220 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	pgconnv5 "github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/catalog"
+	"github.com/creators-of-happiness/amigo-backend/internal/history"
+)
+
+// 카탈로그 관리. 지우지 않고 숨기며(DELETE = 비활성화), 바뀐 행은 catalog_audit 에 관리자와 함께 남는다
+func registerCatalog(adm *gin.RouterGroup, pool *pgxpool.Pool) {
+	cat := adm.Group("/catalog")
+
+	// 변경 기록(최신순, before=이전 페이지 마지막 id). /:entity 보다 먼저 등록
+	cat.GET("/audit", func(c *gin.Context) {
+		entity := c.Query("entity")
+		if entity != "" {
+			if _, err := catalog.Lookup(entity); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+		if err != nil || limit < 1 || limit > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be 1..500"})
+			return
+		}
+		before, err := strconv.ParseInt(c.DefaultQuery("before", "0"), 10, 64)
+		if err != nil || before < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid before"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		items, err := catalog.Audit(ctx, pool, entity, c.Query("key"), before, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items})
+	})
+
+	// active=true|false 로 거를 수 있다(생략하면 전부)
+	cat.GET("/:entity", func(c *gin.Context) {
+		e, ok := entityParam(c)
+		if !ok {
+			return
+		}
+		var in struct {
+			Active *bool `form:"active"`
+			Limit  int   `form:"limit,default=500" binding:"min=1,max=1000"`
+		}
+		if err := c.ShouldBindQuery(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		items, err := e.List(ctx, pool, in.Active, in.Limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"entity": e.Name, "items": items})
+	})
+
+	cat.GET("/:entity/:key", func(c *gin.Context) {
+		e, ok := entityParam(c)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		row, err := e.Get(ctx, pool, c.Param("key"))
+		if err != nil {
+			writeCatalogError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, row)
+	})
+
+	cat.POST("/:entity", func(c *gin.Context) {
+		e, ok := entityParam(c)
+		if !ok {
+			return
+		}
+		vals, ok := bindValues(c, e, true)
+		if !ok {
+			return
+		}
+		row, err := writeCatalog(c, pool, func(ctx context.Context, tx pgx.Tx) (json.RawMessage, error) {
+			return e.Create(ctx, tx, vals)
+		})
+		if err != nil {
+			writeCatalogError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, row)
+	})
+
+	cat.PATCH("/:entity/:key", func(c *gin.Context) {
+		e, ok := entityParam(c)
+		if !ok {
+			return
+		}
+		vals, ok := bindValues(c, e, false)
+		if !ok {
+			return
+		}
+		row, err := writeCatalog(c, pool, func(ctx context.Context, tx pgx.Tx) (json.RawMessage, error) {
+			return e.Update(ctx, tx, c.Param("key"), vals)
+		})
+		if err != nil {
+			writeCatalogError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, row)
+	})
+
+	// 비활성화. 이미 고른 사용자의 참조는 유지되고 새로 고를 수만 없다
+	cat.DELETE("/:entity/:key", func(c *gin.Context) {
+		setActive(c, pool, false)
+	})
+
+	cat.POST("/:entity/:key/enable", func(c *gin.Context) {
+		setActive(c, pool, true)
+	})
+}
+
+func setActive(c *gin.Context, pool *pgxpool.Pool, active bool) {
+	e, ok := entityParam(c)
+	if !ok {
+		return
+	}
+	if !e.Disable {
+		writeCatalogError(c, catalog.ErrCannotDisable)
+		return
+	}
+	row, err := writeCatalog(c, pool, func(ctx context.Context, tx pgx.Tx) (json.RawMessage, error) {
+		return e.SetActive(ctx, tx, c.Param("key"), active)
+	})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, row)
+}
+
+func entityParam(c *gin.Context) (*catalog.Entity, bool) {
+	e, err := catalog.Lookup(c.Param("entity"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return e, true
+}
+
+func bindValues(c *gin.Context, e *catalog.Entity, create bool) (map[string]any, bool) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 64<<10))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	vals, err := e.Values(body, create)
+	if err != nil {
+		writeCatalogError(c, err)
+		return nil, false
+	}
+	return vals, true
+}
+
+// 관리자를 행위자로 건 트랜잭션에서 쓰기
+func writeCatalog(c *gin.Context, pool *pgxpool.Pool, fn func(ctx context.Context, tx pgx.Tx) (json.RawMessage, error)) (json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+	var row json.RawMessage
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		if err := history.SetActor(ctx, tx, history.Actor{ID: c.GetString("uid"), Source: history.SourceAdmin}); err != nil {
+			return err
+		}
+		var err error
+		row, err = fn(ctx, tx)
+		return err
+	})
+	return row, err
+}
+
+func writeCatalogError(c *gin.Context, err error) {
+	var fe *catalog.FieldError
+	var pg *pgconnv5.PgError
+	switch {
+	case errors.As(err, &fe):
+		c.JSON(http.StatusBadRequest, gin.H{"error": fe.Error(), "field": fe.Field})
+	case errors.Is(err, catalog.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, catalog.ErrCannotDisable):
+		c.JSON(http.StatusMethodNotAllowed, gin.H{"error": err.Error()})
+	case errors.Is(err, catalog.ErrHasActiveChildren), errors.Is(err, catalog.ErrParentInactive):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.As(err, &pg) && pg.Code == "23505": // unique_violation
+		c.JSON(http.StatusConflict, gin.H{"error": "already exists", "constraint": pg.ConstraintName})
+	case errors.As(err, &pg) && (pg.Code == "23503" || pg.Code == "23514"): // foreign_key / check
+		c.JSON(http.StatusBadRequest, gin.H{"error": pg.Message, "constraint": pg.ConstraintName})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
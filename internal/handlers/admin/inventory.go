@@ -0,0 +1,102 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/inventory"
+	"github.com/creators-of-happiness/amigo-backend/internal/middleware"
+)
+
+// 사용자 보유 항목 지급/회수(고객지원, 업적 보상)
+func registerInventory(adm *gin.RouterGroup, pool *pgxpool.Pool) {
+	type itemURI struct {
+		ID     string `uri:"id" binding:"required,uuid"`
+		Entity string `uri:"entity" binding:"required,oneof=character_item bg_item avatar_part"`
+		ItemID string `uri:"item_id" binding:"required,uuid"`
+	}
+
+	adm.GET("/users/:id/inventory", middleware.Locale(), func(c *gin.Context) {
+		var uri struct {
+			ID string `uri:"id" binding:"required,uuid"`
+		}
+		if err := c.ShouldBindUri(&uri); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		items, err := inventory.List(ctx, pool, uri.ID, "", c.GetString("locale"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items})
+	})
+
+	// 지급. 이미 가졌으면 200 + granted=false
+	adm.POST("/users/:id/inventory", func(c *gin.Context) {
+		var uri struct {
+			ID string `uri:"id" binding:"required,uuid"`
+		}
+		if err := c.ShouldBindUri(&uri); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var in struct {
+			Entity    string `json:"entity" binding:"required,oneof=character_item bg_item avatar_part"`
+			ItemID    string `json:"item_id" binding:"required,uuid"`
+			Source    string `json:"source" binding:"omitempty,oneof=admin achievement"`
+			SourceRef string `json:"source_ref" binding:"max=200"`
+		}
+		if err := c.ShouldBindJSON(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if in.Source == "" {
+			in.Source = inventory.SourceAdmin
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		granted, err := inventory.Give(ctx, pool, inventory.Grant{UserID: uri.ID, Entity: in.Entity, ItemID: in.ItemID,
+			Source: in.Source, SourceRef: in.SourceRef, GrantedBy: c.GetString("uid")})
+		switch {
+		case errors.Is(err, inventory.ErrUnknownUser):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, inventory.ErrUnknownItem):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		case granted:
+			c.JSON(http.StatusCreated, gin.H{"granted": true})
+		default:
+			c.JSON(http.StatusOK, gin.H{"granted": false})
+		}
+	})
+
+	// 회수. 지금 아바타에 쓰고 있으면 바꿀 때까지 유지된다
+	adm.DELETE("/users/:id/inventory/:entity/:item_id", func(c *gin.Context) {
+		var uri itemURI
+		if err := c.ShouldBindUri(&uri); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		ok, err := inventory.Revoke(ctx, pool, uri.ID, uri.Entity, uri.ItemID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "item not in inventory"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+}
@@ -0,0 +1,126 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/inventory"
+	"github.com/creators-of-happiness/amigo-backend/internal/wallet"
+)
+
+// 코인 지급/환불과 원장 대사(고객지원, 정산)
+func registerWallet(adm *gin.RouterGroup, pool *pgxpool.Pool) {
+	type userURI struct {
+		ID string `uri:"id" binding:"required,uuid"`
+	}
+
+	// 잔액과 거래(최신순, before=이전 페이지 마지막 id)
+	adm.GET("/users/:id/wallet", func(c *gin.Context) {
+		var uri userURI
+		if err := c.ShouldBindUri(&uri); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+		if err != nil || limit < 1 || limit > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be 1..500"})
+			return
+		}
+		before, err := strconv.ParseInt(c.DefaultQuery("before", "0"), 10, 64)
+		if err != nil || before < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid before"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		balance, err := wallet.Balance(ctx, pool, uri.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		items, err := wallet.List(ctx, pool, uri.ID, before, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"balance": balance, "items": items})
+	})
+
+	// 코인 지급. request_id 를 주면 같은 요청은 한 번만 처리된다(다시 보내면 200 + 처음 거래)
+	adm.POST("/users/:id/wallet/grants", func(c *gin.Context) {
+		var uri userURI
+		if err := c.ShouldBindUri(&uri); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var in struct {
+			Amount    int64  `json:"amount" binding:"required,min=1,max=1000000"`
+			Reason    string `json:"reason" binding:"required,max=500"`
+			RequestID string `json:"request_id" binding:"omitempty,min=8,max=100,printascii"`
+		}
+		if err := c.ShouldBindJSON(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+		defer cancel()
+		t, created, err := wallet.Grant(ctx, pool, uri.ID, in.Amount, in.Reason, in.RequestID, c.GetString("uid"))
+		writeWalletResult(c, t, created, err)
+	})
+
+	// 구매 환불. 값을 돌려주고 항목을 회수한다. 이미 환불했으면 200 + 그 환불
+	adm.POST("/users/:id/wallet/transactions/:txn_id/refund", func(c *gin.Context) {
+		var uri struct {
+			ID    string `uri:"id" binding:"required,uuid"`
+			TxnID int64  `uri:"txn_id" binding:"required,min=1"`
+		}
+		if err := c.ShouldBindUri(&uri); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var in struct {
+			Reason string `json:"reason" binding:"required,max=500"`
+		}
+		if err := c.ShouldBindJSON(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+		defer cancel()
+		t, created, err := wallet.Refund(ctx, pool, uri.ID, uri.TxnID, in.Reason, c.GetString("uid"))
+		writeWalletResult(c, t, created, err)
+	})
+
+	// 잔액↔원장 대사. 어긋난 계정/거래가 있으면 ok=false
+	adm.GET("/wallet/reconcile", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+		r, err := wallet.Reconcile(ctx, pool)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, r)
+	})
+}
+
+func writeWalletResult(c *gin.Context, t wallet.Txn, created bool, err error) {
+	switch {
+	case errors.Is(err, inventory.ErrUnknownUser), errors.Is(err, wallet.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, wallet.ErrNotRefundable), errors.Is(err, wallet.ErrRequestReused):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	case created:
+		c.JSON(http.StatusCreated, t)
+	default:
+		c.JSON(http.StatusOK, t)
+	}
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -8,13 +9,15 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/creators-of-happiness/amigo-backend/internal/config"
+	"github.com/creators-of-happiness/amigo-backend/internal/moderation"
+	"github.com/creators-of-happiness/amigo-backend/internal/nickname"
 	"github.com/creators-of-happiness/amigo-backend/internal/otp"
 	"github.com/creators-of-happiness/amigo-backend/internal/repo"
 	"github.com/creators-of-happiness/amigo-backend/internal/token"
 	"github.com/creators-of-happiness/amigo-backend/internal/util"
 )
 
-func Register(v1 *gin.RouterGroup, pool *pgxpool.Pool, cfg config.Config) {
+func Register(v1 *gin.RouterGroup, pool *pgxpool.Pool, cfg config.Config, filter *moderation.Filter) {
 	g := v1.Group("/auth")
 
 	g.POST("/request-code", func(c *gin.Context) {
@@ -61,7 +64,28 @@ func Register(v1 *gin.RouterGroup, pool *pgxpool.Pool, cfg config.Config) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
 			return
 		}
+		// 닉네임은 선택 입력. 주면 규칙 검사, 이미 닉네임이 있는 사용자는 기존 값 유지
+		if in.Nickname != "" {
+			display, err := nickname.Validate(in.Nickname)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "reason": nickname.Reason(err)})
+				return
+			}
+			if err := filter.Check("nickname", display); err != nil {
+				c.JSON(http.StatusBadRequest, err)
+				return
+			}
+			in.Nickname = display
+		}
 		u, err := repo.FindOrCreateUser(c.Request.Context(), pool, in.Phone, in.Nickname)
+		if errors.Is(err, nickname.ErrTaken) {
+			sugg, serr := nickname.Suggest(c.Request.Context(), pool, in.Nickname, 3)
+			if serr != nil {
+				sugg = []string{}
+			}
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "reason": "taken", "suggestions": sugg})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -17,6 +17,7 @@ import (
 
 	"github.com/creators-of-happiness/amigo-backend/internal/config"
 	"github.com/creators-of-happiness/amigo-backend/internal/handlers/auth"
+	"github.com/creators-of-happiness/amigo-backend/internal/moderation"
 )
 
 // newTestPool tries to create a live pgx pool for tests that need Postgres.
@@ -62,7 +63,7 @@ func setupRouter(pool *pgxpool.Pool, cfg config.Config) *gin.Engine {
 	r := gin.New()
 	r.Use(gin.Recovery())
 	v1 := r.Group("/api/v1")
-	auth.Register(v1, pool, cfg)
+	auth.Register(v1, pool, cfg, moderation.Default())
 	return r
 }
 
@@ -173,7 +174,7 @@ func TestAuth_Verify_OK_NewUser(t *testing.T) {
 	r := setupRouter(pool, cfg)
 
 	phone := fmt.Sprintf("+82 10-%04d-%04d", time.Now().Unix()%10000, time.Now().UnixNano()%10000)
-	nickname := fmt.Sprintf("hjyoon-%d", time.Now().UnixNano())
+	nickname := fmt.Sprintf("hj%d", time.Now().UnixNano()%1e12)
 
 	// request-code step is optional for verify in this implementation; verify trusts fixed code
 	w := doJSON(t, r, http.MethodPost, "/api/v1/auth/verify", map[string]any{
@@ -212,6 +213,19 @@ func TestAuth_Verify_OK_NewUser(t *testing.T) {
 		t.Fatalf("user.id not a uuid: %s", out.User.ID)
 	}
 	t.Cleanup(func() { _, _ = pool.Exec(context.Background(), `DELETE FROM app_users WHERE phone=$1`, phone) })
+
+	// 가입 때 채운 닉네임도 본인(auth) 변경으로 이력에 남는다
+	var exists string
+	_ = pool.QueryRow(context.Background(), `SELECT COALESCE(to_regclass('public.profile_history')::text, '')`).Scan(&exists)
+	if exists != "" {
+		var actor, source *string
+		_ = pool.QueryRow(context.Background(), `
+			SELECT actor_id::text, source FROM profile_history
+			WHERE user_id=$1 AND table_name='app_users' AND field='nickname'`, out.User.ID).Scan(&actor, &source)
+		if actor == nil || *actor != out.User.ID || source == nil || *source != "auth" {
+			t.Fatalf("nickname history: actor=%v source=%v", actor, source)
+		}
+	}
 }
 
 func TestAuth_Verify_OK_ExistingUser_PreserveNicknameOnEmptyInput(t *testing.T) {
@@ -227,7 +241,7 @@ func TestAuth_Verify_OK_ExistingUser_PreserveNicknameOnEmptyInput(t *testing.T)
 	r := setupRouter(pool, cfg)
 
 	phone := "+82 10-9876-5432"
-	first := fmt.Sprintf("first-nick-%d", time.Now().UnixNano())
+	first := fmt.Sprintf("first%d", time.Now().UnixNano()%1e10)
 	// 1) first login with nickname set
 	w1 := doJSON(t, r, http.MethodPost, "/api/v1/auth/verify", map[string]any{
 		"phone":    phone,
@@ -259,3 +273,57 @@ func TestAuth_Verify_OK_ExistingUser_PreserveNicknameOnEmptyInput(t *testing.T)
 	}
 	t.Cleanup(func() { _, _ = pool.Exec(context.Background(), `DELETE FROM app_users WHERE phone=$1`, phone) })
 }
+
+// 규칙 위반 닉네임은 400 (DB 접근 전 거절)
+func TestAuth_Verify_InvalidNickname(t *testing.T) {
+	cfg := config.Config{AuthSecret: "test-secret", OTPFixedCode: "000000"}
+	r := setupRouter(nil, cfg)
+
+	for _, nick := range []string{"a", "bad nick!", "운영자", "_lead", "sh1thead"} {
+		w := doJSON(t, r, http.MethodPost, "/api/v1/auth/verify", map[string]any{
+			"phone":    "+82 10-1111-2222",
+			"code":     cfg.OTPFixedCode,
+			"nickname": nick,
+		})
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%q: expected 400, got %d, body=%s", nick, w.Code, w.Body.String())
+		}
+	}
+}
+
+// 다른 사용자가 쓰는 닉네임(대소문자/전각 무시)으로 가입하면 409 + 대안
+func TestAuth_Verify_NicknameTaken(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+
+	cfg := config.Config{
+		AuthSecret:        "test-secret",
+		OTPFixedCode:      "000000",
+		OTPExpiresMinutes: 5,
+		AccessTokenTTLHrs: 1,
+	}
+	r := setupRouter(pool, cfg)
+
+	phone1 := fmt.Sprintf("+82 10-%04d-%04d", time.Now().Unix()%10000, time.Now().UnixNano()%10000)
+	phone2 := fmt.Sprintf("+82 10-%04d-%04d", (time.Now().Unix()+1)%10000, (time.Now().UnixNano()+7)%10000)
+	t.Cleanup(func() {
+		_, _ = pool.Exec(context.Background(), `DELETE FROM app_users WHERE phone IN ($1,$2)`, phone1, phone2)
+	})
+	nick := fmt.Sprintf("dup%d", time.Now().UnixNano()%1e10)
+
+	w := doJSON(t, r, http.MethodPost, "/api/v1/auth/verify", map[string]any{"phone": phone1, "code": cfg.OTPFixedCode, "nickname": nick})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d, body=%s", w.Code, w.Body.String())
+	}
+	w = doJSON(t, r, http.MethodPost, "/api/v1/auth/verify", map[string]any{"phone": phone2, "code": cfg.OTPFixedCode, "nickname": "ＤＵＰ" + nick[3:]})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d, body=%s", w.Code, w.Body.String())
+	}
+	var out struct {
+		Suggestions []string `json:"suggestions"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &out)
+	if len(out.Suggestions) == 0 {
+		t.Fatalf("expected suggestions, got %s", w.Body.String())
+	}
+}
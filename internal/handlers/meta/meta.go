@@ -2,140 +2,432 @@ package meta
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/creators-of-happiness/amigo-backend/internal/catalog"
+	"github.com/creators-of-happiness/amigo-backend/internal/geo"
+	"github.com/creators-of-happiness/amigo-backend/internal/i18n"
+	"github.com/creators-of-happiness/amigo-backend/internal/listing"
 	"github.com/creators-of-happiness/amigo-backend/internal/middleware"
+	"github.com/creators-of-happiness/amigo-backend/internal/region"
 )
 
-func Register(v1 *gin.RouterGroup, pool *pgxpool.Pool, authSecret string) {
-	g := v1.Group("/meta", middleware.Auth(authSecret))
+// cache 가 nil 이면 ETag/메모리 캐시 없이 매번 조회
+func Register(v1 *gin.RouterGroup, pool *pgxpool.Pool, authSecret string, cache *catalog.Cache) {
+	g := v1.Group("/meta", middleware.Auth(authSecret), middleware.Locale())
+	if cache != nil {
+		g.Use(cache.Middleware())
+	}
 
+	// 행정구역 단계별 조회. parent 가 없으면 시/도, 있으면 그 하위 지역
 	g.GET("/regions", func(c *gin.Context) {
+		var in struct {
+			Parent *int `form:"parent" binding:"omitempty,min=1"`
+		}
+		if err := c.ShouldBindQuery(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
 		defer cancel()
-		rows, err := pool.Query(ctx, `SELECT id, name, latitude, longitude FROM region ORDER BY id`)
+		if in.Parent != nil {
+			if active, err := region.IsActive(ctx, pool, *in.Parent); errors.Is(err, region.ErrNotFound) || err == nil && !active {
+				c.JSON(http.StatusNotFound, gin.H{"error": region.ErrNotFound.Error()})
+				return
+			} else if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		items, err := region.Children(ctx, pool, in.Parent)
+		if err == nil {
+			err = region.LocalizeNodes(ctx, pool, c.GetString("locale"), items)
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		defer rows.Close()
-		var out []gin.H
-		for rows.Next() {
-			var id int
-			var name string
-			var lat, lng *float64
-			if err := rows.Scan(&id, &name, &lat, &lng); err != nil {
-				continue
-			}
-			out = append(out, gin.H{"id": id, "name": name, "latitude": lat, "longitude": lng})
-		}
-		c.JSON(http.StatusOK, gin.H{"items": out})
+		c.JSON(http.StatusOK, gin.H{"locale": c.GetString("locale"), "items": items})
 	})
 
-	g.GET("/job-categories", func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	// GPS 좌표에서 가장 가까운 지역(온보딩에서 지역 미리 선택)
+	g.GET("/regions/nearest", func(c *gin.Context) {
+		var in point
+		if !bindPoint(c, &in) {
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
 		defer cancel()
-		rows, err := pool.Query(ctx, `SELECT code, name FROM job_category ORDER BY name`)
+		r, km, err := region.Nearest(ctx, pool, *in.Lat, *in.Lng)
+		if errors.Is(err, region.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		defer rows.Close()
-		var out []gin.H
-		for rows.Next() {
-			var code, name string
-			if err := rows.Scan(&code, &name); err != nil {
-				continue
-			}
-			out = append(out, gin.H{"code": code, "name": name})
+		path, err := region.Path(ctx, pool, r.ID)
+		if err == nil {
+			err = region.Localize(ctx, pool, c.GetString("locale"), path)
 		}
-		c.JSON(http.StatusOK, gin.H{"items": out})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// 경로의 마지막이 찾은 지역
+		c.JSON(http.StatusOK, gin.H{"locale": c.GetString("locale"), "region": path[len(path)-1], "distance_km": km,
+			"full_name": region.FullName(path), "path": path})
 	})
 
-	g.GET("/character-categories", func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	// 반경 km 안의 지역을 가까운 순으로
+	g.GET("/regions/within", func(c *gin.Context) {
+		var in struct {
+			point
+			Km    float64 `form:"km" binding:"required,gt=0,max=100"`
+			Limit int     `form:"limit" binding:"omitempty,min=1,max=200"`
+		}
+		if !bindPoint(c, &in) {
+			return
+		}
+		if in.Limit == 0 {
+			in.Limit = 50
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
 		defer cancel()
-		rows, err := pool.Query(ctx, `SELECT code, name FROM character_category ORDER BY name`)
+		items, err := region.Within(ctx, pool, *in.Lat, *in.Lng, in.Km, in.Limit)
+		if err == nil {
+			err = region.LocalizeNearby(ctx, pool, c.GetString("locale"), items)
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		defer rows.Close()
-		var out []gin.H
-		for rows.Next() {
+		c.JSON(http.StatusOK, gin.H{"locale": c.GetString("locale"), "items": items})
+	})
+
+	// 오프라인 클라이언트용 증분 동기화. 받은 version 을 다음 since 로 보낸다(0 이면 전체)
+	g.GET("/catalog", func(c *gin.Context) {
+		var in struct {
+			Since int64 `form:"since" binding:"omitempty,min=0"`
+		}
+		if err := c.ShouldBindQuery(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+		b, err := catalog.Delta(ctx, pool, in.Since, c.GetString("locale"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, b)
+	})
+
+	// 목록은 listing 규칙(limit/cursor/sort/q, {items,total,next_cursor})을 따른다
+	g.GET("/job-categories", func(c *gin.Context) {
+		p, ok := bindList(c, codedList)
+		if !ok {
+			return
+		}
+		base := `
+			SELECT code, ` + i18n.Name(i18n.JobCategory, "code", "name", p.Arg(c.GetString("locale"))) + ` AS name
+			FROM job_category WHERE active AND ` + p.Match(i18n.JobCategory, "code", "name")
+		writeList(c, pool, p, base, func(rows pgx.Rows) (gin.H, error) {
 			var code, name string
-			if err := rows.Scan(&code, &name); err != nil {
-				continue
-			}
-			out = append(out, gin.H{"code": code, "name": name})
+			err := rows.Scan(p.Dest(&code, &name)...)
+			return gin.H{"code": code, "name": name}, err
+		})
+	})
+
+	// 프로필 질문 목록(새로 고를 수 있는 것만, 기본은 표시 순서대로)
+	g.GET("/profile-prompts", func(c *gin.Context) {
+		p, ok := bindList(c, promptList)
+		if !ok {
+			return
 		}
-		c.JSON(http.StatusOK, gin.H{"items": out})
+		base := `
+			SELECT code, ` + i18n.Name(i18n.ProfilePrompt, "code", "text", p.Arg(c.GetString("locale"))) + ` AS text,
+			       max_length, sort_order
+			FROM profile_prompt WHERE active AND ` + p.Match(i18n.ProfilePrompt, "code", "text")
+		writeList(c, pool, p, base, func(rows pgx.Rows) (gin.H, error) {
+			var code, text string
+			var maxLen, order int
+			err := rows.Scan(p.Dest(&code, &text, &maxLen, &order)...)
+			return gin.H{"code": code, "text": text, "max_length": maxLen}, err
+		})
+	})
+
+	g.GET("/character-categories", func(c *gin.Context) {
+		p, ok := bindList(c, codedList)
+		if !ok {
+			return
+		}
+		base := `
+			SELECT code, ` + i18n.Name(i18n.CharacterCategory, "code", "name", p.Arg(c.GetString("locale"))) + ` AS name
+			FROM character_category WHERE active AND ` + p.Match(i18n.CharacterCategory, "code", "name")
+		writeList(c, pool, p, base, func(rows pgx.Rows) (gin.H, error) {
+			var code, name string
+			err := rows.Scan(p.Dest(&code, &name)...)
+			return gin.H{"code": code, "name": name}, err
+		})
 	})
 
 	g.GET("/characters", func(c *gin.Context) {
-		category := c.Query("category")
-		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
-		defer cancel()
-		var rows pgx.Rows
-		var err error
-		if category != "" {
-			rows, err = pool.Query(ctx, `
-				SELECT i.id, i.name, m.url
-				FROM character_item i
-				LEFT JOIN media_asset m ON m.id = i.preview_asset
-				WHERE i.category_code=$1
-				ORDER BY i.name`, category)
-		} else {
-			rows, err = pool.Query(ctx, `
-				SELECT i.id, i.name, m.url
-				FROM character_item i
-				LEFT JOIN media_asset m ON m.id = i.preview_asset
-				ORDER BY i.name`)
+		p, ok := bindList(c, itemList)
+		if !ok {
+			return
 		}
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		filter, ok := itemFilter(c, p, "i")
+		if !ok {
 			return
 		}
-		defer rows.Close()
-		var out []gin.H
-		for rows.Next() {
-			var id, name string
-			var url *string
-			if err := rows.Scan(&id, &name, &url); err != nil {
-				continue
-			}
-			out = append(out, gin.H{"id": id, "name": name, "preview_url": url})
+		base := `
+			SELECT i.id, ` + i18n.Name(i18n.CharacterItem, "i.id", "i.name", p.Arg(c.GetString("locale"))) + ` AS name, m.url, ` + catalog.PreviewVariants("i.preview_asset") + ` AS variants,
+			       ` + catalog.ItemAttrCols("i") + `
+			FROM character_item i
+			LEFT JOIN media_asset m ON m.id = i.preview_asset
+			WHERE i.active AND ` + filter + ` AND ` + p.Match(i18n.CharacterItem, "i.id", "i.name")
+		if category := c.Query("category"); category != "" {
+			base += ` AND i.category_code = ` + p.Arg(category)
 		}
-		c.JSON(http.StatusOK, gin.H{"items": out})
+		writeList(c, pool, p, base, scanPreviewItem(p))
 	})
 
 	g.GET("/backgrounds", func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
-		defer cancel()
-		rows, err := pool.Query(ctx, `
-			SELECT b.id, b.name, m.url
+		p, ok := bindList(c, itemList)
+		if !ok {
+			return
+		}
+		filter, ok := itemFilter(c, p, "b")
+		if !ok {
+			return
+		}
+		// character 를 주면 그 캐릭터와 함께 쓸 수 있는 배경만(avatar_compat_rule)
+		var in struct {
+			Character string `form:"character" binding:"omitempty,uuid"`
+		}
+		if err := c.ShouldBindQuery(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if in.Character != "" {
+			filter += ` AND avatar_bg_compatible(` + p.Arg(in.Character) + `::uuid, b.id)`
+		}
+		base := `
+			SELECT b.id, ` + i18n.Name(i18n.BgItem, "b.id", "b.name", p.Arg(c.GetString("locale"))) + ` AS name, m.url, ` + catalog.PreviewVariants("b.preview_asset") + ` AS variants,
+			       ` + catalog.ItemAttrCols("b") + `
 			FROM bg_item b
 			LEFT JOIN media_asset m ON m.id = b.preview_asset
-			ORDER BY b.name`)
+			WHERE b.active AND ` + filter + ` AND ` + p.Match(i18n.BgItem, "b.id", "b.name")
+		writeList(c, pool, p, base, scanPreviewItem(p))
+	})
+
+	// 아바타 슬롯(아래 층부터). required 슬롯은 항상 골라야 한다
+	g.GET("/avatar-slots", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+		defer cancel()
+		rows, err := pool.Query(ctx, `SELECT code, z_order, required FROM avatar_slot ORDER BY z_order`)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 		defer rows.Close()
-		var out []gin.H
+		out := []gin.H{}
 		for rows.Next() {
-			var id, name string
-			var url *string
-			if err := rows.Scan(&id, &name, &url); err != nil {
-				continue
+			var code string
+			var z int
+			var required bool
+			if err := rows.Scan(&code, &z, &required); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
 			}
-			out = append(out, gin.H{"id": id, "name": name, "preview_url": url})
+			out = append(out, gin.H{"code": code, "z_order": z, "required": required})
+		}
+		if err := rows.Err(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
 		c.JSON(http.StatusOK, gin.H{"items": out})
 	})
+
+	// 옷/표정/장신구 항목. slot 으로 한 슬롯만
+	g.GET("/avatar-parts", func(c *gin.Context) {
+		p, ok := bindList(c, itemList)
+		if !ok {
+			return
+		}
+		filter, ok := itemFilter(c, p, "ap")
+		if !ok {
+			return
+		}
+		var in struct {
+			Slot string `form:"slot" binding:"omitempty,oneof=outfit expression accessory"`
+		}
+		if err := c.ShouldBindQuery(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if in.Slot != "" {
+			filter += ` AND ap.slot_code = ` + p.Arg(in.Slot)
+		}
+		base := `
+			SELECT ap.id, ` + i18n.Name(i18n.AvatarPart, "ap.id", "ap.name", p.Arg(c.GetString("locale"))) + ` AS name, m.url, ` + catalog.PreviewVariants("ap.preview_asset") + ` AS variants,
+			       ` + catalog.ItemAttrCols("ap") + `, ap.slot_code, ap.anchor_x::float8, ap.anchor_y::float8, ap.scale::float8
+			FROM avatar_part ap
+			LEFT JOIN media_asset m ON m.id = ap.preview_asset
+			WHERE ap.active AND ` + filter + ` AND ` + p.Match(i18n.AvatarPart, "ap.id", "ap.name")
+		writeList(c, pool, p, base, func(rows pgx.Rows) (gin.H, error) {
+			var slot string
+			var ax, ay, scale float64
+			item, err := scanPreviewItem(p, &slot, &ax, &ay, &scale)(rows)
+			item["slot_code"], item["anchor_x"], item["anchor_y"], item["scale"] = slot, ax, ay, scale
+			return item, err
+		})
+	})
+}
+
+// 정렬 이름은 응답 필드 이름. 같은 값이면 키로 이어 정렬
+var (
+	codedList = listing.Spec{
+		Sorts:   map[string][]listing.Key{"name": {{Col: "name", Type: "text"}}, "code": {{Col: "code", Type: "text"}}},
+		Default: "name",
+		ID:      listing.Key{Col: "code", Type: "text"},
+	}
+	// sort_weight 는 작을수록 앞, 같으면 이름순
+	itemList = listing.Spec{
+		Sorts: map[string][]listing.Key{
+			"name":        {{Col: "name", Type: "text"}},
+			"sort_weight": {{Col: "sort_weight", Type: "int"}, {Col: "name", Type: "text"}},
+		},
+		Default: "sort_weight",
+		ID:      listing.Key{Col: "id", Type: "uuid"},
+	}
+	promptList = listing.Spec{
+		Sorts:   map[string][]listing.Key{"sort_order": {{Col: "sort_order", Type: "int"}}, "text": {{Col: "text", Type: "text"}}},
+		Default: "sort_order",
+		ID:      listing.Key{Col: "code", Type: "text"},
+	}
+)
+
+// 목록 쿼리 바인딩. 실패하면 400 을 쓰고 false
+func bindList(c *gin.Context, spec listing.Spec) (*listing.Page, bool) {
+	var in listing.Query
+	if err := c.ShouldBindQuery(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	p, err := listing.Parse(in, spec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return p, true
+}
+
+// base 를 한 페이지 읽어 {locale, items, total, next_cursor} 로 응답
+func writeList(c *gin.Context, pool *pgxpool.Pool, p *listing.Page, base string, scan func(pgx.Rows) (gin.H, error)) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	var total int
+	sql, args := p.Count(base)
+	if err := pool.QueryRow(ctx, sql, args...).Scan(&total); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	sql, args = p.Select(base)
+	rows, err := pool.Query(ctx, sql, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+	out := []gin.H{}
+	for rows.Next() {
+		if p.Full() {
+			break
+		}
+		item, err := scan(rows)
+		if err != nil {
+			// 건너뛰면 next_cursor 가 빠진 행을 가리킬 수 있다
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"locale": c.GetString("locale"), "items": out, "total": total, "next_cursor": p.NextCursor()})
+}
+
+// 노출 기간 안의 항목만, tag 는 모두 가진 것, rarity 는 그중 하나(둘 다 여러 번 줄 수 있다).
+// 잘못된 값이면 400 을 쓰고 false
+func itemFilter(c *gin.Context, p *listing.Page, alias string) (string, bool) {
+	cond := catalog.Available(alias)
+	if tags := c.QueryArray("tag"); len(tags) > 0 {
+		for _, t := range tags {
+			if !catalog.ValidTag(t) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tag " + strconv.Quote(t)})
+				return "", false
+			}
+		}
+		cond += ` AND ` + alias + `.tags @> ` + p.Arg(tags) + `::text[]`
+	}
+	if rarities := c.QueryArray("rarity"); len(rarities) > 0 {
+		for _, r := range rarities {
+			if !slices.Contains(catalog.Rarities, r) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "rarity must be one of " + strings.Join(catalog.Rarities, ", ")})
+				return "", false
+			}
+		}
+		cond += ` AND ` + alias + `.rarity = ANY(` + p.Arg(rarities) + `::text[])`
+	}
+	return cond, true
+}
+
+// id, name, 미리보기 url/변형, 항목 속성. extra 는 그 뒤 열의 Scan 대상
+func scanPreviewItem(p *listing.Page, extra ...any) func(pgx.Rows) (gin.H, error) {
+	return func(rows pgx.Rows) (gin.H, error) {
+		var id, name string
+		var url *string
+		var variants map[string]any
+		var a catalog.ItemAttrs
+		err := rows.Scan(p.Dest(append(append([]any{&id, &name, &url, &variants}, a.Dest()...), extra...)...)...)
+		return gin.H{"id": id, "name": name, "preview_url": url, "preview_variants": variants,
+			"tags": a.Tags, "rarity": a.Rarity, "sort_weight": a.SortWeight,
+			"available_from": a.AvailableFrom, "available_until": a.AvailableUntil, "is_default": a.IsDefault,
+			"locked": a.Locked, "unlock_source": a.UnlockSource, "price": a.Price}, err
+	}
+}
+
+type point struct {
+	Lat *float64 `form:"lat" binding:"required"`
+	Lng *float64 `form:"lng" binding:"required"`
+}
+
+func (p point) coords() (float64, float64) { return *p.Lat, *p.Lng }
+
+// 쿼리 바인딩 + 좌표 범위 검사. 실패하면 400 을 쓰고 false
+func bindPoint(c *gin.Context, in interface{ coords() (float64, float64) }) bool {
+	if err := c.ShouldBindQuery(in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if err := geo.Validate(in.coords()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
 }
@@ -1,18 +1,22 @@
 package meta_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/creators-of-happiness/amigo-backend/internal/catalog"
 	"github.com/creators-of-happiness/amigo-backend/internal/handlers/meta"
 	"github.com/creators-of-happiness/amigo-backend/internal/token"
 )
@@ -72,7 +76,7 @@ func seedBasicMeta(t *testing.T, pool *pgxpool.Pool) (categoryCode string) {
 	defer cancel()
 
 	// region 하나 보장
-	_, _ = pool.Exec(ctx, `INSERT INTO region (name) VALUES ('Testland') ON CONFLICT (name) DO NOTHING`)
+	_, _ = pool.Exec(ctx, `INSERT INTO region (name) VALUES ('Testland') ON CONFLICT DO NOTHING`)
 
 	// job_category 하나 보장
 	_, _ = pool.Exec(ctx, `INSERT INTO job_category (code, name) VALUES ('ut', 'Unit Testing') ON CONFLICT (code) DO NOTHING`)
@@ -144,7 +148,7 @@ func setupRouter(pool *pgxpool.Pool, secret string) (*gin.Engine, string) {
 	r := gin.New()
 	r.Use(gin.Recovery())
 	v1 := r.Group("/api/v1")
-	meta.Register(v1, pool, secret)
+	meta.Register(v1, pool, secret, nil)
 
 	// 임의 사용자 클레임으로 토큰 생성(미들웨어는 클레임만 확인)
 	tok, _, _ := token.Sign(secret, "00000000-0000-0000-0000-000000000000", "+82 10-0000-0000", time.Hour)
@@ -207,6 +211,186 @@ func TestMeta_Regions_OK(t *testing.T) {
 	}
 }
 
+// 시/도 → 시/군/구 → 읍/면/동 단계별 조회
+func TestMeta_Regions_DrillDown(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	ctx := context.Background()
+
+	var province, district int
+	if err := pool.QueryRow(ctx, `INSERT INTO region (code, name, level) VALUES ('UT00000000','UT도',1) RETURNING id`).Scan(&province); err != nil {
+		t.Skipf("skipping: region hierarchy not migrated: %v", err)
+	}
+	t.Cleanup(func() { _, _ = pool.Exec(context.Background(), `DELETE FROM region WHERE id=$1`, province) })
+	if err := pool.QueryRow(ctx, `INSERT INTO region (code, name, parent_id, level) VALUES ('UT01000000','UT구',$1,2) RETURNING id`, province).Scan(&district); err != nil {
+		t.Fatalf("seed district: %v", err)
+	}
+	if _, err := pool.Exec(ctx, `INSERT INTO region (code, name, parent_id, level) VALUES ('UT01010000','UT동',$1,3)`, district); err != nil {
+		t.Fatalf("seed town: %v", err)
+	}
+
+	r, tok := setupRouter(pool, "test-secret")
+	var out struct {
+		Items []struct {
+			ID          int    `json:"id"`
+			Name        string `json:"name"`
+			Level       int    `json:"level"`
+			ParentID    *int   `json:"parent_id"`
+			HasChildren bool   `json:"has_children"`
+		} `json:"items"`
+	}
+	w := doGET(t, r, fmt.Sprintf("/api/v1/meta/regions?parent=%d", province), tok)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &out)
+	if len(out.Items) != 1 || out.Items[0].ID != district || out.Items[0].Level != 2 || !out.Items[0].HasChildren {
+		t.Fatalf("districts: %+v", out.Items)
+	}
+	w = doGET(t, r, fmt.Sprintf("/api/v1/meta/regions?parent=%d", district), tok)
+	out.Items = nil
+	_ = json.Unmarshal(w.Body.Bytes(), &out)
+	if len(out.Items) != 1 || out.Items[0].Level != 3 || out.Items[0].HasChildren {
+		t.Fatalf("towns: %+v", out.Items)
+	}
+
+	// 최상위 목록에는 시/도만
+	w = doGET(t, r, "/api/v1/meta/regions", tok)
+	out.Items = nil
+	_ = json.Unmarshal(w.Body.Bytes(), &out)
+	for _, it := range out.Items {
+		if it.ParentID != nil {
+			t.Fatalf("top level contains child region: %+v", it)
+		}
+	}
+
+	if w := doGET(t, r, "/api/v1/meta/regions?parent=2147483647", tok); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown parent: expected 404, got %d", w.Code)
+	}
+	if w := doGET(t, r, "/api/v1/meta/regions?parent=abc", tok); w.Code != http.StatusBadRequest {
+		t.Fatalf("bad parent: expected 400, got %d", w.Code)
+	}
+}
+
+// 좌표/반경 누락·범위 밖은 DB 접근 전에 400
+func TestMeta_RegionsGeo_Invalid(t *testing.T) {
+	r, tok := setupRouter(nil, "test-secret")
+	for _, path := range []string{
+		"/api/v1/meta/regions/nearest?lat=37.5",
+		"/api/v1/meta/regions/nearest?lat=91&lng=127",
+		"/api/v1/meta/regions/nearest?lat=abc&lng=127",
+		"/api/v1/meta/regions/within?lat=37.5&lng=127",
+		"/api/v1/meta/regions/within?lat=37.5&lng=127&km=0",
+		"/api/v1/meta/regions/within?lat=37.5&lng=127&km=101",
+		"/api/v1/meta/regions/within?lat=37.5&lng=181&km=5",
+	} {
+		if w := doGET(t, r, path, tok); w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d; body=%s", path, w.Code, w.Body.String())
+		}
+	}
+}
+
+// 가까운 최하위 지역과 반경 검색(가까운 순)
+func TestMeta_RegionsGeo_OK(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	ctx := context.Background()
+
+	// 남극 근처라 다른 시드와 겹치지 않는다
+	var province, near, far int
+	if err := pool.QueryRow(ctx, `INSERT INTO region (code, name, level) VALUES ('UT80000000','UT남극도',1) RETURNING id`).Scan(&province); err != nil {
+		t.Skipf("skipping: region hierarchy not migrated: %v", err)
+	}
+	t.Cleanup(func() { _, _ = pool.Exec(context.Background(), `DELETE FROM region WHERE id=$1`, province) })
+	_ = pool.QueryRow(ctx, `INSERT INTO region (code, name, parent_id, level, latitude, longitude) VALUES ('UT80100000','UT가까운구',$1,2,-70.01,10.01) RETURNING id`, province).Scan(&near)
+	_ = pool.QueryRow(ctx, `INSERT INTO region (code, name, parent_id, level, latitude, longitude) VALUES ('UT80200000','UT먼구',$1,2,-70.2,10.2) RETURNING id`, province).Scan(&far)
+
+	r, tok := setupRouter(pool, "test-secret")
+	w := doGET(t, r, "/api/v1/meta/regions/nearest?lat=-70&lng=10", tok)
+	if w.Code != http.StatusOK {
+		t.Fatalf("nearest: %d %s", w.Code, w.Body.String())
+	}
+	var nearest struct {
+		Region struct {
+			ID int `json:"id"`
+		} `json:"region"`
+		DistanceKm float64 `json:"distance_km"`
+		FullName   string  `json:"full_name"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &nearest)
+	if nearest.Region.ID != near || nearest.DistanceKm <= 0 || nearest.DistanceKm > 2 || nearest.FullName != "UT남극도 UT가까운구" {
+		t.Fatalf("unexpected nearest: %+v", nearest)
+	}
+
+	var within struct {
+		Items []struct {
+			ID         int     `json:"id"`
+			DistanceKm float64 `json:"distance_km"`
+		} `json:"items"`
+	}
+	w = doGET(t, r, "/api/v1/meta/regions/within?lat=-70&lng=10&km=5", tok)
+	_ = json.Unmarshal(w.Body.Bytes(), &within)
+	if len(within.Items) != 1 || within.Items[0].ID != near {
+		t.Fatalf("within 5km: %+v", within.Items)
+	}
+	within.Items = nil
+	w = doGET(t, r, "/api/v1/meta/regions/within?lat=-70&lng=10&km=50", tok)
+	_ = json.Unmarshal(w.Body.Bytes(), &within)
+	if len(within.Items) != 2 || within.Items[0].ID != near || within.Items[1].ID != far {
+		t.Fatalf("within 50km: %+v", within.Items)
+	}
+}
+
+// 관리자가 숨긴 항목은 목록에서 빠지고 증분 동기화에서는 지운 것으로 알린다
+func TestMeta_HidesInactive(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	_ = seedBasicMeta(t, pool)
+	ctx := context.Background()
+	var exists string
+	_ = pool.QueryRow(ctx, `SELECT COALESCE(to_regclass('public.catalog_audit')::text, '')`).Scan(&exists)
+	if exists == "" {
+		t.Skip("skipping: catalog_audit not found (run migrations first)")
+	}
+	if _, err := pool.Exec(ctx, `INSERT INTO job_category (code, name) VALUES ('ut-hidden','Hidden') ON CONFLICT (code) DO UPDATE SET active=true`); err != nil {
+		t.Fatalf("seed job_category: %v", err)
+	}
+	t.Cleanup(func() { _, _ = pool.Exec(context.Background(), `DELETE FROM job_category WHERE code='ut-hidden'`) })
+
+	r, tok := setupRouter(pool, "test-secret")
+	w := doGET(t, r, "/api/v1/meta/catalog", tok)
+	var full struct {
+		Version int64 `json:"version"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &full)
+	if _, err := pool.Exec(ctx, `UPDATE job_category SET active=false WHERE code='ut-hidden'`); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+
+	w = doGET(t, r, "/api/v1/meta/job-categories", tok)
+	if w.Code != http.StatusOK || bytes.Contains(w.Body.Bytes(), []byte(`"ut-hidden"`)) {
+		t.Fatalf("inactive job category must be hidden: %d %s", w.Code, w.Body.String())
+	}
+	w = doGET(t, r, fmt.Sprintf("/api/v1/meta/catalog?since=%d", full.Version), tok)
+	var delta struct {
+		JobCategories struct {
+			Upserted []struct {
+				Code string `json:"code"`
+			} `json:"upserted"`
+			Deleted []string `json:"deleted"`
+		} `json:"job_categories"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &delta)
+	if !slices.Contains(delta.JobCategories.Deleted, "ut-hidden") {
+		t.Fatalf("inactive row must be reported as deleted: %s", w.Body.String())
+	}
+	for _, u := range delta.JobCategories.Upserted {
+		if u.Code == "ut-hidden" {
+			t.Fatalf("inactive row must not be upserted: %s", w.Body.String())
+		}
+	}
+}
+
 func TestMeta_JobCategories_OK(t *testing.T) {
 	pool := newTestPool(t)
 	ensureSchema(t, pool)
@@ -235,6 +419,187 @@ func TestMeta_JobCategories_OK(t *testing.T) {
 	}
 }
 
+// Accept-Language 에 따라 번역 이름, 번역이 없으면 원본 이름
+func TestMeta_Localized(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	_ = seedBasicMeta(t, pool)
+	ctx := context.Background()
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO translation (entity, entity_key, locale, name) VALUES ('job_category','ut','ko','단위 테스트')
+		ON CONFLICT (entity, entity_key, locale) DO UPDATE SET name=EXCLUDED.name`); err != nil {
+		t.Skipf("skipping: translation table not migrated: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = pool.Exec(context.Background(), `DELETE FROM translation WHERE entity='job_category' AND entity_key='ut'`)
+	})
+
+	r, tok := setupRouter(pool, "test-secret")
+	get := func(lang string) (string, string) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/meta/job-categories", nil)
+		req.Header.Set("Authorization", "Bearer "+tok)
+		req.Header.Set("Accept-Language", lang)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: %d %s", lang, w.Code, w.Body.String())
+		}
+		if w.Header().Get("Vary") != "Accept-Language" {
+			t.Fatalf("missing Vary header: %v", w.Header())
+		}
+		var out struct {
+			Locale string `json:"locale"`
+			Items  []struct {
+				Code string `json:"code"`
+				Name string `json:"name"`
+			} `json:"items"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &out)
+		if w.Header().Get("Content-Language") != out.Locale {
+			t.Fatalf("Content-Language %q != locale %q", w.Header().Get("Content-Language"), out.Locale)
+		}
+		for _, it := range out.Items {
+			if it.Code == "ut" {
+				return out.Locale, it.Name
+			}
+		}
+		t.Fatalf("%s: ut category missing", lang)
+		return "", ""
+	}
+
+	if loc, name := get("ko-KR,ko;q=0.9,en;q=0.8"); loc != "ko" || name != "단위 테스트" {
+		t.Fatalf("ko: locale=%s name=%s", loc, name)
+	}
+	if loc, name := get("en-US"); loc != "en" || name != "Unit Testing" {
+		t.Fatalf("en: locale=%s name=%s", loc, name)
+	}
+}
+
+// 카탈로그 버전 ETag → If-None-Match 304, 카탈로그가 바뀌면 새 ETag
+func TestMeta_ETag(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	ctx := context.Background()
+	if _, _, err := catalog.Version(ctx, pool); err != nil {
+		t.Skipf("skipping: catalog_version not migrated: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	meta.Register(r.Group("/api/v1"), pool, "test-secret", catalog.NewCache(pool))
+	tok, _, _ := token.Sign("test-secret", "00000000-0000-0000-0000-000000000000", "+82 10-0000-0000", time.Hour)
+
+	w := doGET(t, r, "/api/v1/meta/job-categories", tok)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" || w.Header().Get("Cache-Control") == "" {
+		t.Fatalf("expected 200 with ETag, got %d %v", w.Code, w.Header())
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/meta/job-categories", nil)
+	req.Header.Set("Authorization", "Bearer "+tok)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("expected 304, got %d", w.Code)
+	}
+
+	// 카탈로그 변경 → 버전 증가
+	if _, err := pool.Exec(ctx, `INSERT INTO job_category (code, name) VALUES ('ut-etag','ETag Test') ON CONFLICT DO NOTHING`); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	t.Cleanup(func() { _, _ = pool.Exec(context.Background(), `DELETE FROM job_category WHERE code='ut-etag'`) })
+	if w := doGET(t, r, "/api/v1/meta/job-categories", tok); w.Header().Get("ETag") == etag {
+		t.Fatal("ETag must change after a catalog change")
+	}
+}
+
+func TestMeta_Catalog_InvalidSince(t *testing.T) {
+	r, tok := setupRouter(nil, "test-secret")
+	for _, q := range []string{"-1", "abc"} {
+		if w := doGET(t, r, "/api/v1/meta/catalog?since="+q, tok); w.Code != http.StatusBadRequest {
+			t.Fatalf("since=%s: expected 400, got %d", q, w.Code)
+		}
+	}
+}
+
+// 전체 → 변경/삭제 후 since 로 증분만
+func TestMeta_Catalog_Delta(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	_ = seedBasicMeta(t, pool)
+	ctx := context.Background()
+	var exists string
+	_ = pool.QueryRow(ctx, `SELECT COALESCE(to_regclass('public.catalog_tombstone')::text, '')`).Scan(&exists)
+	if exists == "" {
+		t.Skip("skipping: catalog_tombstone not found (run migrations first)")
+	}
+	_, _ = pool.Exec(ctx, `INSERT INTO job_category (code, name) VALUES ('ut-gone','Gone') ON CONFLICT DO NOTHING`)
+	t.Cleanup(func() {
+		_, _ = pool.Exec(context.Background(), `DELETE FROM job_category WHERE code IN ('ut-new','ut-gone')`)
+	})
+
+	type changes struct {
+		Upserted []struct {
+			Code string `json:"code"`
+		} `json:"upserted"`
+		Deleted []string `json:"deleted"`
+	}
+	type bundle struct {
+		Version       int64   `json:"version"`
+		Full          bool    `json:"full"`
+		JobCategories changes `json:"job_categories"`
+	}
+	r, tok := setupRouter(pool, "test-secret")
+	fetch := func(since int64) bundle {
+		w := doGET(t, r, fmt.Sprintf("/api/v1/meta/catalog?since=%d", since), tok)
+		if w.Code != http.StatusOK {
+			t.Fatalf("since=%d: %d %s", since, w.Code, w.Body.String())
+		}
+		var b bundle
+		if err := json.Unmarshal(w.Body.Bytes(), &b); err != nil {
+			t.Fatalf("invalid json: %v", err)
+		}
+		return b
+	}
+	codes := func(c changes) map[string]bool {
+		m := map[string]bool{}
+		for _, u := range c.Upserted {
+			m[u.Code] = true
+		}
+		return m
+	}
+
+	full := fetch(0)
+	if !full.Full || !codes(full.JobCategories)["ut-gone"] {
+		t.Fatalf("full sync must include every row: %+v", full)
+	}
+
+	_, _ = pool.Exec(ctx, `INSERT INTO job_category (code, name) VALUES ('ut-new','New')`)
+	_, _ = pool.Exec(ctx, `DELETE FROM job_category WHERE code='ut-gone'`)
+
+	delta := fetch(full.Version)
+	if delta.Full || delta.Version <= full.Version {
+		t.Fatalf("expected incremental bundle with newer version: %+v", delta)
+	}
+	got := codes(delta.JobCategories)
+	if !got["ut-new"] || got["ut"] || got["ut-gone"] {
+		t.Fatalf("upserted: %+v", delta.JobCategories.Upserted)
+	}
+	// 다른 패키지 테스트가 같은 DB 를 동시에 쓸 수 있어 이 테스트의 행만 본다
+	deleted := map[string]bool{}
+	for _, k := range delta.JobCategories.Deleted {
+		deleted[k] = true
+	}
+	if !deleted["ut-gone"] || deleted["ut-new"] {
+		t.Fatalf("deleted: %+v", delta.JobCategories.Deleted)
+	}
+
+	again := fetch(delta.Version)
+	if codes(again.JobCategories)["ut-new"] || slices.Contains(again.JobCategories.Deleted, "ut-gone") {
+		t.Fatalf("nothing of ours changed since last sync: %+v", again)
+	}
+}
+
 func TestMeta_CharacterCategories_OK(t *testing.T) {
 	pool := newTestPool(t)
 	ensureSchema(t, pool)
@@ -328,6 +693,196 @@ func TestMeta_Characters_FilterByCategory_OK(t *testing.T) {
 	}
 }
 
+// 정렬/커서/limit 이 잘못되면 DB 없이 400
+func TestMeta_List_Invalid(t *testing.T) {
+	r, tok := setupRouter(nil, "test-secret")
+	for _, path := range []string{
+		"/api/v1/meta/characters?sort=price",
+		"/api/v1/meta/characters?cursor=not-a-cursor",
+		"/api/v1/meta/backgrounds?limit=-1",
+		"/api/v1/meta/job-categories?limit=201",
+		"/api/v1/meta/profile-prompts?sort=name",
+		"/api/v1/meta/characters?tag=Bad+Tag",
+		"/api/v1/meta/backgrounds?rarity=mythic",
+		"/api/v1/meta/backgrounds?character=not-a-uuid",
+		"/api/v1/meta/avatar-parts?slot=base",
+		"/api/v1/meta/avatar-parts?rarity=mythic",
+	} {
+		if w := doGET(t, r, path, tok); w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d; body=%s", path, w.Code, w.Body.String())
+		}
+	}
+}
+
+// q 로 좁히고 limit 단위로 next_cursor 를 따라가면 겹치지 않고 정렬 순서대로 모두 나온다
+func TestMeta_Characters_Paginate(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	code := seedBasicMeta(t, pool)
+	ctx := context.Background()
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO character_item (category_code, name)
+		SELECT $1, 'UT Page ' || n FROM unnest(ARRAY['C','A','B']) n
+		ON CONFLICT (category_code, name) DO NOTHING`, code); err != nil {
+		t.Skipf("skipping: seed failed (run migrations first): %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = pool.Exec(context.Background(), `DELETE FROM character_item WHERE name LIKE 'UT Page %'`)
+	})
+
+	r, tok := setupRouter(pool, "test-secret")
+	type page struct {
+		Items []struct {
+			Name string `json:"name"`
+		} `json:"items"`
+		Total      int     `json:"total"`
+		NextCursor *string `json:"next_cursor"`
+	}
+	for _, tt := range []struct {
+		sort string
+		want []string
+	}{
+		{"name", []string{"UT Page A", "UT Page B", "UT Page C"}},
+		{"-name", []string{"UT Page C", "UT Page B", "UT Page A"}},
+	} {
+		var got []string
+		path := "/api/v1/meta/characters?category=" + code + "&q=ut+page&limit=2&sort=" + tt.sort
+		for pages := 0; ; pages++ {
+			if pages > 3 {
+				t.Fatalf("%s: too many pages: %v", tt.sort, got)
+			}
+			w := doGET(t, r, path, tok)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+			}
+			var out page
+			if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+				t.Fatalf("invalid json: %v", err)
+			}
+			if out.Total != 3 {
+				t.Fatalf("expected total 3, got %d; body=%s", out.Total, w.Body.String())
+			}
+			for _, it := range out.Items {
+				got = append(got, it.Name)
+			}
+			if out.NextCursor == nil {
+				break
+			}
+			path = "/api/v1/meta/characters?category=" + code + "&q=ut+page&limit=2&sort=" + tt.sort + "&cursor=" + *out.NextCursor
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Fatalf("%s: got %v, want %v", tt.sort, got, tt.want)
+		}
+	}
+}
+
+// 노출 기간 밖의 항목은 숨기고 tag(모두)/rarity(하나) 로 거른다. 기본 정렬은 sort_weight
+func TestMeta_Backgrounds_Attributes(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	_ = seedBasicMeta(t, pool)
+	ctx := context.Background()
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO bg_item (name, tags, rarity, sort_weight, available_from, available_until) VALUES
+			('UT Attr Snow',   '{ut_attr,winter}', 'epic',   2, now() - interval '1 day', now() + interval '1 day'),
+			('UT Attr Beach',  '{ut_attr,summer}', 'common', 1, NULL, NULL),
+			('UT Attr Future', '{ut_attr,winter}', 'epic',   0, now() + interval '1 day', NULL),
+			('UT Attr Past',   '{ut_attr}',        'rare',   0, NULL, now() - interval '1 day')
+		ON CONFLICT (name) DO NOTHING`); err != nil {
+		t.Skipf("skipping: seed failed (run migrations first): %v", err)
+	}
+	t.Cleanup(func() { _, _ = pool.Exec(context.Background(), `DELETE FROM bg_item WHERE name LIKE 'UT Attr %'`) })
+
+	r, tok := setupRouter(pool, "test-secret")
+	names := func(query string) []string {
+		t.Helper()
+		w := doGET(t, r, "/api/v1/meta/backgrounds?tag=ut_attr&"+query, tok)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d; body=%s", query, w.Code, w.Body.String())
+		}
+		var out struct {
+			Items []struct {
+				Name   string   `json:"name"`
+				Tags   []string `json:"tags"`
+				Rarity string   `json:"rarity"`
+			} `json:"items"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &out)
+		var got []string
+		for _, it := range out.Items {
+			got = append(got, it.Name)
+		}
+		return got
+	}
+	for _, tt := range []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"UT Attr Beach", "UT Attr Snow"}},
+		{"tag=winter", []string{"UT Attr Snow"}},
+		{"rarity=common&rarity=rare", []string{"UT Attr Beach"}},
+		{"sort=-name", []string{"UT Attr Snow", "UT Attr Beach"}},
+	} {
+		if got := names(tt.query); !slices.Equal(got, tt.want) {
+			t.Fatalf("%q: got %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+// 슬롯은 z_order 순, 파트는 slot 으로 거르고 앵커/비율을 함께 준다
+func TestMeta_AvatarParts(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	ctx := context.Background()
+	var exists string
+	_ = pool.QueryRow(ctx, `SELECT COALESCE(to_regclass('public.avatar_part')::text, '')`).Scan(&exists)
+	if exists == "" {
+		t.Skip("skipping: table avatar_part not found (run migrations first)")
+	}
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO avatar_part (slot_code, name, anchor_x, anchor_y, scale) VALUES
+			('accessory', 'UT Part Hat', 0.5, 0.2, 0.4),
+			('outfit',    'UT Part Coat', 0.5, 0.7, 0.8)
+		ON CONFLICT (slot_code, name) DO NOTHING`); err != nil {
+		t.Fatalf("seed parts: %v", err)
+	}
+	t.Cleanup(func() { _, _ = pool.Exec(context.Background(), `DELETE FROM avatar_part WHERE name LIKE 'UT Part %'`) })
+
+	r, tok := setupRouter(pool, "test-secret")
+	w := doGET(t, r, "/api/v1/meta/avatar-slots", tok)
+	if w.Code != http.StatusOK {
+		t.Fatalf("slots: expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	var slots struct {
+		Items []struct {
+			Code     string `json:"code"`
+			Required bool   `json:"required"`
+		} `json:"items"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &slots)
+	if len(slots.Items) < 2 || slots.Items[0].Code != "background" || !slots.Items[0].Required || slots.Items[1].Code != "base" {
+		t.Fatalf("unexpected slots: %s", w.Body.String())
+	}
+
+	w = doGET(t, r, "/api/v1/meta/avatar-parts?slot=accessory&q=ut+part", tok)
+	if w.Code != http.StatusOK {
+		t.Fatalf("parts: expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	var parts struct {
+		Items []struct {
+			Name     string  `json:"name"`
+			SlotCode string  `json:"slot_code"`
+			AnchorY  float64 `json:"anchor_y"`
+			Scale    float64 `json:"scale"`
+		} `json:"items"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &parts)
+	if len(parts.Items) != 1 || parts.Items[0].Name != "UT Part Hat" || parts.Items[0].SlotCode != "accessory" ||
+		math.Abs(parts.Items[0].AnchorY-0.2) > 1e-6 || math.Abs(parts.Items[0].Scale-0.4) > 1e-6 {
+		t.Fatalf("unexpected parts: %s", w.Body.String())
+	}
+}
+
 func TestMeta_Backgrounds_OK(t *testing.T) {
 	pool := newTestPool(t)
 	ensureSchema(t, pool)
@@ -356,3 +911,40 @@ func TestMeta_Backgrounds_OK(t *testing.T) {
 		t.Fatalf("background shape invalid: %+v", out.Items[0])
 	}
 }
+
+// 프리뷰 파생 이미지가 있으면 preview_variants 로 노출
+func TestMeta_Characters_PreviewVariants(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	code := seedBasicMeta(t, pool)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO media_asset_variant (asset_id, name, storage_key, url, content_type, width, height, size_bytes)
+		SELECT preview_asset, 'thumb', 'variants/ut/thumb.png', 'https://example.com/ut-thumb.png', 'image/png', 160, 160, 100
+		FROM character_item WHERE category_code=$1 AND name='UT Character 1'
+		ON CONFLICT (asset_id, name) DO NOTHING`, code); err != nil {
+		t.Skipf("skipping: media_asset_variant not available (run migrations first): %v", err)
+	}
+
+	r, tok := setupRouter(pool, "test-secret")
+	w := doGET(t, r, "/api/v1/meta/characters?category="+code, tok)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	var out struct {
+		Items []struct {
+			PreviewVariants map[string]struct {
+				URL   string `json:"url"`
+				Width int    `json:"width"`
+			} `json:"preview_variants"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(out.Items) == 0 || out.Items[0].PreviewVariants["thumb"].Width != 160 {
+		t.Fatalf("expected thumb variant in response: %s", w.Body.String())
+	}
+}
@@ -0,0 +1,96 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/middleware"
+	"github.com/creators-of-happiness/amigo-backend/internal/util"
+)
+
+type Notification struct {
+	ID        string          `json:"id"`
+	Kind      string          `json:"kind"`
+	Payload   json.RawMessage `json:"payload"`
+	ReadAt    *time.Time      `json:"read_at"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func Register(v1 *gin.RouterGroup, pool *pgxpool.Pool, authSecret string) {
+	me := v1.Group("/me", middleware.Auth(authSecret))
+
+	// 내 알림 목록(최신순). unread=true 면 읽지 않은 것만
+	me.GET("/notifications", func(c *gin.Context) {
+		uid := c.GetString("uid")
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 || limit > 200 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be 1..200"})
+			return
+		}
+		unreadOnly := c.Query("unread") == "true"
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+		defer cancel()
+		rows, err := pool.Query(ctx, `
+			SELECT id, kind, payload, read_at, created_at
+			FROM user_notification
+			WHERE user_id=$1 AND (NOT $2 OR read_at IS NULL)
+			ORDER BY created_at DESC LIMIT $3`, uid, unreadOnly, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer rows.Close()
+
+		items := []Notification{}
+		for rows.Next() {
+			var n Notification
+			if err := rows.Scan(&n.ID, &n.Kind, &n.Payload, &n.ReadAt, &n.CreatedAt); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			items = append(items, n)
+		}
+		if err := rows.Err(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items})
+	})
+
+	// 읽음 처리(이미 읽은 알림은 그대로 둔다)
+	me.POST("/notifications/:id/read", func(c *gin.Context) {
+		var uri struct {
+			ID string `uri:"id" binding:"required,uuid"`
+		}
+		if err := c.ShouldBindUri(&uri); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		uid := c.GetString("uid")
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+		defer cancel()
+		ct, err := pool.Exec(ctx, `
+			UPDATE user_notification SET read_at=COALESCE(read_at, now())
+			WHERE id=$1 AND user_id=$2`, uri.ID, uid)
+		if err != nil {
+			if util.IsClientInputError(err) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		if ct.RowsAffected() == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "notification not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+}
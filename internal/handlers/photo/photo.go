@@ -0,0 +1,410 @@
+package photo
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/config"
+	"github.com/creators-of-happiness/amigo-backend/internal/facereview"
+	"github.com/creators-of-happiness/amigo-backend/internal/gallery"
+	"github.com/creators-of-happiness/amigo-backend/internal/history"
+	"github.com/creators-of-happiness/amigo-backend/internal/media"
+	"github.com/creators-of-happiness/amigo-backend/internal/middleware"
+	"github.com/creators-of-happiness/amigo-backend/internal/onboarding"
+	"github.com/creators-of-happiness/amigo-backend/internal/storage"
+	"github.com/creators-of-happiness/amigo-backend/internal/token"
+	"github.com/creators-of-happiness/amigo-backend/internal/util"
+)
+
+func Register(v1 *gin.RouterGroup, pool *pgxpool.Pool, cfg config.Config, store storage.Storage, pipe *media.Pipeline, reviewer *facereview.Service) {
+	me := v1.Group("/me", middleware.Auth(cfg.AuthSecret))
+
+	limits := media.Limits{
+		MaxBytes:     int64(cfg.UploadMaxMB) << 20,
+		MaxDimension: cfg.UploadMaxDimension,
+		MinDimension: cfg.UploadMinDimension,
+	}
+
+	// 프로필 사진: multipart 파일 업로드 또는 외부 URL(JSON) 등록.
+	// 바로 프로필에 반영되지 않고 심사(승인) 후 대표 사진(profile_image_id)이 된다
+	me.PATCH("/photo", func(c *gin.Context) {
+		receivePhoto(c, pool, store, pipe, limits, reviewer.Submit)
+	})
+
+	// 2단계 업로드 1) 서명된 업로드 URL 발급
+	me.POST("/photo/uploads", func(c *gin.Context) {
+		issueUpload(c, pool, cfg, v1.BasePath(), limits, "profile_photo")
+	})
+
+	// 갤러리(표시 순서대로)
+	me.GET("/photos", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+		defer cancel()
+		items, err := gallery.List(ctx, pool, c.GetString("uid"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items, "max": cfg.PhotoMaxCount})
+	})
+
+	// 갤러리에 사진 추가(심사 후 맨 뒤에 들어간다). 심사 대기 중인 것도 자리를 차지한다
+	me.POST("/photos", func(c *gin.Context) {
+		if !checkGalleryRoom(c, pool, cfg.PhotoMaxCount) {
+			return
+		}
+		receivePhoto(c, pool, store, pipe, limits, reviewer.SubmitToGallery)
+	})
+
+	me.POST("/photos/uploads", func(c *gin.Context) {
+		if !checkGalleryRoom(c, pool, cfg.PhotoMaxCount) {
+			return
+		}
+		issueUpload(c, pool, cfg, v1.BasePath(), limits, "gallery_photo")
+	})
+
+	// 순서 변경: 모든 사진 id 를 원하는 순서로
+	me.PUT("/photos/order", func(c *gin.Context) {
+		var in struct {
+			IDs []string `json:"ids" binding:"required,dive,uuid"`
+		}
+		if err := c.ShouldBindJSON(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		changeGallery(c, pool, func(ctx context.Context, tx pgx.Tx, uid string) error {
+			return gallery.Reorder(ctx, tx, uid, in.IDs)
+		})
+	})
+
+	me.POST("/photos/:id/primary", func(c *gin.Context) {
+		if !validPhotoID(c) {
+			return
+		}
+		changeGallery(c, pool, func(ctx context.Context, tx pgx.Tx, uid string) error {
+			return gallery.SetPrimary(ctx, tx, uid, c.Param("id"))
+		})
+	})
+
+	me.DELETE("/photos/:id", func(c *gin.Context) {
+		if !validPhotoID(c) {
+			return
+		}
+		changeGallery(c, pool, func(ctx context.Context, tx pgx.Tx, uid string) error {
+			return gallery.Delete(ctx, tx, uid, c.Param("id"))
+		})
+	})
+
+	// 2단계 업로드 2) 서명 URL 로 원본 바이트 PUT (Bearer 불필요, 서명으로 인증)
+	v1.PUT("/uploads/:id", func(c *gin.Context) {
+		uploadID := c.Param("id")
+		expUnix, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+		if err != nil || !token.VerifyUpload(cfg.AuthSecret, uploadID, time.Unix(expUnix, 0), c.Query("sig")) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "invalid or expired upload signature"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		// 업로드 세션을 잠그고 처리한다. 동시에 두 번 PUT 되면 늦은 쪽은 먼저 끝난 쪽을 기다렸다가 409(객체를 남기지 않음)
+		tx, err := pool.Begin(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer tx.Rollback(ctx)
+		var uid, status, purpose string
+		err = tx.QueryRow(ctx, `SELECT user_id, status, purpose FROM media_upload WHERE id=$1 FOR UPDATE`, uploadID).Scan(&uid, &status, &purpose)
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "upload not found"})
+			return
+		}
+		if err != nil {
+			writeDBError(c, err)
+			return
+		}
+		if status != "pending" {
+			c.JSON(http.StatusConflict, gin.H{"error": "upload already completed"})
+			return
+		}
+
+		img, err := media.Process(c.Request.Body, limits)
+		if err != nil {
+			writeImageError(c, err)
+			return
+		}
+		assetID, url, err := storeImage(ctx, pool, store, uid, img)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE media_upload SET status='completed', asset_id=$2, completed_at=now()
+			WHERE id=$1`, uploadID, assetID)
+		if err == nil {
+			err = tx.Commit(ctx)
+		}
+		if err != nil {
+			discardAsset(pool, store, assetID)
+			writeDBError(c, err)
+			return
+		}
+		pipe.Notify()
+		submit := reviewer.Submit
+		if purpose == "gallery_photo" {
+			submit = reviewer.SubmitToGallery
+		}
+		up, err := submit(ctx, uid, assetID, url, img.Data)
+		if err != nil {
+			writeDBError(c, err)
+			return
+		}
+		writeSubmitted(ctx, c, pool, up)
+	})
+
+	// 내 얼굴 사진 심사 이력(최신순)
+	me.GET("/face-uploads", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+		defer cancel()
+		items, err := reviewer.ListByUser(ctx, c.GetString("uid"), 20)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items})
+	})
+}
+
+type submitFunc func(ctx context.Context, uid, assetID, url string, img []byte) (facereview.Upload, error)
+
+// multipart 파일(정규화 후 저장) 또는 외부 URL(JSON) 을 받아 심사에 올린다
+func receivePhoto(c *gin.Context, pool *pgxpool.Pool, store storage.Storage, pipe *media.Pipeline, limits media.Limits, submit submitFunc) {
+	uid := c.GetString("uid")
+
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		// multipart 오버헤드 여유분 1MB
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limits.MaxBytes+1<<20)
+		fh, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "multipart field 'file' is required"})
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer f.Close()
+		img, err := media.Process(f, limits)
+		if err != nil {
+			writeImageError(c, err)
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+		assetID, url, err := storeImage(ctx, pool, store, uid, img)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		pipe.Notify()
+		up, err := submit(ctx, uid, assetID, url, img.Data)
+		if err != nil {
+			writeDBError(c, err)
+			return
+		}
+		writeSubmitted(ctx, c, pool, up)
+		return
+	}
+
+	var in struct {
+		URL string `json:"url" binding:"required,uri"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	// media_asset 생성. 서버는 사용자가 준 URL 을 가져오지 않으므로 파생본도 만들지 않는다
+	var assetID string
+	if err := pool.QueryRow(ctx, `
+		INSERT INTO media_asset (kind, url, owner_id, variant_status, variant_error)
+		VALUES ('image', $1, $2, 'failed', 'external url') RETURNING id`, in.URL, uid).Scan(&assetID); err != nil {
+		writeDBError(c, err)
+		return
+	}
+	up, err := submit(ctx, uid, assetID, in.URL, nil)
+	if err != nil {
+		writeDBError(c, err)
+		return
+	}
+	writeSubmitted(ctx, c, pool, up)
+}
+
+// 2단계 업로드 세션 생성 + 서명 URL 발급
+func issueUpload(c *gin.Context, pool *pgxpool.Pool, cfg config.Config, basePath string, limits media.Limits, purpose string) {
+	uid := c.GetString("uid")
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+
+	exp := time.Now().Add(time.Duration(cfg.UploadURLTTLMinutes) * time.Minute).Truncate(time.Second)
+	var uploadID string
+	err := pool.QueryRow(ctx, `
+		INSERT INTO media_upload (user_id, purpose, expires_at)
+		VALUES ($1, $2, $3) RETURNING id`, uid, purpose, exp).Scan(&uploadID)
+	if err != nil {
+		writeDBError(c, err)
+		return
+	}
+	sig := token.SignUpload(cfg.AuthSecret, uploadID, exp)
+	c.JSON(http.StatusCreated, gin.H{
+		"upload_id":  uploadID,
+		"method":     http.MethodPut,
+		"upload_url": basePath + "/uploads/" + uploadID + "?expires=" + strconv.FormatInt(exp.Unix(), 10) + "&sig=" + sig,
+		"expires_at": exp,
+		"max_bytes":  limits.MaxBytes,
+	})
+}
+
+// 갤러리가 차 있으면 409 를 쓰고 false
+func checkGalleryRoom(c *gin.Context, pool *pgxpool.Pool, limit int) bool {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	n, err := gallery.Reserved(ctx, pool, c.GetString("uid"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if n >= limit {
+		c.JSON(http.StatusConflict, gin.H{"error": gallery.ErrFull.Error(), "max": limit})
+		return false
+	}
+	return true
+}
+
+func validPhotoID(c *gin.Context) bool {
+	var uri struct {
+		ID string `uri:"id" binding:"required,uuid"`
+	}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// 갤러리 변경을 본인 이력과 함께 한 트랜잭션으로 처리하고 변경 후 목록을 돌려준다
+func changeGallery(c *gin.Context, pool *pgxpool.Pool, fn func(ctx context.Context, tx pgx.Tx, uid string) error) {
+	uid := c.GetString("uid")
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		if err := history.SetActor(ctx, tx, history.Actor{ID: uid, Source: history.SourceUser}); err != nil {
+			return err
+		}
+		return fn(ctx, tx, uid)
+	})
+	switch {
+	case errors.Is(err, gallery.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, gallery.ErrBadOrder):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		writeDBError(c, err)
+		return
+	}
+	items, err := gallery.List(ctx, pool, uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// 정규화된 이미지를 저장소에 올리고 media_asset 행 생성
+func storeImage(ctx context.Context, pool *pgxpool.Pool, store storage.Storage, uid string, img *media.Image) (assetID, url string, err error) {
+	var rnd [16]byte
+	if _, err := rand.Read(rnd[:]); err != nil {
+		return "", "", err
+	}
+	key := "users/" + uid + "/photos/" + hex.EncodeToString(rnd[:]) + img.Ext
+	if err := store.Put(ctx, key, bytes.NewReader(img.Data), int64(len(img.Data)), img.ContentType); err != nil {
+		return "", "", err
+	}
+	url = store.URL(key)
+	err = pool.QueryRow(ctx, `
+		INSERT INTO media_asset (kind, url, storage_key, content_type, width, height, size_bytes, owner_id)
+		VALUES ('image', $1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		url, key, img.ContentType, img.Width, img.Height, len(img.Data), uid).Scan(&assetID)
+	if err != nil {
+		// 고아 객체 정리
+		_ = store.Delete(context.Background(), key)
+		return "", "", err
+	}
+	return assetID, url, nil
+}
+
+// 세션에 연결하지 못한 에셋과 객체를 지운다(실패해도 응답에는 영향 없음)
+func discardAsset(pool *pgxpool.Pool, store storage.Storage, assetID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var key *string
+	if err := pool.QueryRow(ctx, `DELETE FROM media_asset WHERE id=$1 RETURNING storage_key`, assetID).Scan(&key); err == nil && key != nil {
+		_ = store.Delete(ctx, *key)
+	}
+}
+
+// 심사에 올라간 사진도 온보딩 사진 단계를 채우므로 보상을 먼저 확인한다.
+// 업로드는 이미 반영됐으므로 지급이 실패해도 성공으로 응답한다(재시도하면 에셋이 중복된다)
+func writeSubmitted(ctx context.Context, c *gin.Context, pool *pgxpool.Pool, up facereview.Upload) {
+	if err := onboarding.Reward(ctx, pool, up.UserID); err != nil {
+		log.Printf("onboarding reward for %s: %v", up.UserID, err)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ok":            true,
+		"asset_id":      up.AssetID,
+		"url":           up.URL,
+		"upload_id":     up.ID,
+		"review_status": up.Status,
+		"reject_reason": up.RejectReason,
+	})
+}
+
+func writeImageError(c *gin.Context, err error) {
+	var mbe *http.MaxBytesError
+	switch {
+	case errors.Is(err, media.ErrTooLarge), errors.As(err, &mbe):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": media.ErrTooLarge.Error()})
+	case errors.Is(err, media.ErrUnsupportedType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, media.ErrDimensions), errors.Is(err, media.ErrCorrupt):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func writeDBError(c *gin.Context, err error) {
+	if util.IsClientInputError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	} else {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,88 @@
+package profile
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/friend"
+)
+
+// 친구 신청/수락/끊기. 친구에게는 공개 범위 'friends' 필드가 보인다
+func registerFriends(me *gin.RouterGroup, pool *pgxpool.Pool) {
+	type userURI struct {
+		ID string `uri:"id" binding:"required,uuid"`
+	}
+
+	me.GET("/friends", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		items, err := friend.List(ctx, pool, c.GetString("uid"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items})
+	})
+
+	// 받은 신청과 보낸 신청
+	me.GET("/friends/requests", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		incoming, outgoing, err := friend.Requests(ctx, pool, c.GetString("uid"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"incoming": incoming, "outgoing": outgoing})
+	})
+
+	// 친구 신청. 상대가 먼저 신청했으면 수락이 된다. 새로 신청/수락하면 201, 이미 그 상태면 200
+	me.PUT("/friends/:id", func(c *gin.Context) {
+		var uri userURI
+		if err := c.ShouldBindUri(&uri); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		status, created, err := friend.Ask(ctx, pool, c.GetString("uid"), uri.ID)
+		switch {
+		case errors.Is(err, friend.ErrSelf):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, friend.ErrUnknownUser):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		case created:
+			c.JSON(http.StatusCreated, gin.H{"status": status})
+		default:
+			c.JSON(http.StatusOK, gin.H{"status": status})
+		}
+	})
+
+	// 친구 끊기, 보낸 신청 취소, 받은 신청 거절
+	me.DELETE("/friends/:id", func(c *gin.Context) {
+		var uri userURI
+		if err := c.ShouldBindUri(&uri); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		removed, err := friend.Remove(ctx, pool, c.GetString("uid"), uri.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !removed {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no friendship or request"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+}
@@ -0,0 +1,35 @@
+package profile
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/inventory"
+	"github.com/creators-of-happiness/amigo-backend/internal/middleware"
+)
+
+func registerInventory(me *gin.RouterGroup, pool *pgxpool.Pool) {
+	// 보유한 항목(잠긴 항목을 얻은 기록)
+	me.GET("/inventory", middleware.Locale(), func(c *gin.Context) {
+		var in struct {
+			Entity string `form:"entity" binding:"omitempty,oneof=character_item bg_item avatar_part"`
+		}
+		if err := c.ShouldBindQuery(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		uid := c.GetString("uid")
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		items, err := inventory.List(ctx, pool, uid, in.Entity, c.GetString("locale"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"locale": c.GetString("locale"), "items": items})
+	})
+}
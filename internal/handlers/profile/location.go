@@ -0,0 +1,129 @@
+package profile
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+	"github.com/creators-of-happiness/amigo-backend/internal/geo"
+	"github.com/creators-of-happiness/amigo-backend/internal/region"
+)
+
+const (
+	LocationDecimals  = 2 // 저장 좌표 정밀도(≈1.1km)
+	LocationPrecision = 6 // 지오해시 길이(≈1.2km 격자)
+)
+
+type Location struct {
+	Latitude  float64        `json:"latitude"`
+	Longitude float64        `json:"longitude"`
+	Geohash   string         `json:"geohash"`
+	Region    *region.Region `json:"region"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	Stale     bool           `json:"stale"`
+}
+
+func registerLocation(me *gin.RouterGroup, pool *pgxpool.Pool, staleAfter time.Duration) {
+	// 현재 위치. 좌표는 정밀도를 낮춰 저장하고 가장 가까운 지역을 함께 기록한다
+	me.PUT("/location", func(c *gin.Context) {
+		uid := c.GetString("uid")
+		var in struct {
+			Latitude  *float64 `json:"latitude" binding:"required"`
+			Longitude *float64 `json:"longitude" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := geo.Validate(*in.Latitude, *in.Longitude); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// 지오해시도 낮춘 좌표로 계산해야 원래 위치가 드러나지 않는다
+		lat := geo.Coarsen(*in.Latitude, LocationDecimals)
+		lng := geo.Coarsen(*in.Longitude, LocationDecimals)
+		hash := geo.Encode(lat, lng, LocationPrecision)
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		var regionID *int
+		r, _, err := region.Nearest(ctx, pool, lat, lng)
+		switch {
+		case err == nil:
+			regionID = &r.ID
+		case !errors.Is(err, region.ErrNotFound):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		_, err = pool.Exec(ctx, `
+			INSERT INTO user_location (user_id, latitude, longitude, geohash, region_id, updated_at)
+			VALUES ($1, $2, $3, $4, $5, now())
+			ON CONFLICT (user_id) DO UPDATE SET
+			  latitude=EXCLUDED.latitude, longitude=EXCLUDED.longitude, geohash=EXCLUDED.geohash,
+			  region_id=EXCLUDED.region_id, updated_at=now()`, uid, lat, lng, hash, regionID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		loc, err := loadLocation(ctx, pool, uid, staleAfter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, loc)
+	})
+
+	me.GET("/location", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+		defer cancel()
+		loc, err := loadLocation(ctx, pool, c.GetString("uid"), staleAfter)
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "location not set"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, loc)
+	})
+
+	// 위치 공유 중단
+	me.DELETE("/location", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+		defer cancel()
+		if _, err := pool.Exec(ctx, `DELETE FROM user_location WHERE user_id=$1`, c.GetString("uid")); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+}
+
+// 저장된 위치. staleAfter 보다 오래됐으면 Stale
+func loadLocation(ctx context.Context, q db.Querier, uid string, staleAfter time.Duration) (Location, error) {
+	var loc Location
+	var regionID *int
+	err := q.QueryRow(ctx, `
+		SELECT latitude::float8, longitude::float8, geohash, updated_at, region_id
+		FROM user_location WHERE user_id=$1`, uid).
+		Scan(&loc.Latitude, &loc.Longitude, &loc.Geohash, &loc.UpdatedAt, &regionID)
+	if err != nil {
+		return loc, err
+	}
+	if regionID != nil {
+		r, err := region.Get(ctx, q, *regionID)
+		if err != nil {
+			return loc, err
+		}
+		loc.Region = &r
+	}
+	loc.Stale = staleAfter > 0 && time.Since(loc.UpdatedAt) > staleAfter
+	return loc, nil
+}
@@ -2,76 +2,224 @@ package profile
 
 import (
 	"context"
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/creators-of-happiness/amigo-backend/internal/age"
+	"github.com/creators-of-happiness/amigo-backend/internal/avatar"
+	"github.com/creators-of-happiness/amigo-backend/internal/config"
+	"github.com/creators-of-happiness/amigo-backend/internal/gallery"
+	"github.com/creators-of-happiness/amigo-backend/internal/history"
+	"github.com/creators-of-happiness/amigo-backend/internal/media"
 	"github.com/creators-of-happiness/amigo-backend/internal/middleware"
+	"github.com/creators-of-happiness/amigo-backend/internal/moderation"
+	"github.com/creators-of-happiness/amigo-backend/internal/nickname"
+	"github.com/creators-of-happiness/amigo-backend/internal/onboarding"
+	"github.com/creators-of-happiness/amigo-backend/internal/privacy"
+	"github.com/creators-of-happiness/amigo-backend/internal/region"
 	"github.com/creators-of-happiness/amigo-backend/internal/util"
 )
 
-func Register(v1 *gin.RouterGroup, pool *pgxpool.Pool, authSecret string) {
-	me := v1.Group("/me", middleware.Auth(authSecret))
+func Register(v1 *gin.RouterGroup, pool *pgxpool.Pool, cfg config.Config, filter *moderation.Filter, avatars *avatar.Renderer) {
+	me := v1.Group("/me", middleware.Auth(cfg.AuthSecret))
+	users := v1.Group("/users", middleware.Auth(cfg.AuthSecret))
+	registerPrompts(me, pool, filter)
+	registerLocation(me, pool, time.Duration(cfg.LocationStaleHours)*time.Hour)
+	registerInventory(me, pool)
+	registerWallet(me, pool)
+	cooldown := time.Duration(cfg.NicknameCooldownDays) * 24 * time.Hour
 
 	// 진행상태 조회(프론트가 어느 단계부터 시작할지 판단)
 	me.GET("/onboarding-state", func(c *gin.Context) {
 		uid := c.GetString("uid")
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
 		defer cancel()
+		state, err := onboarding.State(ctx, pool, uid)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, state)
+	})
+
+	// 내 프로필 전체 조회(사진은 서버 생성 파생 이미지 포함)
+	me.GET("/profile", func(c *gin.Context) {
+		uid := c.GetString("uid")
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+		defer cancel()
+
+		out, err := loadProfile(ctx, pool, uid)
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, out)
+	})
+
+	// 필드별 공개 범위
+	me.GET("/privacy", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+		defer cancel()
+		s, err := privacy.Load(ctx, pool, c.GetString("uid"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, s)
+	})
+
+	// 생략한 필드는 그대로 둔다
+	me.PUT("/privacy", func(c *gin.Context) {
+		uid := c.GetString("uid")
+		var in privacy.Settings
+		if err := c.ShouldBindJSON(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+		defer cancel()
+		// 처음이면 기본값 행을 만든 뒤 보낸 필드만 덮어쓴다
+		if _, err := pool.Exec(ctx, `INSERT INTO user_privacy (user_id) VALUES ($1) ON CONFLICT (user_id) DO NOTHING`, uid); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		var s privacy.Settings
+		err := pool.QueryRow(ctx, `
+			UPDATE user_privacy SET
+			  birthdate  = COALESCE(NULLIF($2,''), birthdate),
+			  region     = COALESCE(NULLIF($3,''), region),
+			  job        = COALESCE(NULLIF($4,''), job),
+			  photo      = COALESCE(NULLIF($5,''), photo),
+			  updated_at = now()
+			WHERE user_id=$1
+			RETURNING birthdate, region, job, photo`, uid, in.Birthdate, in.Region, in.Job, in.Photo).
+			Scan(&s.Birthdate, &s.Region, &s.Job, &s.Photo)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, s)
+	})
+
+	// 친구('friends' 공개 범위를 보는 사람)
+	registerFriends(me, pool)
+
+	// 닉네임 사용 가능 여부(규칙 + 중복). 불가하면 대안 제안
+	me.GET("/nickname/availability", func(c *gin.Context) {
+		uid := c.GetString("uid")
+		q := c.Query("q")
+		if q == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+		defer cancel()
 
-		var nickname *string
-		var gender *string
-		var birth *time.Time
-		var regionID *int
-		var profileImg *string
-
-		_ = pool.QueryRow(ctx, `SELECT nickname FROM app_users WHERE id=$1`, uid).Scan(&nickname)
-		_ = pool.QueryRow(ctx, `
-			SELECT gender, birth_date, region_id, ma.url
-			FROM user_profile up
-			LEFT JOIN media_asset ma ON ma.id = up.profile_image_id
-			WHERE up.user_id=$1`, uid).Scan(&gender, &birth, &regionID, &profileImg)
-
-		var catCode *string
-		var charID, bgID *string
-		_ = pool.QueryRow(ctx, `
-			SELECT category_code, character_id, bg_id FROM user_avatar WHERE user_id=$1`, uid).
-			Scan(&catCode, &charID, &bgID)
-
-		var jobCat *string
-		_ = pool.QueryRow(ctx, `SELECT category FROM user_job WHERE user_id=$1`, uid).Scan(&jobCat)
-
-		c.JSON(http.StatusOK, gin.H{
-			"nickname":  nickname != nil,
-			"gender":    gender != nil,
-			"birthdate": birth != nil,
-			"region":    regionID != nil,
-			"job":       jobCat != nil,
-			"avatar":    (charID != nil && bgID != nil),
-			"photo":     profileImg != nil,
-		})
+		display, err := nickname.Validate(q)
+		if err == nil && filter.Check("nickname", display) != nil {
+			// 금칙어가 든 닉네임은 대안의 바탕으로도 쓰지 않는다
+			sugg, serr := nickname.Suggest(ctx, pool, "", 3)
+			if serr != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": serr.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"nickname": display, "available": false, "reason": "profanity", "suggestions": sugg})
+			return
+		}
+		if err == nil {
+			ok, qerr := nickname.Available(ctx, pool, nickname.Key(display), uid)
+			if qerr != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": qerr.Error()})
+				return
+			}
+			if !ok {
+				err = nickname.ErrTaken
+			}
+		}
+		if err == nil {
+			c.JSON(http.StatusOK, gin.H{"nickname": display, "available": true, "reason": nil, "suggestions": []string{}})
+			return
+		}
+		sugg, serr := nickname.Suggest(ctx, pool, display, 3)
+		if serr != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": serr.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"nickname": display, "available": false, "reason": nickname.Reason(err), "suggestions": sugg})
 	})
 
-	// 1) 닉네임
+	// 1) 닉네임(규칙 검사, 중복 시 409 + 대안, 변경 쿨다운)
 	me.PATCH("/nickname", func(c *gin.Context) {
 		uid := c.GetString("uid")
 		var in struct {
-			Nickname string `json:"nickname" binding:"required,min=1,max=30"`
+			Nickname string `json:"nickname" binding:"required,max=64"`
 		}
 		if err := c.ShouldBindJSON(&in); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		display, err := nickname.Validate(in.Nickname)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "reason": nickname.Reason(err)})
+			return
+		}
+		if err := filter.Check("nickname", display); err != nil {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
 		defer cancel()
-		_, err := pool.Exec(ctx, `UPDATE app_users SET nickname=$1, updated_at=now() WHERE id=$2`, in.Nickname, uid)
+
+		var cur *string
+		err = pool.QueryRow(ctx, `SELECT nickname FROM app_users WHERE id=$1`, uid).Scan(&cur)
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"ok": true})
+		if cur != nil && *cur == display {
+			c.JSON(http.StatusOK, gin.H{"ok": true, "nickname": display})
+			return
+		}
+
+		// 설정할 때마다(처음 포함) 쿨다운이 시작된다. 검사와 갱신을 한 문장으로 해 동시 요청도 한 번만 통과
+		ct, err := history.Exec(ctx, pool, self(uid), `
+			UPDATE app_users
+			SET nickname=$1, nickname_key=$2, nickname_changed_at=now(), updated_at=now()
+			WHERE id=$3 AND (nickname_changed_at IS NULL OR nickname_changed_at <= now() - make_interval(secs => $4))`,
+			display, nickname.Key(display), uid, cooldown.Seconds())
+		if util.IsUniqueViolation(err, "") {
+			writeNicknameTaken(ctx, c, pool, display)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if ct.RowsAffected() == 0 {
+			var changedAt time.Time
+			if err := pool.QueryRow(ctx, `SELECT nickname_changed_at FROM app_users WHERE id=$1`, uid).Scan(&changedAt); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "nickname was changed recently", "retry_at": changedAt.Add(cooldown)})
+			return
+		}
+		rewardOnboarding(ctx, pool, uid)
+		c.JSON(http.StatusOK, gin.H{"ok": true, "nickname": display})
 	})
 
 	// 2) 성별
@@ -91,10 +239,11 @@ func Register(v1 *gin.RouterGroup, pool *pgxpool.Pool, authSecret string) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		rewardOnboarding(ctx, pool, uid)
 		c.JSON(http.StatusOK, gin.H{"ok": true})
 	})
 
-	// 3) 생년월일(YYYY-MM-DD)
+	// 3) 생년월일(YYYY-MM-DD). 최소 나이 미만 거절, 한 번 정하면 고객지원으로만 변경
 	me.PATCH("/birthdate", func(c *gin.Context) {
 		uid := c.GetString("uid")
 		var in struct {
@@ -104,20 +253,32 @@ func Register(v1 *gin.RouterGroup, pool *pgxpool.Pool, authSecret string) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		birth, _ := time.Parse("2006-01-02", in.Birthdate)
+		if n := age.International(birth, age.Today()); n < cfg.MinAge {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "too young to sign up", "code": "under_age", "min_age": cfg.MinAge})
+			return
+		}
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
 		defer cancel()
-		_, err := pool.Exec(ctx, `
+		// 같은 값을 다시 보내는 것은 허용(재시도)
+		ct, err := history.Exec(ctx, pool, self(uid), `
 			INSERT INTO user_profile (user_id, birth_date, created_at, updated_at)
 			VALUES ($1, $2::date, now(), now())
-			ON CONFLICT (user_id) DO UPDATE SET birth_date=EXCLUDED.birth_date, updated_at=now()`, uid, in.Birthdate)
+			ON CONFLICT (user_id) DO UPDATE SET birth_date=EXCLUDED.birth_date, updated_at=now()
+			WHERE user_profile.birth_date IS NULL OR user_profile.birth_date = EXCLUDED.birth_date`, uid, in.Birthdate)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		if ct.RowsAffected() == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "birthdate is locked; contact support to change it", "code": "locked"})
+			return
+		}
+		rewardOnboarding(ctx, pool, uid)
 		c.JSON(http.StatusOK, gin.H{"ok": true})
 	})
 
-	// 4) 지역
+	// 4) 지역. 하위 지역이 없는 지역(보통 읍/면/동)만 고를 수 있다
 	me.PATCH("/region", func(c *gin.Context) {
 		uid := c.GetString("uid")
 		var in struct {
@@ -129,7 +290,24 @@ func Register(v1 *gin.RouterGroup, pool *pgxpool.Pool, authSecret string) {
 		}
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
 		defer cancel()
-		_, err := pool.Exec(ctx, `
+		// 숨긴 지역은 새로 고를 수 없다
+		if active, err := region.IsActive(ctx, pool, in.RegionID); errors.Is(err, region.ErrNotFound) || err == nil && !active {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown region"})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		leaf, err := region.IsLeaf(ctx, pool, in.RegionID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !leaf {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "pick the most specific region (it has sub-regions)", "code": "not_leaf"})
+			return
+		}
+		_, err = history.Exec(ctx, pool, self(uid), `
 			INSERT INTO user_profile (user_id, region_id, created_at, updated_at)
 			VALUES ($1, $2, now(), now())
 			ON CONFLICT (user_id) DO UPDATE SET region_id=EXCLUDED.region_id, updated_at=now()`, uid, in.RegionID)
@@ -137,6 +315,7 @@ func Register(v1 *gin.RouterGroup, pool *pgxpool.Pool, authSecret string) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		rewardOnboarding(ctx, pool, uid)
 		c.JSON(http.StatusOK, gin.H{"ok": true})
 	})
 
@@ -145,15 +324,32 @@ func Register(v1 *gin.RouterGroup, pool *pgxpool.Pool, authSecret string) {
 		uid := c.GetString("uid")
 		var in struct {
 			Category string `json:"category" binding:"required"` // job_category.code
-			Detail   string `json:"detail"`
+			Detail   string `json:"detail" binding:"max=100"`
 		}
 		if err := c.ShouldBindJSON(&in); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if err := filter.Check("detail", in.Detail); err != nil {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
 		defer cancel()
-		_, err := pool.Exec(ctx, `
+		// 숨긴 직업은 지금 그것을 쓰고 있는 사용자만 유지할 수 있다
+		var ok bool
+		err := pool.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM job_category WHERE code=$1
+				AND (active OR EXISTS (SELECT 1 FROM user_job WHERE user_id=$2 AND category=$1)))`, in.Category, uid).Scan(&ok)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown job category"})
+			return
+		}
+		_, err = history.Exec(ctx, pool, self(uid), `
 			INSERT INTO user_job (user_id, category, detail, created_at)
 			VALUES ($1, $2, $3, now())
 			ON CONFLICT (user_id) DO UPDATE SET category=EXCLUDED.category, detail=EXCLUDED.detail`, uid, in.Category, in.Detail)
@@ -161,84 +357,232 @@ func Register(v1 *gin.RouterGroup, pool *pgxpool.Pool, authSecret string) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		rewardOnboarding(ctx, pool, uid)
 		c.JSON(http.StatusOK, gin.H{"ok": true})
 	})
 
-	// 6) 프로필(아바타: 캐릭터+배경)
+	// 6) 프로필(아바타: 슬롯별 항목). slots 로 모든 슬롯을 한 번에 바꾸거나,
+	// 예전 형식(character_id/bg_id)으로 base·background 만 바꾼다(다른 슬롯은 그대로)
 	me.PUT("/avatar", func(c *gin.Context) {
 		uid := c.GetString("uid")
 		var in struct {
-			CategoryCode string `json:"category_code"` // optional
-			CharacterID  string `json:"character_id" binding:"required"`
-			BgID         string `json:"bg_id" binding:"required"`
+			CategoryCode string             `json:"category_code"` // optional
+			Slots        map[string]*string `json:"slots" binding:"omitempty,dive,omitempty,uuid"`
+			CharacterID  string             `json:"character_id" binding:"omitempty,uuid"`
+			BgID         string             `json:"bg_id" binding:"omitempty,uuid"`
 		}
 		if err := c.ShouldBindJSON(&in); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		sel := &avatar.Selection{CategoryCode: in.CategoryCode, CharacterID: in.CharacterID, BgID: in.BgID}
+		var err error
+		switch {
+		case in.Slots != nil && (in.CharacterID != "" || in.BgID != ""):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "use either slots or character_id/bg_id"})
+			return
+		case in.Slots != nil:
+			sel, err = avatar.FromSlots(in.CategoryCode, in.Slots)
+		case in.CharacterID == "":
+			err = &avatar.FieldError{Field: "character_id", Code: avatar.CodeRequired}
+		case in.BgID == "":
+			err = &avatar.FieldError{Field: "bg_id", Code: avatar.CodeRequired}
+		}
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
 		defer cancel()
-		_, err := pool.Exec(ctx, `
-			INSERT INTO user_avatar (user_id, category_code, character_id, bg_id, selected_at)
-			VALUES ($1, NULLIF($2,''), $3, $4, now())
-			ON CONFLICT (user_id) DO UPDATE SET category_code=EXCLUDED.category_code, character_id=EXCLUDED.character_id, bg_id=EXCLUDED.bg_id, selected_at=now()
-		`, uid, in.CategoryCode, in.CharacterID, in.BgID)
+		// 분류는 캐릭터에서 정하고(category_code 는 확인용), 배경은 호환 규칙을 따른다
+		if err == nil {
+			err = avatar.Check(ctx, pool, uid, sel)
+		}
+		if err == nil {
+			_, err = history.Exec(ctx, pool, self(uid), `
+			INSERT INTO user_avatar (user_id, category_code, character_id, bg_id, outfit_id, expression_id, accessory_id, selected_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, now())
+			ON CONFLICT (user_id) DO UPDATE SET category_code=EXCLUDED.category_code, character_id=EXCLUDED.character_id, bg_id=EXCLUDED.bg_id,
+				outfit_id=CASE WHEN $8 THEN EXCLUDED.outfit_id ELSE user_avatar.outfit_id END,
+				expression_id=CASE WHEN $8 THEN EXCLUDED.expression_id ELSE user_avatar.expression_id END,
+				accessory_id=CASE WHEN $8 THEN EXCLUDED.accessory_id ELSE user_avatar.accessory_id END,
+				selected_at=now()
+		`, uid, sel.CategoryCode, sel.CharacterID, sel.BgID, sel.Part("outfit"), sel.Part("expression"), sel.Part("accessory"), sel.BySlot)
+			err = sel.FromPgError(err)
+		}
+		var fe *avatar.FieldError
+		if errors.As(err, &fe) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fe.Error(), "field": fe.Field, "code": fe.Code})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		rewardOnboarding(ctx, pool, uid)
 		c.JSON(http.StatusOK, gin.H{"ok": true})
 	})
 
-	// 7) 프로필 사진 업로드(간단화: URL 직접 입력 또는 multipart 파일 미구현 시 URL만)
-	me.PATCH("/photo", func(c *gin.Context) {
-		uid := c.GetString("uid")
-		var in struct {
-			URL string `json:"url" binding:"required,uri"`
+	// 아바타 합성 이미지(슬롯을 z_order 순으로 얹는다). 공유 카드/푸시 알림용 단일 URL
+	me.GET("/avatar/image", func(c *gin.Context) {
+		writeAvatarImage(c, avatars, c.GetString("uid"))
+	})
+
+	// 다른 사용자 프로필(공개 범위 적용)
+	users.GET("/:id/profile", func(c *gin.Context) {
+		var uri struct {
+			ID string `uri:"id" binding:"required,uuid"`
 		}
-		if err := c.ShouldBindJSON(&in); err != nil {
+		if err := c.ShouldBindUri(&uri); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
 		defer cancel()
 
-		// media_asset 생성
-		var assetID string
-		if err := pool.QueryRow(ctx, `INSERT INTO media_asset (kind, url) VALUES ('image', $1) RETURNING id`, in.URL).Scan(&assetID); err != nil {
-			if util.IsClientInputError(err) {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			}
+		full, err := loadProfile(ctx, pool, uri.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 			return
 		}
-
-		_, err := pool.Exec(ctx, `
-			INSERT INTO user_profile (user_id, profile_image_id, created_at, updated_at)
-			VALUES ($1, $2, now(), now())
-			ON CONFLICT (user_id) DO UPDATE SET profile_image_id=EXCLUDED.profile_image_id, updated_at=now()`, uid, assetID)
+		var out map[string]any
+		if err == nil {
+			out, err = privacy.Apply(ctx, pool, uri.ID, c.GetString("uid"), full)
+		}
 		if err != nil {
-			if util.IsClientInputError(err) {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		c.JSON(http.StatusOK, out)
+	})
 
-		// 업로드 로그(선택)
-		_, _ = pool.Exec(ctx, `
-			INSERT INTO user_face_upload (user_id, url, status, created_at)
-			VALUES ($1, $2, 'uploaded', now())`, uid, in.URL)
-
-		c.JSON(http.StatusOK, gin.H{"ok": true, "asset_id": assetID})
+	// 아바타는 공개 범위와 관계없이 보인다
+	users.GET("/:id/avatar/image", func(c *gin.Context) {
+		var uri struct {
+			ID string `uri:"id" binding:"required,uuid"`
+		}
+		if err := c.ShouldBindUri(&uri); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		writeAvatarImage(c, avatars, uri.ID)
 	})
 }
 
+// size(한 변 픽셀), format(png|webp). 처음 요청이거나 아바타/미리보기가 바뀌었으면 그 자리에서 그린다
+func writeAvatarImage(c *gin.Context, avatars *avatar.Renderer, uid string) {
+	in := struct {
+		Size   int    `form:"size" binding:"omitempty,oneof=128 256 512 1024"`
+		Format string `form:"format" binding:"omitempty,oneof=png webp"`
+	}{Size: avatar.DefaultRenderSize, Format: avatar.DefaultRenderFormat}
+	if err := c.ShouldBindQuery(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	img, err := avatars.Get(ctx, uid, in.Size, in.Format)
+	if errors.Is(err, avatar.ErrNoAvatar) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, img)
+}
+
+// 본인/타인 프로필 조회 공용. 타인에게는 privacy.Apply 가 만든 응답만 내려보낼 것
+func loadProfile(ctx context.Context, pool *pgxpool.Pool, uid string) (gin.H, error) {
+	var nickname, gender, bio *string
+	var birth *time.Time
+	var regionID *int
+	var jobCat, jobDetail *string
+	var avCat, avChar, avBg, avOutfit, avExpr, avAcc *string
+	var photoID, photoURL *string
+	err := pool.QueryRow(ctx, `
+		SELECT u.nickname, up.gender, up.bio, up.birth_date, up.region_id,
+		       uj.category, uj.detail,
+		       ua.category_code, ua.character_id::text, ua.bg_id::text,
+		       ua.outfit_id::text, ua.expression_id::text, ua.accessory_id::text,
+		       ma.id::text, ma.url
+		FROM app_users u
+		LEFT JOIN user_profile up ON up.user_id = u.id
+		LEFT JOIN media_asset ma ON ma.id = up.profile_image_id
+		LEFT JOIN user_job uj ON uj.user_id = u.id
+		LEFT JOIN user_avatar ua ON ua.user_id = u.id
+		WHERE u.id=$1`, uid).Scan(&nickname, &gender, &bio, &birth, &regionID,
+		&jobCat, &jobDetail, &avCat, &avChar, &avBg, &avOutfit, &avExpr, &avAcc, &photoID, &photoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	prompts, err := loadPrompts(ctx, pool, uid)
+	if err != nil {
+		return nil, err
+	}
+	photos, err := gallery.List(ctx, pool, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	out := gin.H{"id": uid, "nickname": nickname, "gender": gender, "bio": bio, "prompts": prompts, "birthdate": nil,
+		"age": nil, "korean_age": nil, "age_range": nil, "region": nil, "job": nil, "avatar": nil, "photo": nil, "photos": photos}
+	if birth != nil {
+		n := age.International(*birth, age.Today())
+		out["birthdate"] = birth.Format("2006-01-02")
+		out["age"] = n
+		out["korean_age"] = age.Korean(*birth, age.Today())
+		out["age_range"] = age.Bucket(n)
+	}
+	if regionID != nil {
+		// 시/도부터의 전체 경로
+		path, err := region.Path(ctx, pool, *regionID)
+		if err != nil {
+			return nil, err
+		}
+		leaf := path[len(path)-1]
+		out["region"] = gin.H{"id": leaf.ID, "name": leaf.Name, "level": leaf.Level, "full_name": region.FullName(path), "path": path}
+	}
+	if jobCat != nil {
+		out["job"] = gin.H{"category": jobCat, "detail": jobDetail}
+	}
+	if avChar != nil || avBg != nil {
+		out["avatar"] = gin.H{"category_code": avCat, "character_id": avChar, "bg_id": avBg, "slots": gin.H{
+			avatar.SlotBase: avChar, avatar.SlotBackground: avBg, "outfit": avOutfit, "expression": avExpr, "accessory": avAcc}}
+	}
+	if photoID != nil {
+		variants, err := media.LoadVariants(ctx, pool, *photoID)
+		if err != nil {
+			return nil, err
+		}
+		out["photo"] = gin.H{"asset_id": *photoID, "url": photoURL, "variants": variants}
+	}
+	return out, nil
+}
+
+// 409 + 대안 닉네임(auth 와 동일한 응답 형태)
+func writeNicknameTaken(ctx context.Context, c *gin.Context, pool *pgxpool.Pool, display string) {
+	sugg, err := nickname.Suggest(ctx, pool, display, 3)
+	if err != nil {
+		sugg = []string{}
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": nickname.ErrTaken.Error(), "reason": "taken", "suggestions": sugg})
+}
+
+// 본인 변경으로 이력 기록
+func self(uid string) history.Actor {
+	return history.Actor{ID: uid, Source: history.SourceUser}
+}
+
+// 온보딩 단계를 채운 쓰기 뒤에 부른다. 모두 마쳤으면 보상을 지급한다.
+// 쓰기는 이미 커밋됐으므로 지급이 실패해도 응답은 성공으로 두고 기록만 남긴다(다음 단계 쓰기에서 다시 시도)
+func rewardOnboarding(ctx context.Context, pool *pgxpool.Pool, uid string) {
+	if err := onboarding.Reward(ctx, pool, uid); err != nil {
+		log.Printf("onboarding reward for %s: %v", uid, err)
+	}
+}
+
 func upsertProfile(ctx context.Context, pool *pgxpool.Pool, uid, field, str string, i1, i2, i3 any) (int64, error) {
 	// 간단화: gender만 이 헬퍼를 사용
-	ct, err := pool.Exec(ctx, `
+	ct, err := history.Exec(ctx, pool, self(uid), `
 		INSERT INTO user_profile (user_id, `+field+`, created_at, updated_at)
 		VALUES ($1, $2, now(), now())
 		ON CONFLICT (user_id) DO UPDATE SET `+field+`=EXCLUDED.`+field+`, updated_at=now()`, uid, str)
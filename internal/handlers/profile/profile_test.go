@@ -5,18 +5,30 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"image"
+	"image/color"
+	"image/png"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/image/draw"
 
+	"github.com/creators-of-happiness/amigo-backend/internal/avatar"
+	"github.com/creators-of-happiness/amigo-backend/internal/config"
 	"github.com/creators-of-happiness/amigo-backend/internal/handlers/profile"
+	"github.com/creators-of-happiness/amigo-backend/internal/inventory"
+	"github.com/creators-of-happiness/amigo-backend/internal/moderation"
 	"github.com/creators-of-happiness/amigo-backend/internal/repo"
+	"github.com/creators-of-happiness/amigo-backend/internal/storage"
 	"github.com/creators-of-happiness/amigo-backend/internal/token"
+	"github.com/creators-of-happiness/amigo-backend/internal/wallet"
 )
 
 // ----------------------------- helpers ---------------------------------------
@@ -87,7 +99,7 @@ func seedMeta(t *testing.T, pool *pgxpool.Pool) metaSeed {
 		WITH ins AS (
 			INSERT INTO region (name, latitude, longitude)
 			VALUES ('Testland', 0, 0)
-			ON CONFLICT (name) DO NOTHING
+			ON CONFLICT DO NOTHING
 			RETURNING id
 		)
 		SELECT id FROM ins
@@ -163,11 +175,16 @@ func seedMeta(t *testing.T, pool *pgxpool.Pool) metaSeed {
 }
 
 func setupRouter(pool *pgxpool.Pool, secret string) *gin.Engine {
+	return setupRouterWith(pool, secret, nil)
+}
+
+// 아바타 합성 이미지를 그리는 테스트는 저장소가 있는 Renderer 를 넘긴다
+func setupRouterWith(pool *pgxpool.Pool, secret string, avatars *avatar.Renderer) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	r.Use(gin.Recovery())
 	v1 := r.Group("/api/v1")
-	profile.Register(v1, pool, secret)
+	profile.Register(v1, pool, config.Config{AuthSecret: secret, NicknameCooldownDays: 30, MinAge: 18}, moderation.Default(), avatars)
 	return r
 }
 
@@ -179,7 +196,7 @@ func newUserAndToken(t *testing.T, pool *pgxpool.Pool, secret string, withNickna
 	phone = fmt.Sprintf("+82 10-%04d-%04d", time.Now().Unix()%10000, time.Now().UnixNano()%10000)
 	nick := ""
 	if withNickname {
-		nick = fmt.Sprintf("nick-%d", time.Now().UnixNano())
+		nick = fmt.Sprintf("nick%d", time.Now().UnixNano()%1e12)
 	}
 	u, err := repo.FindOrCreateUser(ctx, pool, phone, nick)
 	if err != nil {
@@ -260,7 +277,7 @@ func TestNickname_Update_OK(t *testing.T) {
 
 	r := setupRouter(pool, secret)
 
-	want := fmt.Sprintf("hjyoon-%d", time.Now().UnixNano())
+	want := fmt.Sprintf("hj%d", time.Now().UnixNano()%1e12)
 	w := doJSON(t, r, http.MethodPatch, "/api/v1/me/nickname", tok, map[string]any{"nickname": want})
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
@@ -278,6 +295,138 @@ func TestNickname_Update_OK(t *testing.T) {
 	}
 }
 
+// 규칙 위반 닉네임은 400 + 사유 코드 (DB 접근 전 거절)
+func TestNickname_Invalid(t *testing.T) {
+	secret := "test-secret"
+	r := setupRouter(nil, secret)
+	tok, _, _ := token.Sign(secret, "00000000-0000-0000-0000-000000000000", "+82 10-0000-0000", time.Hour)
+
+	cases := map[string]string{
+		"a":                 "length",
+		"abcdefghijklmnopq": "length",
+		"hello world":       "characters",
+		"dot..dot":          "characters",
+		"😀😀":                "characters",
+		"Ａｄｍｉｎ":             "reserved",
+		"real_amigo":        "reserved",
+		"관리자":               "reserved",
+	}
+	for nick, reason := range cases {
+		w := doJSON(t, r, http.MethodPatch, "/api/v1/me/nickname", tok, map[string]any{"nickname": nick})
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%q: expected 400, got %d; body=%s", nick, w.Code, w.Body.String())
+		}
+		var out struct {
+			Reason string `json:"reason"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &out)
+		if out.Reason != reason {
+			t.Fatalf("%q: expected reason %q, got %q", nick, reason, out.Reason)
+		}
+	}
+}
+
+// 금칙어가 든 닉네임/직업 상세는 400 + 필드 이름 (DB 접근 전 거절)
+func TestProfanity_Rejected(t *testing.T) {
+	secret := "test-secret"
+	r := setupRouter(nil, secret)
+	tok, _, _ := token.Sign(secret, "00000000-0000-0000-0000-000000000000", "+82 10-0000-0000", time.Hour)
+
+	cases := []struct {
+		method, path, field string
+		body                map[string]any
+	}{
+		{http.MethodPatch, "/api/v1/me/nickname", "nickname", map[string]any{"nickname": "씨1발놈"}},
+		{http.MethodPut, "/api/v1/me/job", "detail", map[string]any{"category": "dev", "detail": "f u c k this job"}},
+	}
+	for _, tc := range cases {
+		w := doJSON(t, r, tc.method, tc.path, tok, tc.body)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d; body=%s", tc.path, w.Code, w.Body.String())
+		}
+		var out struct {
+			Field string `json:"field"`
+			Code  string `json:"code"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &out)
+		if out.Field != tc.field || out.Code != "profanity" {
+			t.Fatalf("%s: unexpected body %s", tc.path, w.Body.String())
+		}
+	}
+}
+
+// 다른 사용자의 닉네임(대소문자 무시)은 409 + 대안 제안, 가용성 조회도 taken
+func TestNickname_Conflict_Suggests(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+
+	secret := "test-secret"
+	_, _, tokA := newUserAndToken(t, pool, secret, false)
+	_, _, tokB := newUserAndToken(t, pool, secret, false)
+	r := setupRouter(pool, secret)
+
+	nick := fmt.Sprintf("Taken%d", time.Now().UnixNano()%1e10)
+	if w := doJSON(t, r, http.MethodPatch, "/api/v1/me/nickname", tokA, map[string]any{"nickname": nick}); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+
+	w := doJSON(t, r, http.MethodPatch, "/api/v1/me/nickname", tokB, map[string]any{"nickname": strings.ToLower(nick)})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d; body=%s", w.Code, w.Body.String())
+	}
+	var conflict struct {
+		Suggestions []string `json:"suggestions"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &conflict)
+	if len(conflict.Suggestions) == 0 {
+		t.Fatalf("expected suggestions, got %s", w.Body.String())
+	}
+
+	w = doJSON(t, r, http.MethodGet, "/api/v1/me/nickname/availability?q="+strings.ToUpper(nick), tokB, nil)
+	var avail struct {
+		Available   bool     `json:"available"`
+		Reason      string   `json:"reason"`
+		Suggestions []string `json:"suggestions"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &avail)
+	if w.Code != http.StatusOK || avail.Available || avail.Reason != "taken" || len(avail.Suggestions) == 0 {
+		t.Fatalf("unexpected availability: %d %s", w.Code, w.Body.String())
+	}
+
+	// 본인 닉네임은 사용 가능으로 응답
+	w = doJSON(t, r, http.MethodGet, "/api/v1/me/nickname/availability?q="+nick, tokA, nil)
+	_ = json.Unmarshal(w.Body.Bytes(), &avail)
+	if !avail.Available {
+		t.Fatalf("own nickname should be available: %s", w.Body.String())
+	}
+}
+
+// 처음 설정도 쿨다운을 시작한다: 설정 직후 변경은 429
+func TestNickname_Cooldown(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+
+	secret := "test-secret"
+	_, _, tok := newUserAndToken(t, pool, secret, false)
+	r := setupRouter(pool, secret)
+
+	base := time.Now().UnixNano() % 1e10
+	if w := doJSON(t, r, http.MethodPatch, "/api/v1/me/nickname", tok, map[string]any{"nickname": fmt.Sprintf("first%d", base)}); w.Code != http.StatusOK {
+		t.Fatalf("first set: expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	w := doJSON(t, r, http.MethodPatch, "/api/v1/me/nickname", tok, map[string]any{"nickname": fmt.Sprintf("second%d", base)})
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second change: expected 429, got %d; body=%s", w.Code, w.Body.String())
+	}
+
+	// 가입 때 받은 닉네임도 마찬가지
+	_, _, tok2 := newUserAndToken(t, pool, secret, true)
+	w = doJSON(t, r, http.MethodPatch, "/api/v1/me/nickname", tok2, map[string]any{"nickname": fmt.Sprintf("third%d", base)})
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("change after signup: expected 429, got %d; body=%s", w.Code, w.Body.String())
+	}
+}
+
 // 성별 설정 OK
 func TestGender_Set_OK(t *testing.T) {
 	pool := newTestPool(t)
@@ -424,40 +573,1034 @@ func TestAvatar_Upsert_OK(t *testing.T) {
 	}
 }
 
-// 프로필 사진 URL 설정 OK (media_asset 생성 + profile_image_id 세팅)
-func TestPhoto_Set_OK(t *testing.T) {
+// 노출 기간 밖의 캐릭터는 새로 고를 수 없고, 이미 고른 사용자는 유지할 수 있다
+func TestAvatar_Unavailable(t *testing.T) {
 	pool := newTestPool(t)
 	ensureSchema(t, pool)
-	_ = seedMeta(t, pool)
+	seed := seedMeta(t, pool)
+	ctx := context.Background()
+	setUntil := func(until any) {
+		t.Helper()
+		if _, err := pool.Exec(ctx, `UPDATE character_item SET available_until=$2 WHERE id=$1`, seed.CharacterID, until); err != nil {
+			t.Skipf("skipping: available_until not found (run migrations first): %v", err)
+		}
+	}
+	setUntil(nil)
+	t.Cleanup(func() {
+		_, _ = pool.Exec(context.Background(), `UPDATE character_item SET available_until=NULL WHERE id=$1`, seed.CharacterID)
+	})
+
+	secret := "test-secret"
+	_, _, tok := newUserAndToken(t, pool, secret, true)
+	_, _, other := newUserAndToken(t, pool, secret, true)
+	r := setupRouter(pool, secret)
+	body := map[string]any{"character_id": seed.CharacterID, "bg_id": seed.BgID}
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/avatar", tok, body); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+
+	setUntil(time.Now().Add(-time.Hour))
+	w := doJSON(t, r, http.MethodPut, "/api/v1/me/avatar", other, body)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"character_id"`) {
+		t.Fatalf("expected 400 on character_id, got %d; body=%s", w.Code, w.Body.String())
+	}
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/avatar", tok, body); w.Code != http.StatusOK {
+		t.Fatalf("current owner must keep the item, got %d; body=%s", w.Code, w.Body.String())
+	}
+}
+
+// 분류는 캐릭터에서 정해지고, 다른 분류를 보내면 mismatch
+func TestAvatar_CategoryMismatch(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	seed := seedMeta(t, pool)
+	other := seedMeta(t, pool)
+
+	secret := "test-secret"
+	uid, _, tok := newUserAndToken(t, pool, secret, true)
+	r := setupRouter(pool, secret)
+	body := map[string]any{"category_code": other.CharCatCode, "character_id": seed.CharacterID, "bg_id": seed.BgID}
+	w := doJSON(t, r, http.MethodPut, "/api/v1/me/avatar", tok, body)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"category_code"`) ||
+		!strings.Contains(w.Body.String(), `"code":"mismatch"`) {
+		t.Fatalf("expected 400 mismatch on category_code, got %d; body=%s", w.Code, w.Body.String())
+	}
+
+	// category_code 를 빼면 캐릭터의 분류로 저장
+	delete(body, "category_code")
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/avatar", tok, body); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	var cc string
+	if err := pool.QueryRow(context.Background(), `SELECT category_code FROM user_avatar WHERE user_id=$1`, uid).Scan(&cc); err != nil {
+		t.Fatalf("query user_avatar: %v", err)
+	}
+	if cc != seed.CharCatCode {
+		t.Fatalf("expected derived category %s, got %s", seed.CharCatCode, cc)
+	}
+}
+
+// deny 규칙에 걸린 배경은 새로 고를 수 없고, 이미 그 조합인 사용자는 유지할 수 있다
+func TestAvatar_IncompatibleBackground(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	seed := seedMeta(t, pool)
+	ctx := context.Background()
+	var exists string
+	if err := pool.QueryRow(ctx, `SELECT COALESCE(to_regclass('public.avatar_compat_rule')::text, '')`).Scan(&exists); err != nil || exists == "" {
+		t.Skip("skipping: table avatar_compat_rule not found (run migrations first)")
+	}
+
+	secret := "test-secret"
+	_, _, tok := newUserAndToken(t, pool, secret, true)
+	_, _, other := newUserAndToken(t, pool, secret, true)
+	r := setupRouter(pool, secret)
+	body := map[string]any{"character_id": seed.CharacterID, "bg_id": seed.BgID}
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/avatar", tok, body); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+
+	tag := "ut-night-" + fmt.Sprint(time.Now().UnixNano()%1000000)
+	var ruleID int
+	if err := pool.QueryRow(ctx, `
+		INSERT INTO avatar_compat_rule (character_category, bg_tag, effect) VALUES ($1, $2, 'deny') RETURNING id`,
+		seed.CharCatCode, tag).Scan(&ruleID); err != nil {
+		t.Fatalf("seed rule: %v", err)
+	}
+	if _, err := pool.Exec(ctx, `UPDATE bg_item SET tags = array_append(tags, $2) WHERE id=$1`, seed.BgID, tag); err != nil {
+		t.Fatalf("tag bg: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx := context.Background()
+		_, _ = pool.Exec(ctx, `DELETE FROM avatar_compat_rule WHERE id=$1`, ruleID)
+		_, _ = pool.Exec(ctx, `UPDATE bg_item SET tags = array_remove(tags, $2) WHERE id=$1`, seed.BgID, tag)
+	})
+
+	w := doJSON(t, r, http.MethodPut, "/api/v1/me/avatar", other, body)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"bg_id"`) ||
+		!strings.Contains(w.Body.String(), `"code":"incompatible"`) {
+		t.Fatalf("expected 400 incompatible on bg_id, got %d; body=%s", w.Code, w.Body.String())
+	}
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/avatar", tok, body); w.Code != http.StatusOK {
+		t.Fatalf("current pair must be kept, got %d; body=%s", w.Code, w.Body.String())
+	}
+}
+
+// slots 로 파트까지 고르고, 다른 슬롯의 파트는 mismatch. 예전 형식으로 바꾸면 파트는 그대로
+func TestAvatar_Slots(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	seed := seedMeta(t, pool)
+	ctx := context.Background()
+	var exists string
+	if err := pool.QueryRow(ctx, `SELECT COALESCE(to_regclass('public.avatar_part')::text, '')`).Scan(&exists); err != nil || exists == "" {
+		t.Skip("skipping: table avatar_part not found (run migrations first)")
+	}
+	name := "UT Slot Hat " + fmt.Sprint(time.Now().UnixNano()%1000000)
+	var hat string
+	if err := pool.QueryRow(ctx, `
+		INSERT INTO avatar_part (slot_code, name, anchor_y, scale) VALUES ('accessory', $1, 0.2, 0.4) RETURNING id::text`, name).Scan(&hat); err != nil {
+		t.Fatalf("seed part: %v", err)
+	}
 
 	secret := "test-secret"
 	uid, _, tok := newUserAndToken(t, pool, secret, true)
+	t.Cleanup(func() {
+		ctx := context.Background()
+		_, _ = pool.Exec(ctx, `DELETE FROM user_avatar WHERE user_id=$1`, uid)
+		_, _ = pool.Exec(ctx, `DELETE FROM avatar_part WHERE id=$1`, hat)
+	})
+	r := setupRouter(pool, secret)
+
+	w := doJSON(t, r, http.MethodPut, "/api/v1/me/avatar", tok, map[string]any{
+		"slots": map[string]any{"base": seed.CharacterID, "background": seed.BgID, "outfit": hat}})
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"slots.outfit"`) ||
+		!strings.Contains(w.Body.String(), `"code":"mismatch"`) {
+		t.Fatalf("expected 400 mismatch on slots.outfit, got %d; body=%s", w.Code, w.Body.String())
+	}
+	w = doJSON(t, r, http.MethodPut, "/api/v1/me/avatar", tok, map[string]any{
+		"slots": map[string]any{"base": seed.CharacterID, "background": seed.BgID, "accessory": hat}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+
+	// 예전 형식은 base/background 만 바꾼다
+	body := map[string]any{"character_id": seed.CharacterID, "bg_id": seed.BgID}
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/avatar", tok, body); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	w = doJSON(t, r, http.MethodGet, "/api/v1/me/profile", tok, nil)
+	var out struct {
+		Avatar struct {
+			Slots map[string]*string `json:"slots"`
+		} `json:"avatar"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &out)
+	if got := out.Avatar.Slots["accessory"]; got == nil || *got != hat || out.Avatar.Slots["base"] == nil {
+		t.Fatalf("expected accessory to be kept, body=%s", w.Body.String())
+	}
+
+	// null 로 비운다
+	w = doJSON(t, r, http.MethodPut, "/api/v1/me/avatar", tok, map[string]any{
+		"slots": map[string]any{"base": seed.CharacterID, "background": seed.BgID, "accessory": nil}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	var acc *string
+	if err := pool.QueryRow(ctx, `SELECT accessory_id::text FROM user_avatar WHERE user_id=$1`, uid).Scan(&acc); err != nil || acc != nil {
+		t.Fatalf("expected accessory cleared, got %v (%v)", acc, err)
+	}
+}
 
+// 모르는 슬롯/필수 슬롯 누락/두 형식 혼용은 DB 없이 400
+func TestAvatar_SlotsInvalid(t *testing.T) {
+	secret := "test-secret"
+	r := setupRouter(nil, secret)
+	tok, _, _ := token.Sign(secret, "00000000-0000-0000-0000-000000000000", "+82 10-0000-0000", time.Hour)
+	id := "00000000-0000-0000-0000-000000000001"
+	for _, tt := range []struct {
+		body map[string]any
+		want string
+	}{
+		{map[string]any{"slots": map[string]any{"base": id, "background": id, "hat": id}}, `"field":"slots.hat"`},
+		{map[string]any{"slots": map[string]any{"base": id}}, `"code":"required"`},
+		{map[string]any{"slots": map[string]any{"base": id, "background": "not-a-uuid"}}, `"error"`},
+		{map[string]any{"slots": map[string]any{"base": id, "background": id}, "character_id": id}, `"error"`},
+		{map[string]any{"character_id": id}, `"field":"bg_id"`},
+	} {
+		w := doJSON(t, r, http.MethodPut, "/api/v1/me/avatar", tok, tt.body)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tt.want) {
+			t.Fatalf("%v: expected 400 with %s, got %d; body=%s", tt.body, tt.want, w.Code, w.Body.String())
+		}
+	}
+}
+
+// 잠긴 배경은 보유해야 고를 수 있고, 회수돼도 지금 쓰고 있으면 유지한다
+func TestAvatar_Locked(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	seed := seedMeta(t, pool)
+	ctx := context.Background()
+	var exists string
+	if err := pool.QueryRow(ctx, `SELECT COALESCE(to_regclass('public.user_inventory')::text, '')`).Scan(&exists); err != nil || exists == "" {
+		t.Skip("skipping: table user_inventory not found (run migrations first)")
+	}
+	var bg string
+	if err := pool.QueryRow(ctx, `
+		INSERT INTO bg_item (name, locked, unlock_source) VALUES ($1, true, 'purchase') RETURNING id::text`,
+		fmt.Sprintf("UT Locked %d", time.Now().UnixNano())).Scan(&bg); err != nil {
+		t.Fatalf("seed bg: %v", err)
+	}
+
+	secret := "test-secret"
+	uid, _, tok := newUserAndToken(t, pool, secret, true)
+	t.Cleanup(func() {
+		ctx := context.Background()
+		_, _ = pool.Exec(ctx, `DELETE FROM user_avatar WHERE user_id=$1`, uid)
+		_, _ = pool.Exec(ctx, `DELETE FROM bg_item WHERE id=$1`, bg)
+	})
 	r := setupRouter(pool, secret)
-	url := fmt.Sprintf("https://example.com/me-%d.jpg", time.Now().UnixNano())
-	w := doJSON(t, r, http.MethodPatch, "/api/v1/me/photo", tok, map[string]any{"url": url})
+	body := map[string]any{"character_id": seed.CharacterID, "bg_id": bg}
+	w := doJSON(t, r, http.MethodPut, "/api/v1/me/avatar", tok, body)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"code":"locked"`) {
+		t.Fatalf("expected 400 locked, got %d; body=%s", w.Code, w.Body.String())
+	}
+
+	if _, err := inventory.Give(ctx, pool, inventory.Grant{UserID: uid, Entity: "bg_item", ItemID: bg, Source: inventory.SourcePurchase}); err != nil {
+		t.Fatalf("grant: %v", err)
+	}
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/avatar", tok, body); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 after grant, got %d; body=%s", w.Code, w.Body.String())
+	}
+	w = doJSON(t, r, http.MethodGet, "/api/v1/me/inventory?entity=bg_item", tok, nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), bg) || !strings.Contains(w.Body.String(), `"source":"purchase"`) {
+		t.Fatalf("expected item in inventory, got %d; body=%s", w.Code, w.Body.String())
+	}
+
+	if _, err := inventory.Revoke(ctx, pool, uid, "bg_item", bg); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/avatar", tok, body); w.Code != http.StatusOK {
+		t.Fatalf("current avatar must be kept, got %d; body=%s", w.Code, w.Body.String())
+	}
+}
+
+// 온보딩 보상은 마지막 단계를 채우는 쓰기에서 지급되고, 조회(GET)는 아무것도 지급하지 않는다
+func TestOnboarding_RewardOnLastStep(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	seed := seedMeta(t, pool)
+	ctx := context.Background()
+	var exists string
+	if err := pool.QueryRow(ctx, `SELECT COALESCE(to_regclass('public.user_inventory')::text, '')`).Scan(&exists); err != nil || exists == "" {
+		t.Skip("skipping: table user_inventory not found (run migrations first)")
+	}
+	var bg string
+	if err := pool.QueryRow(ctx, `
+		INSERT INTO bg_item (name, locked, unlock_source) VALUES ($1, true, 'onboarding') RETURNING id::text`,
+		fmt.Sprintf("UT Onboarding %d", time.Now().UnixNano())).Scan(&bg); err != nil {
+		t.Fatalf("seed bg: %v", err)
+	}
+	t.Cleanup(func() { _, _ = pool.Exec(context.Background(), `DELETE FROM bg_item WHERE id=$1`, bg) })
+
+	secret := "test-secret"
+	uid, _, tok := newUserAndToken(t, pool, secret, true)
+	r := setupRouter(pool, secret)
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO user_face_upload (user_id, url, status, target) VALUES ($1, 'https://example.com/ut-face.jpg', 'pending_review', 'primary')`, uid); err != nil {
+		t.Fatalf("seed photo: %v", err)
+	}
+	steps := []struct {
+		method, path string
+		body         map[string]any
+	}{
+		{http.MethodPatch, "/api/v1/me/birthdate", map[string]any{"birthdate": "1995-05-05"}},
+		{http.MethodPatch, "/api/v1/me/region", map[string]any{"region_id": seed.RegionID}},
+		{http.MethodPut, "/api/v1/me/job", map[string]any{"category": seed.JobCode}},
+		{http.MethodPut, "/api/v1/me/avatar", map[string]any{"character_id": seed.CharacterID, "bg_id": seed.BgID}},
+	}
+	for _, s := range steps {
+		if w := doJSON(t, r, s.method, s.path, tok, s.body); w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d; body=%s", s.path, w.Code, w.Body.String())
+		}
+	}
+
+	owned := func() bool {
+		var ok bool
+		_ = pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM user_inventory WHERE user_id=$1 AND item_id=$2)`, uid, bg).Scan(&ok)
+		return ok
+	}
+	if w := doJSON(t, r, http.MethodGet, "/api/v1/me/onboarding-state", tok, nil); w.Code != http.StatusOK || owned() {
+		t.Fatalf("reward before the last step: %d %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(t, r, http.MethodPatch, "/api/v1/me/gender", tok, map[string]any{"gender": "female"}); w.Code != http.StatusOK {
+		t.Fatalf("gender: expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	if !owned() {
+		t.Fatal("completing onboarding must grant the reward")
+	}
+}
+
+func TestInventory_Invalid(t *testing.T) {
+	secret := "test-secret"
+	r := setupRouter(nil, secret)
+	tok, _, _ := token.Sign(secret, "00000000-0000-0000-0000-000000000000", "+82 10-0000-0000", time.Hour)
+	if w := doJSON(t, r, http.MethodGet, "/api/v1/me/inventory?entity=region", tok, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d; body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestWallet_Invalid(t *testing.T) {
+	secret := "test-secret"
+	r := setupRouter(nil, secret)
+	tok, _, _ := token.Sign(secret, "00000000-0000-0000-0000-000000000000", "+82 10-0000-0000", time.Hour)
+	item := "33333333-3333-3333-3333-333333333333"
+	for _, tt := range []struct {
+		method, path string
+		body         any
+	}{
+		{http.MethodGet, "/api/v1/me/wallet?limit=0", nil},
+		{http.MethodGet, "/api/v1/me/wallet?before=x", nil},
+		{http.MethodPost, "/api/v1/me/wallet/purchases", map[string]any{"entity": "bg_item", "item_id": item}},
+		{http.MethodPost, "/api/v1/me/wallet/purchases", map[string]any{"request_id": "short", "entity": "bg_item", "item_id": item}},
+		{http.MethodPost, "/api/v1/me/wallet/purchases", map[string]any{"request_id": "req-00000001", "entity": "region", "item_id": item}},
+		{http.MethodPost, "/api/v1/me/wallet/purchases", map[string]any{"request_id": "req-00000001", "entity": "bg_item", "item_id": "x"}},
+	} {
+		if w := doJSON(t, r, tt.method, tt.path, tok, tt.body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s %s %v: expected 400, got %d; body=%s", tt.method, tt.path, tt.body, w.Code, w.Body.String())
+		}
+	}
+}
+
+// 구매: 재시도는 같은 거래, 잔액 부족/이미 보유/팔지 않는 항목은 거절. 산 배경은 아바타에 쓸 수 있다
+func TestWallet_Purchase(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	seed := seedMeta(t, pool)
+	ctx := context.Background()
+	var exists string
+	if err := pool.QueryRow(ctx, `SELECT COALESCE(to_regclass('public.wallet_txn')::text, '')`).Scan(&exists); err != nil || exists == "" {
+		t.Skip("skipping: table wallet_txn not found (run migrations first)")
+	}
+	var cheap, pricey string
+	for _, it := range []struct {
+		dst   *string
+		price int
+	}{{&cheap, 300}, {&pricey, 900}} {
+		if err := pool.QueryRow(ctx, `
+			INSERT INTO bg_item (name, locked, unlock_source, price) VALUES ($1, true, 'purchase', $2) RETURNING id::text`,
+			fmt.Sprintf("UT Shop %d", time.Now().UnixNano()), it.price).Scan(it.dst); err != nil {
+			t.Fatalf("seed bg: %v", err)
+		}
+	}
+
+	secret := "test-secret"
+	uid, _, tok := newUserAndToken(t, pool, secret, true)
+	t.Cleanup(func() {
+		ctx := context.Background()
+		_, _ = pool.Exec(ctx, `DELETE FROM user_avatar WHERE user_id=$1`, uid)
+		_, _ = pool.Exec(ctx, `DELETE FROM bg_item WHERE id = ANY($1::uuid[])`, []string{cheap, pricey})
+	})
+	if _, _, err := wallet.Grant(ctx, pool, uid, 500, "test", "", ""); err != nil {
+		t.Fatalf("grant: %v", err)
+	}
+	r := setupRouter(pool, secret)
+	const path = "/api/v1/me/wallet/purchases"
+	reqID := fmt.Sprintf("req-%d", time.Now().UnixNano())
+	body := map[string]any{"request_id": reqID, "entity": "bg_item", "item_id": cheap}
+	var first, again wallet.Txn
+	w := doJSON(t, r, http.MethodPost, path, tok, body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("purchase: expected 201, got %d; body=%s", w.Code, w.Body.String())
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &first)
+	w = doJSON(t, r, http.MethodPost, path, tok, body)
 	if w.Code != http.StatusOK {
+		t.Fatalf("retry: expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &again)
+	if first.ID != again.ID || first.Amount != -300 || first.BalanceAfter != 200 {
+		t.Fatalf("unexpected purchases %+v %+v", first, again)
+	}
+	// 대문자 uuid 로 재시도해도 같은 요청이다
+	w = doJSON(t, r, http.MethodPost, path, tok, map[string]any{"request_id": reqID, "entity": "bg_item", "item_id": strings.ToUpper(cheap)})
+	if w.Code != http.StatusOK {
+		t.Fatalf("uppercase retry: expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	// 관리자 지급의 request_id 는 구매와 따로다
+	if _, created, err := wallet.Grant(ctx, pool, uid, 100, "test", reqID, ""); err != nil || !created {
+		t.Fatalf("grant with a purchase request_id: created=%v err=%v", created, err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		body map[string]any
+		code int
+	}{
+		{"reused request_id", map[string]any{"request_id": reqID, "entity": "bg_item", "item_id": pricey}, http.StatusConflict},
+		{"already owned", map[string]any{"request_id": reqID + "-2", "entity": "bg_item", "item_id": cheap}, http.StatusConflict},
+		{"insufficient", map[string]any{"request_id": reqID + "-3", "entity": "bg_item", "item_id": pricey}, http.StatusPaymentRequired},
+		{"not for sale", map[string]any{"request_id": reqID + "-4", "entity": "bg_item", "item_id": seed.BgID}, http.StatusConflict},
+		{"unknown", map[string]any{"request_id": reqID + "-5", "entity": "bg_item", "item_id": "00000000-0000-0000-0000-000000000000"}, http.StatusNotFound},
+	} {
+		if w := doJSON(t, r, http.MethodPost, path, tok, tt.body); w.Code != tt.code {
+			t.Errorf("%s: expected %d, got %d; body=%s", tt.name, tt.code, w.Code, w.Body.String())
+		}
+	}
+
+	w = doJSON(t, r, http.MethodGet, "/api/v1/me/wallet", tok, nil)
+	var out struct {
+		Balance int64        `json:"balance"`
+		Items   []wallet.Txn `json:"items"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &out)
+	if out.Balance != 300 || len(out.Items) != 3 || out.Items[0].Kind != wallet.KindGrant || out.Items[1].Kind != wallet.KindPurchase {
+		t.Fatalf("unexpected wallet: %s", w.Body.String())
+	}
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/avatar", tok, map[string]any{"character_id": seed.CharacterID, "bg_id": cheap}); w.Code != http.StatusOK {
+		t.Fatalf("avatar with purchased bg: expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+}
+
+// 같은 request_id 로 동시에 들어온 구매는 한 번만 처리되고 둘 다 같은 거래를 받는다
+func TestWallet_Purchase_ConcurrentRetry(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	ctx := context.Background()
+	var exists string
+	if err := pool.QueryRow(ctx, `SELECT COALESCE(to_regclass('public.wallet_txn')::text, '')`).Scan(&exists); err != nil || exists == "" {
+		t.Skip("skipping: table wallet_txn not found (run migrations first)")
+	}
+	var item string
+	if err := pool.QueryRow(ctx, `
+		INSERT INTO bg_item (name, locked, unlock_source, price) VALUES ($1, true, 'purchase', 100) RETURNING id::text`,
+		fmt.Sprintf("UT Shop %d", time.Now().UnixNano())).Scan(&item); err != nil {
+		t.Fatalf("seed bg: %v", err)
+	}
+	t.Cleanup(func() { _, _ = pool.Exec(context.Background(), `DELETE FROM bg_item WHERE id=$1`, item) })
+
+	secret := "test-secret"
+	uid, _, tok := newUserAndToken(t, pool, secret, true)
+	if _, _, err := wallet.Grant(ctx, pool, uid, 500, "test", "", ""); err != nil {
+		t.Fatalf("grant: %v", err)
+	}
+	r := setupRouter(pool, secret)
+	body := map[string]any{"request_id": fmt.Sprintf("req-%d", time.Now().UnixNano()), "entity": "bg_item", "item_id": item}
+	res := make([]*httptest.ResponseRecorder, 2)
+	var wg sync.WaitGroup
+	for i := range res {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res[i] = doJSON(t, r, http.MethodPost, "/api/v1/me/wallet/purchases", tok, body)
+		}()
+	}
+	wg.Wait()
+
+	codes := map[int]int{}
+	ids := map[int64]bool{}
+	for _, w := range res {
+		codes[w.Code]++
+		var txn wallet.Txn
+		_ = json.Unmarshal(w.Body.Bytes(), &txn)
+		ids[txn.ID] = true
+	}
+	if codes[http.StatusCreated] != 1 || codes[http.StatusOK] != 1 || len(ids) != 1 {
+		t.Fatalf("expected one 201 and one 200 with the same txn: %s / %s", res[0].Body.String(), res[1].Body.String())
+	}
+	var balance int64
+	if err := pool.QueryRow(ctx, `SELECT balance FROM wallet_account WHERE user_id=$1`, uid).Scan(&balance); err != nil || balance != 400 {
+		t.Fatalf("expected balance 400, got %d (%v)", balance, err)
+	}
+}
+
+// 아바타 합성: 처음 요청에 그리고 다음부터는 캐시, 아바타를 바꾸면 이전 합성본은 지워진다
+func TestAvatarImage_RenderAndInvalidate(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	seed := seedMeta(t, pool)
+	other := seedMeta(t, pool)
+	ctx := context.Background()
+	var exists string
+	if err := pool.QueryRow(ctx, `SELECT COALESCE(to_regclass('public.avatar_render')::text, '')`).Scan(&exists); err != nil || exists == "" {
+		t.Skip("skipping: table avatar_render not found (run migrations first)")
+	}
+
+	store, err := storage.NewLocal(t.TempDir(), "http://media.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 미리보기 에셋을 저장소 객체로 연결(외부 URL 을 받지 않도록)
+	attach := func(table, id string, c color.Color) {
+		t.Helper()
+		var buf bytes.Buffer
+		img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+		draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+		_ = png.Encode(&buf, img)
+		key := fmt.Sprintf("catalog/ut-%s-%d.png", table, time.Now().UnixNano())
+		if err := store.Put(ctx, key, bytes.NewReader(buf.Bytes()), int64(buf.Len()), "image/png"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pool.Exec(ctx, `UPDATE media_asset SET storage_key=$2 WHERE id=(SELECT preview_asset FROM `+table+` WHERE id=$1)`, id, key); err != nil {
+			t.Fatalf("attach preview: %v", err)
+		}
+		t.Cleanup(func() {
+			_, _ = pool.Exec(context.Background(), `UPDATE media_asset SET storage_key=NULL WHERE storage_key=$1`, key)
+		})
+	}
+	attach("character_item", seed.CharacterID, color.NRGBA{255, 0, 0, 255})
+	attach("character_item", other.CharacterID, color.NRGBA{0, 255, 0, 255})
+	attach("bg_item", seed.BgID, color.NRGBA{0, 0, 255, 255})
+
+	secret := "test-secret"
+	uid, _, tok := newUserAndToken(t, pool, secret, true)
+	_, _, viewer := newUserAndToken(t, pool, secret, true)
+	r := setupRouterWith(pool, secret, avatar.NewRenderer(pool, store))
+	get := func(path, bearer string) avatar.Image {
+		t.Helper()
+		w := doJSON(t, r, http.MethodGet, path, bearer, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d; body=%s", path, w.Code, w.Body.String())
+		}
+		var img avatar.Image
+		_ = json.Unmarshal(w.Body.Bytes(), &img)
+		return img
+	}
+
+	if w := doJSON(t, r, http.MethodGet, "/api/v1/me/avatar/image", tok, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 before avatar is set, got %d; body=%s", w.Code, w.Body.String())
+	}
+	body := map[string]any{"character_id": seed.CharacterID, "bg_id": seed.BgID}
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/avatar", tok, body); w.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
 	}
 
+	first := get("/api/v1/me/avatar/image?size=128", tok)
+	if first.Width != 128 || first.ContentType != "image/png" || !strings.HasPrefix(first.URL, "http://media.test/users/"+uid+"/avatar/") {
+		t.Fatalf("unexpected image: %+v", first)
+	}
+	rc, err := store.Get(ctx, strings.TrimPrefix(first.URL, "http://media.test/"))
+	if err != nil {
+		t.Fatalf("rendered object: %v", err)
+	}
+	decoded, err := png.Decode(rc)
+	rc.Close()
+	if err != nil || decoded.Bounds().Dx() != 128 {
+		t.Fatalf("decode rendered image: %v", err)
+	}
+	if red, _, _, _ := decoded.At(64, 64).RGBA(); red>>8 != 255 {
+		t.Fatalf("expected character in the middle, got %v", decoded.At(64, 64))
+	}
+	if again := get("/api/v1/me/avatar/image?size=128", tok); again.AssetID != first.AssetID {
+		t.Fatalf("expected cached render %s, got %s", first.AssetID, again.AssetID)
+	}
+	if webp := get("/api/v1/me/avatar/image?size=128&format=webp", tok); webp.ContentType != "image/webp" || webp.AssetID == first.AssetID {
+		t.Fatalf("unexpected webp render: %+v", webp)
+	}
+
+	body["character_id"] = other.CharacterID
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/avatar", tok, body); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	var left int
+	_ = pool.QueryRow(ctx, `SELECT count(*) FROM media_asset WHERE id=$1`, first.AssetID).Scan(&left)
+	if left != 0 {
+		t.Fatal("expected previous render to be dropped on avatar change")
+	}
+	changed := get("/api/v1/users/"+uid+"/avatar/image?size=128", viewer)
+	if changed.AssetID == first.AssetID {
+		t.Fatal("expected a new render after avatar change")
+	}
+}
+
+func TestAvatarImage_Invalid(t *testing.T) {
+	secret := "test-secret"
+	r := setupRouter(nil, secret)
+	tok, _, _ := token.Sign(secret, "00000000-0000-0000-0000-000000000000", "+82 10-0000-0000", time.Hour)
+	for _, path := range []string{
+		"/api/v1/me/avatar/image?size=100",
+		"/api/v1/me/avatar/image?format=gif",
+		"/api/v1/users/not-a-uuid/avatar/image",
+	} {
+		if w := doJSON(t, r, http.MethodGet, path, tok, nil); w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d; body=%s", path, w.Code, w.Body.String())
+		}
+	}
+}
+
+// 내 프로필 조회: 설정한 값 + 사진 파생 이미지 포함
+func TestProfile_Get_OK(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	seed := seedMeta(t, pool)
+
+	secret := "test-secret"
+	uid, _, tok := newUserAndToken(t, pool, secret, true)
+	r := setupRouter(pool, secret)
+
+	if w := doJSON(t, r, http.MethodPatch, "/api/v1/me/region", tok, map[string]any{"region_id": seed.RegionID}); w.Code != http.StatusOK {
+		t.Fatalf("set region: %d %s", w.Code, w.Body.String())
+	}
+
+	// 사진 + 파생 이미지 직접 시드(파이프라인 처리 결과 가정)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	var assetID string
+	url := fmt.Sprintf("https://example.com/ut-photo-%d.jpg", time.Now().UnixNano())
+	if err := pool.QueryRow(ctx, `INSERT INTO media_asset (kind, url) VALUES ('image',$1) RETURNING id`, url).Scan(&assetID); err != nil {
+		t.Fatalf("seed asset: %v", err)
+	}
+	t.Cleanup(func() { _, _ = pool.Exec(context.Background(), `DELETE FROM media_asset WHERE id=$1`, assetID) })
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO media_asset_variant (asset_id, name, storage_key, url, content_type, width, height, size_bytes)
+		VALUES ($1, 'thumb', 'variants/x/thumb.jpg', 'https://example.com/thumb.jpg', 'image/jpeg', 160, 160, 1000)`, assetID); err != nil {
+		t.Fatalf("seed variant: %v", err)
+	}
+	if _, err := pool.Exec(ctx, `UPDATE user_profile SET profile_image_id=$2 WHERE user_id=$1`, uid, assetID); err != nil {
+		t.Fatalf("set photo: %v", err)
+	}
+
+	w := doJSON(t, r, http.MethodGet, "/api/v1/me/profile", tok, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
 	var out struct {
-		OK      bool   `json:"ok"`
-		AssetID string `json:"asset_id"`
+		ID     string `json:"id"`
+		Region *struct {
+			ID int `json:"id"`
+		} `json:"region"`
+		Photo *struct {
+			AssetID  string `json:"asset_id"`
+			Variants map[string]struct {
+				URL   string `json:"url"`
+				Width int    `json:"width"`
+			} `json:"variants"`
+		} `json:"photo"`
 	}
 	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
 		t.Fatalf("invalid json: %v", err)
 	}
-	if !out.OK || out.AssetID == "" {
-		t.Fatalf("unexpected response: %+v", out)
+	if out.ID != uid || out.Region == nil || out.Region.ID != seed.RegionID {
+		t.Fatalf("unexpected profile: %s", w.Body.String())
+	}
+	if out.Photo == nil || out.Photo.AssetID != assetID || out.Photo.Variants["thumb"].Width != 160 {
+		t.Fatalf("photo variants missing: %s", w.Body.String())
 	}
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
-	var pid *string
-	if err := pool.QueryRow(ctx, `SELECT profile_image_id::text FROM user_profile WHERE user_id=$1`, uid).Scan(&pid); err != nil {
-		t.Fatalf("query profile_image_id: %v", err)
+// 잘못된 공개 범위 값은 400 (DB 접근 전 거절)
+func TestPrivacy_Put_Invalid(t *testing.T) {
+	secret := "test-secret"
+	r := setupRouter(nil, secret)
+	tok, _, _ := token.Sign(secret, "00000000-0000-0000-0000-000000000000", "+82 10-0000-0000", time.Hour)
+
+	w := doJSON(t, r, http.MethodPut, "/api/v1/me/privacy", tok, map[string]any{"region": "public"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d; body=%s", w.Code, w.Body.String())
+	}
+}
+
+// 타인 조회 시 공개 범위 적용: nobody 는 숨김, friends 는 친구에게만, 본인은 전부
+func TestPrivacy_OtherUserProfile(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	for _, tbl := range []string{"public.user_privacy", "public.user_friend", "public.user_friend_request"} {
+		var exists string
+		if err := pool.QueryRow(context.Background(), `SELECT COALESCE(to_regclass($1)::text, '')`, tbl).Scan(&exists); err != nil || exists == "" {
+			t.Skipf("skipping: table %s not found (run migrations first)", tbl)
+		}
+	}
+	seed := seedMeta(t, pool)
+
+	secret := "test-secret"
+	owner, _, ownerTok := newUserAndToken(t, pool, secret, true)
+	friend, _, friendTok := newUserAndToken(t, pool, secret, true)
+	_, _, strangerTok := newUserAndToken(t, pool, secret, true)
+	r := setupRouter(pool, secret)
+
+	if w := doJSON(t, r, http.MethodPatch, "/api/v1/me/region", ownerTok, map[string]any{"region_id": seed.RegionID}); w.Code != http.StatusOK {
+		t.Fatalf("set region: %d %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(t, r, http.MethodPatch, "/api/v1/me/birthdate", ownerTok, map[string]any{"birthdate": "1995-05-05"}); w.Code != http.StatusOK {
+		t.Fatalf("set birthdate: %d %s", w.Code, w.Body.String())
+	}
+
+	w := doJSON(t, r, http.MethodGet, "/api/v1/me/privacy", ownerTok, nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"birthdate":"friends"`) {
+		t.Fatalf("unexpected defaults: %d %s", w.Code, w.Body.String())
+	}
+	w = doJSON(t, r, http.MethodPut, "/api/v1/me/privacy", ownerTok, map[string]any{"region": "nobody"})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"region":"nobody"`) || !strings.Contains(w.Body.String(), `"job":"everyone"`) {
+		t.Fatalf("unexpected settings: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/friends/"+owner, friendTok, nil); w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"requested"`) {
+		t.Fatalf("friend request: %d %s", w.Code, w.Body.String())
+	}
+
+	type view struct {
+		Birthdate *string         `json:"birthdate"`
+		AgeRange  *string         `json:"age_range"`
+		Region    json.RawMessage `json:"region"`
+	}
+	get := func(tok string) view {
+		t.Helper()
+		w := doJSON(t, r, http.MethodGet, "/api/v1/users/"+owner+"/profile", tok, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+		}
+		var v view
+		_ = json.Unmarshal(w.Body.Bytes(), &v)
+		return v
+	}
+
+	if v := get(strangerTok); v.Birthdate != nil || v.AgeRange != nil || string(v.Region) != "null" {
+		t.Fatalf("stranger sees hidden fields: %+v", v)
+	}
+	// 수락 전에는 아직 친구가 아니다
+	if v := get(friendTok); v.AgeRange != nil {
+		t.Fatalf("pending request must not unlock friends fields: %+v", v)
+	}
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/friends/"+friend, ownerTok, nil); w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"friends"`) {
+		t.Fatalf("accept: %d %s", w.Code, w.Body.String())
+	}
+	// 친구에게도 생년월일 대신 나이대만
+	if v := get(friendTok); v.Birthdate != nil || v.AgeRange == nil || string(v.Region) != "null" {
+		t.Fatalf("friend view wrong: birthdate=%v age_range=%v region=%s", v.Birthdate, v.AgeRange, v.Region)
+	}
+	if v := get(ownerTok); v.Birthdate == nil || string(v.Region) == "null" {
+		t.Fatalf("owner must see everything: birthdate=%v region=%s", v.Birthdate, v.Region)
+	}
+	// 친구를 끊으면 다시 타인
+	if w := doJSON(t, r, http.MethodDelete, "/api/v1/me/friends/"+owner, friendTok, nil); w.Code != http.StatusOK {
+		t.Fatalf("unfriend: %d %s", w.Code, w.Body.String())
+	}
+	if v := get(friendTok); v.AgeRange != nil {
+		t.Fatalf("ex-friend still sees friends fields: %+v", v)
+	}
+}
+
+// 친구 신청 목록, 자기 자신/없는 사용자, 신청 중복은 200 + 현재 상태
+func TestFriends_Requests(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	var exists string
+	_ = pool.QueryRow(context.Background(), `SELECT COALESCE(to_regclass('public.user_friend_request')::text, '')`).Scan(&exists)
+	if exists == "" {
+		t.Skip("skipping: table user_friend_request not found (run migrations first)")
+	}
+
+	secret := "test-secret"
+	a, _, tokA := newUserAndToken(t, pool, secret, true)
+	b, _, tokB := newUserAndToken(t, pool, secret, true)
+	r := setupRouter(pool, secret)
+
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/friends/"+a, tokA, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("self: expected 400, got %d; body=%s", w.Code, w.Body.String())
+	}
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/friends/00000000-0000-0000-0000-000000000000", tokA, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown: expected 404, got %d; body=%s", w.Code, w.Body.String())
+	}
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/friends/"+b, tokA, nil); w.Code != http.StatusCreated {
+		t.Fatalf("request: expected 201, got %d; body=%s", w.Code, w.Body.String())
+	}
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/friends/"+b, tokA, nil); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"requested"`) {
+		t.Fatalf("repeat: expected 200 requested, got %d; body=%s", w.Code, w.Body.String())
+	}
+
+	w := doJSON(t, r, http.MethodGet, "/api/v1/me/friends/requests", tokB, nil)
+	var reqs struct {
+		Incoming []struct {
+			UserID string `json:"user_id"`
+		} `json:"incoming"`
+		Outgoing []struct {
+			UserID string `json:"user_id"`
+		} `json:"outgoing"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &reqs)
+	if w.Code != http.StatusOK || len(reqs.Incoming) != 1 || reqs.Incoming[0].UserID != a || len(reqs.Outgoing) != 0 {
+		t.Fatalf("unexpected requests: %d %s", w.Code, w.Body.String())
+	}
+
+	// 거절하면 신청이 사라지고, 한 번 더 지우면 404
+	if w := doJSON(t, r, http.MethodDelete, "/api/v1/me/friends/"+a, tokB, nil); w.Code != http.StatusOK {
+		t.Fatalf("decline: expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	if w := doJSON(t, r, http.MethodDelete, "/api/v1/me/friends/"+a, tokB, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("decline again: expected 404, got %d; body=%s", w.Code, w.Body.String())
+	}
+	w = doJSON(t, r, http.MethodGet, "/api/v1/me/friends", tokA, nil)
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), b) {
+		t.Fatalf("unexpected friends: %d %s", w.Code, w.Body.String())
+	}
+}
+
+// 최소 나이 미만은 400 (DB 접근 전 거절)
+func TestBirthdate_UnderAge(t *testing.T) {
+	secret := "test-secret"
+	r := setupRouter(nil, secret)
+	tok, _, _ := token.Sign(secret, "00000000-0000-0000-0000-000000000000", "+82 10-0000-0000", time.Hour)
+
+	bd := time.Now().AddDate(-9, 0, 0).Format("2006-01-02")
+	w := doJSON(t, r, http.MethodPatch, "/api/v1/me/birthdate", tok, map[string]any{"birthdate": bd})
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "under_age") {
+		t.Fatalf("expected 400 under_age, got %d; body=%s", w.Code, w.Body.String())
+	}
+}
+
+// 처음 설정한 뒤엔 다른 값으로 바꿀 수 없다(같은 값 재전송은 허용)
+func TestBirthdate_Locked(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+
+	secret := "test-secret"
+	_, _, tok := newUserAndToken(t, pool, secret, true)
+	r := setupRouter(pool, secret)
+
+	if w := doJSON(t, r, http.MethodPatch, "/api/v1/me/birthdate", tok, map[string]any{"birthdate": "1990-01-02"}); w.Code != http.StatusOK {
+		t.Fatalf("first set: %d %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(t, r, http.MethodPatch, "/api/v1/me/birthdate", tok, map[string]any{"birthdate": "1990-01-02"}); w.Code != http.StatusOK {
+		t.Fatalf("same value: %d %s", w.Code, w.Body.String())
+	}
+	w := doJSON(t, r, http.MethodPatch, "/api/v1/me/birthdate", tok, map[string]any{"birthdate": "1985-01-02"})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d; body=%s", w.Code, w.Body.String())
+	}
+
+	w = doJSON(t, r, http.MethodGet, "/api/v1/me/profile", tok, nil)
+	var out struct {
+		Birthdate string `json:"birthdate"`
+		Age       int    `json:"age"`
+		AgeRange  string `json:"age_range"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &out)
+	if out.Birthdate != "1990-01-02" || out.Age < 30 || out.AgeRange == "" {
+		t.Fatalf("unexpected profile: %s", w.Body.String())
+	}
+}
+
+// 자기소개/답변 검증은 DB 접근 전에 거절
+func TestPrompts_Validation(t *testing.T) {
+	secret := "test-secret"
+	r := setupRouter(nil, secret)
+	tok, _, _ := token.Sign(secret, "00000000-0000-0000-0000-000000000000", "+82 10-0000-0000", time.Hour)
+
+	if w := doJSON(t, r, http.MethodPatch, "/api/v1/me/bio", tok, map[string]any{"bio": strings.Repeat("가", 501)}); w.Code != http.StatusBadRequest {
+		t.Fatalf("long bio: expected 400, got %d; body=%s", w.Code, w.Body.String())
+	}
+	if w := doJSON(t, r, http.MethodPatch, "/api/v1/me/bio", tok, map[string]any{"bio": "안녕 병신아"}); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "profanity") {
+		t.Fatalf("profane bio: expected 400 profanity, got %d; body=%s", w.Code, w.Body.String())
+	}
+
+	four := []map[string]any{}
+	for _, code := range []string{"weekend", "perfect_day", "looking_for", "fun_fact"} {
+		four = append(four, map[string]any{"code": code, "answer": "hi"})
+	}
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/profile/prompts", tok, map[string]any{"items": four}); w.Code != http.StatusBadRequest {
+		t.Fatalf("too many: expected 400, got %d; body=%s", w.Code, w.Body.String())
+	}
+	dup := []map[string]any{{"code": "weekend", "answer": "a"}, {"code": "weekend", "answer": "b"}}
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/profile/prompts", tok, map[string]any{"items": dup}); w.Code != http.StatusBadRequest {
+		t.Fatalf("duplicate: expected 400, got %d; body=%s", w.Code, w.Body.String())
+	}
+	bad := []map[string]any{{"code": "weekend", "answer": "f u c k"}}
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/profile/prompts", tok, map[string]any{"items": bad}); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "items[0].answer") {
+		t.Fatalf("profane answer: expected 400, got %d; body=%s", w.Code, w.Body.String())
+	}
+}
+
+// 답변 저장(순서 유지) → 프로필 조회에 포함, 없는 질문은 400
+func TestPrompts_Put_OK(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	var exists string
+	_ = pool.QueryRow(context.Background(), `SELECT COALESCE(to_regclass('public.user_prompt')::text, '')`).Scan(&exists)
+	if exists == "" {
+		t.Skip("skipping: table user_prompt not found (run migrations first)")
+	}
+
+	secret := "test-secret"
+	_, _, tok := newUserAndToken(t, pool, secret, true)
+	r := setupRouter(pool, secret)
+
+	if w := doJSON(t, r, http.MethodPatch, "/api/v1/me/bio", tok, map[string]any{"bio": "  Coffee and hiking  "}); w.Code != http.StatusOK {
+		t.Fatalf("bio: %d %s", w.Code, w.Body.String())
+	}
+	items := []map[string]any{{"code": "travel", "answer": "Jeju"}, {"code": "weekend", "answer": "Hiking"}}
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/profile/prompts", tok, map[string]any{"items": items}); w.Code != http.StatusOK {
+		t.Fatalf("prompts: %d %s", w.Code, w.Body.String())
+	}
+	unknown := []map[string]any{{"code": "no-such-prompt", "answer": "x"}}
+	if w := doJSON(t, r, http.MethodPut, "/api/v1/me/profile/prompts", tok, map[string]any{"items": unknown}); w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown prompt: expected 400, got %d; body=%s", w.Code, w.Body.String())
+	}
+
+	w := doJSON(t, r, http.MethodGet, "/api/v1/me/profile", tok, nil)
+	var out struct {
+		Bio     string           `json:"bio"`
+		Prompts []profile.Prompt `json:"prompts"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &out)
+	if out.Bio != "Coffee and hiking" {
+		t.Fatalf("bio not trimmed/saved: %s", w.Body.String())
+	}
+	if len(out.Prompts) != 2 || out.Prompts[0].Code != "travel" || out.Prompts[1].Answer != "Hiking" || out.Prompts[0].Text == "" {
+		t.Fatalf("unexpected prompts: %s", w.Body.String())
+	}
+}
+
+// 위도/경도 누락·범위 밖은 DB 접근 전에 400
+func TestLocation_Invalid(t *testing.T) {
+	secret := "test-secret"
+	r := setupRouter(nil, secret)
+	tok, _, _ := token.Sign(secret, "00000000-0000-0000-0000-000000000000", "+82 10-0000-0000", time.Hour)
+
+	for _, body := range []map[string]any{
+		{"latitude": 37.5},
+		{"latitude": 91, "longitude": 127},
+		{"latitude": 37.5, "longitude": -181},
+	} {
+		if w := doJSON(t, r, http.MethodPut, "/api/v1/me/location", tok, body); w.Code != http.StatusBadRequest {
+			t.Fatalf("%v: expected 400, got %d; body=%s", body, w.Code, w.Body.String())
+		}
+	}
+}
+
+// 좌표는 소수 2자리로 저장되고 지오해시·가까운 지역이 채워진다
+func TestLocation_Put_OK(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	var exists string
+	_ = pool.QueryRow(context.Background(), `SELECT COALESCE(to_regclass('public.user_location')::text, '')`).Scan(&exists)
+	if exists == "" {
+		t.Skip("skipping: table user_location not found (run migrations first)")
+	}
+	seed := seedMeta(t, pool) // Testland (0,0)
+
+	secret := "test-secret"
+	_, _, tok := newUserAndToken(t, pool, secret, true)
+	r := setupRouter(pool, secret)
+
+	if w := doJSON(t, r, http.MethodGet, "/api/v1/me/location", tok, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("before put: expected 404, got %d", w.Code)
+	}
+	w := doJSON(t, r, http.MethodPut, "/api/v1/me/location", tok, map[string]any{"latitude": 0.012345, "longitude": 0.016789})
+	if w.Code != http.StatusOK {
+		t.Fatalf("put: %d %s", w.Code, w.Body.String())
+	}
+	var got struct {
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+		Geohash   string  `json:"geohash"`
+		Stale     bool    `json:"stale"`
+		Region    *struct {
+			ID int `json:"id"`
+		} `json:"region"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &got)
+	if got.Latitude != 0.01 || got.Longitude != 0.02 {
+		t.Fatalf("coordinates not coarsened: %+v", got)
+	}
+	if len(got.Geohash) != profile.LocationPrecision || got.Stale {
+		t.Fatalf("unexpected geohash/stale: %+v", got)
+	}
+	if got.Region == nil || got.Region.ID != seed.RegionID {
+		t.Fatalf("nearest region: want %d, got %+v", seed.RegionID, got.Region)
+	}
+
+	if w := doJSON(t, r, http.MethodDelete, "/api/v1/me/location", tok, nil); w.Code != http.StatusOK {
+		t.Fatalf("delete: %d", w.Code)
+	}
+	if w := doJSON(t, r, http.MethodGet, "/api/v1/me/location", tok, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("after delete: expected 404, got %d", w.Code)
+	}
+}
+
+// 하위 지역이 있으면 400, 최하위 지역은 저장되고 프로필에 전체 경로가 나온다
+func TestRegion_Leaf_FullPath(t *testing.T) {
+	pool := newTestPool(t)
+	ensureSchema(t, pool)
+	ctx := context.Background()
+
+	var province, district, town int
+	if err := pool.QueryRow(ctx, `INSERT INTO region (code, name, level) VALUES ('UT90000000','UT특별시',1) RETURNING id`).Scan(&province); err != nil {
+		t.Skipf("skipping: region hierarchy not migrated: %v", err)
+	}
+	t.Cleanup(func() { _, _ = pool.Exec(context.Background(), `DELETE FROM region WHERE id=$1`, province) })
+	_ = pool.QueryRow(ctx, `INSERT INTO region (code, name, parent_id, level) VALUES ('UT90100000','UT구',$1,2) RETURNING id`, province).Scan(&district)
+	_ = pool.QueryRow(ctx, `INSERT INTO region (code, name, parent_id, level) VALUES ('UT90101000','UT동',$1,3) RETURNING id`, district).Scan(&town)
+
+	secret := "test-secret"
+	_, _, tok := newUserAndToken(t, pool, secret, true)
+	r := setupRouter(pool, secret)
+
+	if w := doJSON(t, r, http.MethodPatch, "/api/v1/me/region", tok, map[string]any{"region_id": district}); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "not_leaf") {
+		t.Fatalf("district: expected 400 not_leaf, got %d; body=%s", w.Code, w.Body.String())
+	}
+	if w := doJSON(t, r, http.MethodPatch, "/api/v1/me/region", tok, map[string]any{"region_id": 2147483647}); w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown: expected 400, got %d", w.Code)
+	}
+	if w := doJSON(t, r, http.MethodPatch, "/api/v1/me/region", tok, map[string]any{"region_id": town}); w.Code != http.StatusOK {
+		t.Fatalf("town: %d %s", w.Code, w.Body.String())
+	}
+
+	w := doJSON(t, r, http.MethodGet, "/api/v1/me/profile", tok, nil)
+	var got struct {
+		Region struct {
+			ID       int    `json:"id"`
+			Level    int    `json:"level"`
+			FullName string `json:"full_name"`
+			Path     []struct {
+				ID int `json:"id"`
+			} `json:"path"`
+		} `json:"region"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &got)
+	if got.Region.ID != town || got.Region.Level != 3 || got.Region.FullName != "UT특별시 UT구 UT동" {
+		t.Fatalf("unexpected region: %+v", got.Region)
 	}
-	if pid == nil || *pid != out.AssetID {
-		t.Fatalf("profile_image_id mismatch: want=%s got=%v", out.AssetID, pid)
+	if len(got.Region.Path) != 3 || got.Region.Path[0].ID != province || got.Region.Path[2].ID != town {
+		t.Fatalf("unexpected path: %+v", got.Region.Path)
 	}
 }
@@ -0,0 +1,197 @@
+package profile
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+	"github.com/creators-of-happiness/amigo-backend/internal/history"
+	"github.com/creators-of-happiness/amigo-backend/internal/moderation"
+)
+
+const (
+	MaxBioLength = 500 // chk_user_profile_bio 와 같아야 함
+	MaxPrompts   = 3
+)
+
+type Prompt struct {
+	Code   string `json:"code"`
+	Text   string `json:"text"`
+	Answer string `json:"answer"`
+}
+
+func registerPrompts(me *gin.RouterGroup, pool *pgxpool.Pool, filter *moderation.Filter) {
+	// 자기소개. 빈 문자열이면 지운다
+	me.PATCH("/bio", func(c *gin.Context) {
+		uid := c.GetString("uid")
+		var in struct {
+			Bio *string `json:"bio" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		bio := strings.TrimSpace(*in.Bio)
+		if utf8.RuneCountInString(bio) > MaxBioLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("bio must be at most %d characters", MaxBioLength)})
+			return
+		}
+		if err := filter.Check("bio", bio); err != nil {
+			c.JSON(http.StatusBadRequest, err)
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+		defer cancel()
+		_, err := history.Exec(ctx, pool, self(uid), `
+			INSERT INTO user_profile (user_id, bio, created_at, updated_at)
+			VALUES ($1, NULLIF($2,''), now(), now())
+			ON CONFLICT (user_id) DO UPDATE SET bio=EXCLUDED.bio, updated_at=now()`, uid, bio)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	me.GET("/profile/prompts", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+		defer cancel()
+		items, err := loadPrompts(ctx, pool, c.GetString("uid"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items})
+	})
+
+	// 답변 목록 전체 교체. 배열 순서가 표시 순서
+	me.PUT("/profile/prompts", func(c *gin.Context) {
+		uid := c.GetString("uid")
+		var in struct {
+			Items []struct {
+				Code   string `json:"code" binding:"required"`
+				Answer string `json:"answer" binding:"required"`
+			} `json:"items" binding:"dive"`
+		}
+		if err := c.ShouldBindJSON(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if len(in.Items) > MaxPrompts {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d prompts", MaxPrompts)})
+			return
+		}
+		seen := map[string]bool{}
+		for i := range in.Items {
+			it := &in.Items[i]
+			it.Answer = strings.TrimSpace(it.Answer)
+			if seen[it.Code] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "duplicate prompt: " + it.Code})
+				return
+			}
+			seen[it.Code] = true
+			if it.Answer == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("items[%d].answer is empty", i)})
+				return
+			}
+			if err := filter.Check(fmt.Sprintf("items[%d].answer", i), it.Answer); err != nil {
+				c.JSON(http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		// 질문별 길이 제한. 사용 중단된 질문은 이미 답한 경우에만 유지할 수 있다
+		limits := map[string]int{}
+		rows, err := pool.Query(ctx, `
+			SELECT code, max_length FROM profile_prompt
+			WHERE active OR code IN (SELECT prompt_code FROM user_prompt WHERE user_id=$1)`, uid)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for rows.Next() {
+			var code string
+			var n int
+			if err = rows.Scan(&code, &n); err != nil {
+				break
+			}
+			limits[code] = n
+		}
+		rows.Close()
+		if err == nil {
+			err = rows.Err()
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for i, it := range in.Items {
+			limit, ok := limits[it.Code]
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "unknown prompt: " + it.Code})
+				return
+			}
+			if utf8.RuneCountInString(it.Answer) > limit {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("items[%d].answer must be at most %d characters", i, limit)})
+				return
+			}
+		}
+
+		err = pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, `DELETE FROM user_prompt WHERE user_id=$1`, uid); err != nil {
+				return err
+			}
+			for i, it := range in.Items {
+				if _, err := tx.Exec(ctx, `
+					INSERT INTO user_prompt (user_id, prompt_code, answer, position)
+					VALUES ($1, $2, $3, $4)`, uid, it.Code, it.Answer, i); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		items, err := loadPrompts(ctx, pool, uid)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items})
+	})
+}
+
+// 표시 순서대로 질문+답변
+func loadPrompts(ctx context.Context, q db.Querier, uid string) ([]Prompt, error) {
+	rows, err := q.Query(ctx, `
+		SELECT p.code, p.text, up.answer
+		FROM user_prompt up
+		JOIN profile_prompt p ON p.code = up.prompt_code
+		WHERE up.user_id=$1
+		ORDER BY up.position`, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Prompt{}
+	for rows.Next() {
+		var p Prompt
+		if err := rows.Scan(&p.Code, &p.Text, &p.Answer); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
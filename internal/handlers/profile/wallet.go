@@ -0,0 +1,76 @@
+package profile
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/inventory"
+	"github.com/creators-of-happiness/amigo-backend/internal/wallet"
+)
+
+func registerWallet(me *gin.RouterGroup, pool *pgxpool.Pool) {
+	// 잔액과 최근 거래(최신순, before=이전 페이지 마지막 id)
+	me.GET("/wallet", func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 || limit > 200 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be 1..200"})
+			return
+		}
+		before, err := strconv.ParseInt(c.DefaultQuery("before", "0"), 10, 64)
+		if err != nil || before < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid before"})
+			return
+		}
+		uid := c.GetString("uid")
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		balance, err := wallet.Balance(ctx, pool, uid)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		items, err := wallet.List(ctx, pool, uid, before, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"balance": balance, "items": items})
+	})
+
+	// 코인으로 항목 구매. request_id 는 클라이언트가 구매 시도마다 만드는 값이고,
+	// 재시도에 같은 값을 보내면 한 번만 처리되어 처음 결과(200)를 돌려받는다
+	me.POST("/wallet/purchases", func(c *gin.Context) {
+		var in struct {
+			RequestID string `json:"request_id" binding:"required,min=8,max=100,printascii"`
+			Entity    string `json:"entity" binding:"required,oneof=character_item bg_item avatar_part"`
+			ItemID    string `json:"item_id" binding:"required,uuid"`
+		}
+		if err := c.ShouldBindJSON(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+		defer cancel()
+		t, created, err := wallet.Purchase(ctx, pool, c.GetString("uid"), in.RequestID, in.Entity, in.ItemID)
+		switch {
+		case errors.Is(err, inventory.ErrUnknownItem):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, wallet.ErrInsufficientFunds):
+			c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+		case errors.Is(err, wallet.ErrNotForSale), errors.Is(err, wallet.ErrAlreadyOwned), errors.Is(err, wallet.ErrRequestReused):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		case created:
+			c.JSON(http.StatusCreated, t)
+		default:
+			c.JSON(http.StatusOK, t)
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+)
+
+// profile_history.source
+const (
+	SourceUser       = "user"       // 본인 API 호출
+	SourceAuth       = "auth"       // 가입/로그인(본인 인증 중 채운 값)
+	SourceAdmin      = "admin"      // 운영/고객지원
+	SourceClassifier = "classifier" // 자동 분류기(얼굴 심사 등)
+	SourceSystem     = "system"     // 설정 없이 바뀐 경우(마이그레이션, 배치 등)
+)
+
+// 이력에 기록될 변경 주체. ID 가 비면 actor_id 는 NULL
+type Actor struct {
+	ID     string
+	Source string
+}
+
+// 현재 트랜잭션의 변경 주체 설정(트랜잭션이 끝나면 사라짐)
+func SetActor(ctx context.Context, q db.Querier, a Actor) error {
+	_, err := q.Exec(ctx, `SELECT set_config('amigo.actor_id', $1, true), set_config('amigo.source', $2, true)`, a.ID, a.Source)
+	return err
+}
+
+// 단일 쓰기 문장을 변경 주체와 함께 한 트랜잭션으로 실행
+func Exec(ctx context.Context, pool *pgxpool.Pool, a Actor, sql string, args ...any) (pgconn.CommandTag, error) {
+	var ct pgconn.CommandTag
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		if err := SetActor(ctx, tx, a); err != nil {
+			return err
+		}
+		var err error
+		ct, err = tx.Exec(ctx, sql, args...)
+		return err
+	})
+	return ct, err
+}
+
+type Entry struct {
+	ID        int64           `json:"id"`
+	Table     string          `json:"table"`
+	Op        string          `json:"op"`
+	Field     string          `json:"field"`
+	OldValue  json.RawMessage `json:"old_value"`
+	NewValue  json.RawMessage `json:"new_value"`
+	ActorID   *string         `json:"actor_id"`
+	Source    string          `json:"source"`
+	ChangedAt time.Time       `json:"changed_at"`
+}
+
+// 최신순 이력. beforeID>0 이면 그보다 오래된 것만(페이지 넘김)
+func List(ctx context.Context, q db.Querier, uid string, beforeID int64, limit int) ([]Entry, error) {
+	rows, err := q.Query(ctx, `
+		SELECT id, table_name, op, field, old_value, new_value, actor_id::text, source, changed_at
+		FROM profile_history
+		WHERE user_id=$1 AND ($2 = 0 OR id < $2)
+		ORDER BY id DESC LIMIT $3`, uid, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Entry{}
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.ID, &e.Table, &e.Op, &e.Field, &e.OldValue, &e.NewValue, &e.ActorID, &e.Source, &e.ChangedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// 테이블별 컬럼 값. 그 시점에 행이 없었으면 테이블 키가 없다
+type Snapshot map[string]map[string]any
+
+var tracked = []string{"app_users", "user_profile", "user_job", "user_avatar"}
+
+// 현재 행에서 at 이후의 변경을 역순으로 되돌려 그 시점의 프로필을 복원
+func AsOf(ctx context.Context, q db.Querier, uid string, at time.Time) (Snapshot, error) {
+	var raw []byte
+	err := q.QueryRow(ctx, `
+		SELECT jsonb_build_object(
+		  'app_users',    (SELECT to_jsonb(t) FROM app_users t WHERE id=$1),
+		  'user_profile', (SELECT to_jsonb(t) FROM user_profile t WHERE user_id=$1),
+		  'user_job',     (SELECT to_jsonb(t) FROM user_job t WHERE user_id=$1),
+		  'user_avatar',  (SELECT to_jsonb(t) FROM user_avatar t WHERE user_id=$1))`, uid).Scan(&raw)
+	if err != nil {
+		return nil, err
+	}
+	var cur map[string]map[string]any
+	if err := json.Unmarshal(raw, &cur); err != nil {
+		return nil, err
+	}
+	snap := Snapshot{}
+	for _, t := range tracked {
+		if cur[t] != nil {
+			snap[t] = cur[t]
+		}
+	}
+
+	rows, err := q.Query(ctx, `
+		SELECT table_name, op, field, old_value
+		FROM profile_history
+		WHERE user_id=$1 AND changed_at > $2
+		ORDER BY id DESC`, uid, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table, op, field string
+		var old json.RawMessage
+		if err := rows.Scan(&table, &op, &field, &old); err != nil {
+			return nil, err
+		}
+		// at 이후에 만들어진 행은 그 시점엔 없었다
+		if op == "insert" {
+			delete(snap, table)
+			continue
+		}
+		if snap[table] == nil {
+			snap[table] = map[string]any{}
+		}
+		var v any
+		if len(old) > 0 {
+			if err := json.Unmarshal(old, &v); err != nil {
+				return nil, err
+			}
+		}
+		snap[table][field] = v
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
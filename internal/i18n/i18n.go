@@ -0,0 +1,101 @@
+package i18n
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+)
+
+// 지원 언어. 카탈로그 원본 name 컬럼은 영어이고, 번역이 없으면 원본을 쓴다
+const Default = "ko"
+
+var Supported = []string{"ko", "en"}
+
+// 번역 대상(translation.entity)
+const (
+	Region            = "region"
+	JobCategory       = "job_category"
+	CharacterCategory = "character_category"
+	CharacterItem     = "character_item"
+	BgItem            = "bg_item"
+	PrefItem          = "pref_item"
+	ProfilePrompt     = "profile_prompt"
+	AvatarPart        = "avatar_part"
+)
+
+// Accept-Language 에서 지원 언어 중 가장 선호하는 것. 없으면 Default.
+// "ko-KR" 처럼 지역이 붙어도 주 언어로 맞춘다
+func Negotiate(header string) string {
+	type pref struct {
+		tag string
+		q   float64
+		pos int
+	}
+	var prefs []pref
+	for i, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, p := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(p), "q="); ok {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		prefs = append(prefs, pref{tag, q, i})
+	}
+	sort.SliceStable(prefs, func(a, b int) bool { return prefs[a].q > prefs[b].q })
+	for _, p := range prefs {
+		if p.tag == "*" {
+			return Default
+		}
+		primary, _, _ := strings.Cut(p.tag, "-")
+		for _, s := range Supported {
+			if primary == s {
+				return s
+			}
+		}
+	}
+	return Default
+}
+
+// 번역된 이름 SQL 식. keyExpr 은 text 로 비교되는 키, param 은 locale 자리표시자($n)
+//
+//	i18n.Name(i18n.JobCategory, "code", "name", "$1")
+func Name(entity, keyExpr, fallback, param string) string {
+	return `COALESCE((SELECT t.name FROM translation t WHERE t.entity='` + entity +
+		`' AND t.entity_key=(` + keyExpr + `)::text AND t.locale=` + param + `), ` + fallback + `)`
+}
+
+// keys 의 번역 이름(번역이 있는 것만)
+func Lookup(ctx context.Context, q db.Querier, entity, locale string, keys []string) (map[string]string, error) {
+	out := map[string]string{}
+	if len(keys) == 0 {
+		return out, nil
+	}
+	rows, err := q.Query(ctx, `
+		SELECT entity_key, name FROM translation
+		WHERE entity=$1 AND locale=$2 AND entity_key = ANY($3)`, entity, locale, keys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var k, name string
+		if err := rows.Scan(&k, &name); err != nil {
+			return nil, err
+		}
+		out[k] = name
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,32 @@
+package i18n
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	cases := map[string]string{
+		"":                            Default,
+		"en":                          "en",
+		"en-US,en;q=0.9":              "en",
+		"ko-KR,ko;q=0.9,en;q=0.8":     "ko",
+		"fr-FR,en;q=0.5,ko;q=0.7":     "ko",
+		"ja, en;q=0.1":                "en",
+		"fr":                          Default,
+		"*":                           Default,
+		"en;q=0, ko":                  "ko",
+		"EN-gb":                       "en",
+		"en;q=0.5, ko;q=0.5":          "en", // 같은 가중치면 먼저 쓴 것
+		"de;q=0.9, en;q=bad, ko;q=.2": "en",
+	}
+	for in, want := range cases {
+		if got := Negotiate(in); got != want {
+			t.Errorf("Negotiate(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	want := `COALESCE((SELECT t.name FROM translation t WHERE t.entity='job_category' AND t.entity_key=(code)::text AND t.locale=$1), name)`
+	if got := Name(JobCategory, "code", "name", "$1"); got != want {
+		t.Fatalf("got %s", got)
+	}
+}
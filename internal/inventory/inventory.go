@@ -0,0 +1,153 @@
+// Package inventory 는 사용자가 가진 카탈로그 항목(user_inventory)이다.
+// 잠긴(locked) 항목은 보유해야 아바타에 쓸 수 있고, 잠기지 않은 항목은 모두가 쓸 수 있어 기록하지 않는다
+package inventory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	pgconnv5 "github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+	"github.com/creators-of-happiness/amigo-backend/internal/i18n"
+)
+
+// user_inventory.entity
+var Entities = []string{i18n.CharacterItem, i18n.BgItem, i18n.AvatarPart}
+
+// 얻은 경로(user_inventory.source, 항목의 unlock_source)
+const (
+	SourceOnboarding  = "onboarding"  // 온보딩 완료 보상(자동)
+	SourceAchievement = "achievement" // source_ref 는 업적 코드
+	SourcePurchase    = "purchase"    // source_ref 는 구매 id
+	SourceAdmin       = "admin"       // 운영 지급
+)
+
+var (
+	ErrUnknownItem   = errors.New("unknown item")
+	ErrUnknownEntity = errors.New("unknown inventory entity")
+	ErrUnknownUser   = errors.New("user not found")
+)
+
+type Item struct {
+	Entity     string    `json:"entity"`
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	SlotCode   *string   `json:"slot_code"` // avatar_part 만
+	PreviewURL *string   `json:"preview_url"`
+	Source     string    `json:"source"`
+	SourceRef  *string   `json:"source_ref"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+type Grant struct {
+	UserID    string
+	Entity    string
+	ItemID    string
+	Source    string
+	SourceRef string // 비우면 NULL
+	GrantedBy string // 관리자 id. 비우면 NULL
+}
+
+// 항목을 지급한다. 이미 가졌으면 아무것도 바꾸지 않고 false.
+// 잠기지 않은 항목도 지급할 수 있다(나중에 잠가도 유지된다)
+func Give(ctx context.Context, q db.Querier, g Grant) (bool, error) {
+	if !slices.Contains(Entities, g.Entity) {
+		return false, ErrUnknownEntity
+	}
+	var inserted, exists bool
+	err := q.QueryRow(ctx, `
+		WITH item AS (SELECT id FROM `+g.Entity+` WHERE id = $3::uuid),
+		ins AS (
+			INSERT INTO user_inventory (user_id, entity, item_id, source, source_ref, granted_by)
+			SELECT $1, $2, id, $4, NULLIF($5, ''), NULLIF($6, '')::uuid FROM item
+			ON CONFLICT (user_id, entity, item_id) DO NOTHING
+			RETURNING 1
+		)
+		SELECT EXISTS (SELECT 1 FROM ins), EXISTS (SELECT 1 FROM item)`,
+		g.UserID, g.Entity, g.ItemID, g.Source, g.SourceRef, g.GrantedBy).Scan(&inserted, &exists)
+	var pe *pgconnv5.PgError
+	if errors.As(err, &pe) && pe.ConstraintName == "user_inventory_user_id_fkey" {
+		return false, ErrUnknownUser
+	}
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, ErrUnknownItem
+	}
+	return inserted, nil
+}
+
+// 보유 기록을 지운다. 지금 아바타에 쓰고 있으면 바꿀 때까지 유지된다(avatar.Check)
+func Revoke(ctx context.Context, q db.Querier, uid, entity, itemID string) (bool, error) {
+	if !slices.Contains(Entities, entity) {
+		return false, ErrUnknownEntity
+	}
+	ct, err := q.Exec(ctx, `DELETE FROM user_inventory WHERE user_id = $1 AND entity = $2 AND item_id = $3::uuid`, uid, entity, itemID)
+	if err != nil {
+		return false, err
+	}
+	return ct.RowsAffected() > 0, nil
+}
+
+// 온보딩 완료 보상(활성인 잠긴 항목 중 unlock_source='onboarding')을 아직 없는 것만 지급하고 개수를 돌려준다
+func GrantOnboarding(ctx context.Context, q db.Querier, uid string) (int64, error) {
+	ct, err := q.Exec(ctx, `
+		INSERT INTO user_inventory (user_id, entity, item_id, source)
+		SELECT $1, i.entity, i.id, 'onboarding' FROM (
+			SELECT 'character_item' AS entity, id FROM character_item WHERE active AND locked AND unlock_source = 'onboarding'
+			UNION ALL SELECT 'bg_item', id FROM bg_item WHERE active AND locked AND unlock_source = 'onboarding'
+			UNION ALL SELECT 'avatar_part', id FROM avatar_part WHERE active AND locked AND unlock_source = 'onboarding'
+		) i
+		ON CONFLICT (user_id, entity, item_id) DO NOTHING`, uid)
+	if err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
+}
+
+// 보유 항목(최근에 얻은 것부터). entity 를 주면 그 종류만, 이름은 locale 로
+func List(ctx context.Context, q db.Querier, uid, entity, locale string) ([]Item, error) {
+	if entity != "" && !slices.Contains(Entities, entity) {
+		return nil, ErrUnknownEntity
+	}
+	rows, err := q.Query(ctx, `
+		SELECT inv.entity, inv.item_id::text, it.name, it.slot_code, m.url, inv.source, inv.source_ref, inv.acquired_at
+		FROM user_inventory inv
+		JOIN (
+			SELECT 'character_item' AS entity, ci.id, `+i18n.Name(i18n.CharacterItem, "ci.id", "ci.name", "$3")+` AS name,
+			       NULL::text AS slot_code, ci.preview_asset
+			FROM character_item ci
+			UNION ALL
+			SELECT 'bg_item', b.id, `+i18n.Name(i18n.BgItem, "b.id", "b.name", "$3")+`, NULL, b.preview_asset
+			FROM bg_item b
+			UNION ALL
+			SELECT 'avatar_part', p.id, `+i18n.Name(i18n.AvatarPart, "p.id", "p.name", "$3")+`, p.slot_code, p.preview_asset
+			FROM avatar_part p
+		) it ON it.entity = inv.entity AND it.id = inv.item_id
+		LEFT JOIN media_asset m ON m.id = it.preview_asset
+		WHERE inv.user_id = $1 AND ($2 = '' OR inv.entity = $2)
+		ORDER BY inv.acquired_at DESC, inv.entity, inv.item_id`, uid, entity, locale)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Item{}
+	for rows.Next() {
+		var it Item
+		if err := rows.Scan(&it.Entity, &it.ID, &it.Name, &it.SlotCode, &it.PreviewURL, &it.Source, &it.SourceRef, &it.AcquiredAt); err != nil {
+			return nil, err
+		}
+		out = append(out, it)
+	}
+	return out, rows.Err()
+}
+
+// 잠긴 항목을 쓸 수 있는지(SQL). uid 는 사용자 id 자리표시자, alias 는 항목 테이블 별칭
+func Owned(entity, uid, alias string) string {
+	return `(NOT ` + alias + `.locked OR EXISTS (SELECT 1 FROM user_inventory inv WHERE inv.user_id = ` + uid +
+		` AND inv.entity = '` + entity + `' AND inv.item_id = ` + alias + `.id))`
+}
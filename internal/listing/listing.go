@@ -0,0 +1,212 @@
+// Package listing 은 목록 API 공통 규칙이다.
+//
+// 쿼리: limit(기본 50, 최대 200), cursor(이전 응답의 next_cursor), sort(정렬 이름, "-" 가 붙으면 역순), q(이름 검색).
+// 응답: {items, total, next_cursor}. next_cursor 가 null 이면 마지막 페이지.
+// 페이지는 키셋 방식이라 목록이 바뀌어도 건너뛰거나 겹치는 행이 없고, total 은 cursor 와 무관한 전체 개수다.
+//
+//	p, err := listing.Parse(in, spec)
+//	base := `SELECT ... WHERE active AND category = ` + p.Arg(category)
+//	sql, args := p.Select(base)
+//	for rows.Next() {
+//		if p.Full() { break }
+//		rows.Scan(p.Dest(&id, &name)...)
+//	}
+//	p.NextCursor()
+package listing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+var (
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// 쿼리 파라미터(gin ShouldBindQuery)
+type Query struct {
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=200"`
+	Cursor string `form:"cursor" binding:"omitempty,max=1024"`
+	Sort   string `form:"sort" binding:"omitempty,max=40"`
+	Q      string `form:"q" binding:"omitempty,max=100"`
+}
+
+// base 쿼리의 출력 열과 그 SQL 타입(커서 값을 되돌릴 때 캐스트). 타입은 text, int, uuid
+type Key struct {
+	Col  string
+	Type string
+}
+
+var uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// 커서 값이 캐스트에 실패하지 않는지(조작된 커서가 DB 오류가 되지 않게)
+func (k Key) valid(v string) bool {
+	switch k.Type {
+	case "int":
+		_, err := strconv.ParseInt(v, 10, 32)
+		return err == nil
+	case "uuid":
+		return uuidRe.MatchString(v)
+	default:
+		return !strings.ContainsRune(v, 0)
+	}
+}
+
+// 엔드포인트별 정렬 규칙. 정렬마다 열을 앞에서부터 비교하고(모두 같은 방향),
+// 같은 값은 ID 로 이어 정렬해 순서가 항상 정해지게 한다
+type Spec struct {
+	Sorts   map[string][]Key
+	Default string
+	ID      Key
+}
+
+type Page struct {
+	Limit int
+	Q     string
+	sort  string
+	keys  []Key // 정렬 열 + id
+	desc  bool
+	after []string // 커서(마지막 행의 정렬 값들, id)
+
+	args []any
+	n    int
+	more bool
+	last []string
+}
+
+type cursor struct {
+	Sort string   `json:"s"`
+	Keys []string `json:"k"`
+}
+
+// 정렬/커서 검사. 다른 정렬로 만든 커서나 값이 열 타입에 맞지 않는 커서는 ErrInvalidCursor
+func Parse(in Query, spec Spec) (*Page, error) {
+	p := &Page{Limit: in.Limit, Q: strings.TrimSpace(in.Q), sort: in.Sort}
+	if p.Limit <= 0 {
+		p.Limit = DefaultLimit
+	}
+	p.Limit = min(p.Limit, MaxLimit)
+	if p.sort == "" {
+		p.sort = spec.Default
+	}
+	name, desc := strings.CutPrefix(p.sort, "-")
+	keys, ok := spec.Sorts[name]
+	if !ok {
+		return nil, ErrInvalidSort
+	}
+	p.keys, p.desc = append(slices.Clone(keys), spec.ID), desc
+	p.last = make([]string, len(p.keys))
+	if in.Cursor != "" {
+		b, err := base64.RawURLEncoding.DecodeString(in.Cursor)
+		var cur cursor
+		if err != nil || json.Unmarshal(b, &cur) != nil || cur.Sort != p.sort || len(cur.Keys) != len(p.keys) {
+			return nil, ErrInvalidCursor
+		}
+		for i, k := range p.keys {
+			if !k.valid(cur.Keys[i]) {
+				return nil, ErrInvalidCursor
+			}
+		}
+		p.after = cur.Keys
+	}
+	return p, nil
+}
+
+// 인자를 추가하고 자리표시자를 돌려준다. base 쿼리의 인자는 모두 이것으로 넣는다
+func (p *Page) Arg(v any) string {
+	p.args = append(p.args, v)
+	return "$" + strconv.Itoa(len(p.args))
+}
+
+// q 가 이름 접두어이거나 trigram 으로 비슷한 행(pg_trgm). cols 중 하나라도 맞으면 된다.
+// entity 가 있으면 keyExpr 로 찾은 모든 언어의 번역 이름도 본다. q 가 비면 "true"
+func (p *Page) Match(entity, keyExpr string, cols ...string) string {
+	if p.Q == "" {
+		return "true"
+	}
+	prefix := p.Arg(likeEscaper.Replace(p.Q) + "%")
+	q := p.Arg(p.Q)
+	match := func(col string) string { return col + ` ILIKE ` + prefix + ` OR ` + col + ` % ` + q }
+	var or []string
+	for _, c := range cols {
+		or = append(or, match(c))
+	}
+	if entity != "" {
+		or = append(or, `EXISTS (SELECT 1 FROM translation tr WHERE tr.entity='`+entity+
+			`' AND tr.entity_key=(`+keyExpr+`)::text AND (`+match("tr.name")+`))`)
+	}
+	return "(" + strings.Join(or, " OR ") + ")"
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// 페이지 조회문. base 의 열 뒤에 커서용 정렬 값들과 id(text)가 붙고 limit+1 행까지 읽는다
+func (p *Page) Select(base string) (string, []any) {
+	args := append([]any(nil), p.args...)
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+	dir, cmp := "", ">"
+	if p.desc {
+		dir, cmp = " DESC", "<"
+	}
+	var cols, texts, order, vals []string
+	for i, k := range p.keys {
+		col := "s." + k.Col
+		cols, texts, order = append(cols, col), append(texts, col+"::text"), append(order, col+dir)
+		if p.after != nil {
+			vals = append(vals, arg(p.after[i])+"::text::"+k.Type)
+		}
+	}
+	sql := `SELECT s.*, ` + strings.Join(texts, ", ") + ` FROM (` + base + `) s`
+	if p.after != nil {
+		sql += ` WHERE (` + strings.Join(cols, ", ") + `) ` + cmp + ` (` + strings.Join(vals, ", ") + `)`
+	}
+	sql += ` ORDER BY ` + strings.Join(order, ", ") + ` LIMIT ` + strconv.Itoa(p.Limit+1)
+	return sql, args
+}
+
+// total 조회문(커서와 무관)
+func (p *Page) Count(base string) (string, []any) {
+	return `SELECT count(*) FROM (` + base + `) s`, p.args
+}
+
+// rows.Next() 직후 Scan 전에 부른다. limit 을 넘은 행이면 다음 페이지가 있다고 표시하고 true
+func (p *Page) Full() bool {
+	p.n++
+	if p.n > p.Limit {
+		p.more = true
+		return true
+	}
+	return false
+}
+
+// Scan 대상 뒤에 커서용 열을 붙인다
+func (p *Page) Dest(dest ...any) []any {
+	for i := range p.last {
+		dest = append(dest, &p.last[i])
+	}
+	return dest
+}
+
+// 다음 페이지 커서. 마지막 페이지면 nil
+func (p *Page) NextCursor() *string {
+	if !p.more {
+		return nil
+	}
+	b, _ := json.Marshal(cursor{Sort: p.sort, Keys: slices.Clone(p.last)})
+	s := base64.RawURLEncoding.EncodeToString(b)
+	return &s
+}
@@ -0,0 +1,125 @@
+package listing
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var spec = Spec{
+	Sorts: map[string][]Key{
+		"name":       {{Col: "name", Type: "text"}},
+		"sort_order": {{Col: "sort_order", Type: "int"}, {Col: "name", Type: "text"}},
+	},
+	Default: "name",
+	ID:      Key{Col: "id", Type: "uuid"},
+}
+
+const id = "0b7e2c4a-4f1e-4d8a-9c3b-2a1f0e9d8c7b"
+
+func enc(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		in   Query
+		want error
+	}{
+		{Query{Sort: "price"}, ErrInvalidSort},
+		{Query{Sort: "--name"}, ErrInvalidSort},
+		{Query{Cursor: "!!"}, ErrInvalidCursor},
+		{Query{Cursor: "bm90LWpzb24"}, ErrInvalidCursor},
+		{Query{Cursor: enc(`{"s":"name","k":["Cat","abc"]}`)}, ErrInvalidCursor},                                        // uuid
+		{Query{Sort: "sort_order", Cursor: enc(`{"s":"sort_order","k":["abc","Cat","` + id + `"]}`)}, ErrInvalidCursor}, // int
+		{Query{Sort: "sort_order", Cursor: enc(`{"s":"sort_order","k":["9999999999","Cat","` + id + `"]}`)}, ErrInvalidCursor},
+		{Query{Cursor: enc(`{"s":"name","k":["C\u0000at","` + id + `"]}`)}, ErrInvalidCursor},
+		{Query{Sort: "sort_order", Cursor: enc(`{"s":"sort_order","k":["-3","Cat","` + id + `"]}`)}, nil},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.in, spec); !errors.Is(err, tt.want) {
+			t.Errorf("%+v: expected %v, got %v", tt.in, tt.want, err)
+		}
+	}
+}
+
+// limit+1 번째 행에서 멈추고 마지막으로 읽은 행이 다음 커서가 된다
+func TestPage_Cursor(t *testing.T) {
+	p, err := Parse(Query{Limit: 2, Sort: "-name"}, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := [][2]string{{"Dog", id[:35] + "b"}, {"Cat", id[:35] + "a"}, {"Ant", id[:35] + "c"}}
+	var got []string
+	for _, r := range rows {
+		if p.Full() {
+			break
+		}
+		// rows.Scan 대신: 이름, 커서용 정렬 값, id
+		var name string
+		dest := p.Dest(&name)
+		*dest[0].(*string), *dest[1].(*string), *dest[2].(*string) = r[0], r[0], r[1]
+		got = append(got, name)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 rows, got %v", got)
+	}
+	cur := p.NextCursor()
+	if cur == nil {
+		t.Fatal("expected next cursor")
+	}
+
+	// 같은 정렬이면 마지막 행 다음부터
+	next, err := Parse(Query{Limit: 2, Sort: "-name", Cursor: *cur}, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql, args := next.Select("SELECT id, name FROM t")
+	if !strings.Contains(sql, "(s.name, s.id) < ($1::text::text, $2::text::uuid)") ||
+		!strings.Contains(sql, "ORDER BY s.name DESC, s.id DESC LIMIT 3") {
+		t.Fatalf("unexpected sql: %s", sql)
+	}
+	if len(args) != 2 || args[0] != "Cat" || args[1] != rows[1][1] {
+		t.Fatalf("unexpected args: %v", args)
+	}
+
+	// 다른 정렬에서 만든 커서는 거부
+	if _, err := Parse(Query{Sort: "name", Cursor: *cur}, spec); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor, got %v", err)
+	}
+
+	// 마지막 페이지
+	last, _ := Parse(Query{Limit: 5}, spec)
+	for range rows {
+		if last.Full() {
+			break
+		}
+	}
+	if last.NextCursor() != nil {
+		t.Fatal("expected no cursor on last page")
+	}
+}
+
+// base 인자 뒤에 검색/커서 인자가 이어 번호를 받고, total 은 커서 인자를 쓰지 않는다
+func TestPage_Args(t *testing.T) {
+	p, err := Parse(Query{Q: " 50%_off ", Sort: "sort_order"}, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := "SELECT id, name, sort_order FROM t WHERE locale = " + p.Arg("ko") + " AND " + p.Match("bg_item", "t.id", "t.name")
+	if !strings.Contains(base, "t.name ILIKE $2 OR t.name % $3") || !strings.Contains(base, "tr.entity='bg_item'") {
+		t.Fatalf("unexpected match: %s", base)
+	}
+	sql, args := p.Count(base)
+	if !strings.HasPrefix(sql, "SELECT count(*)") || len(args) != 3 || args[1] != `50\%\_off%` || args[2] != "50%_off" {
+		t.Fatalf("unexpected count: %s %v", sql, args)
+	}
+	if sql, _ := p.Select(base); !strings.Contains(sql, "s.sort_order::text, s.name::text, s.id::text") ||
+		!strings.Contains(sql, "ORDER BY s.sort_order, s.name, s.id LIMIT 51") {
+		t.Fatalf("unexpected select: %s", sql)
+	}
+
+	empty, _ := Parse(Query{}, spec)
+	if m := empty.Match("bg_item", "t.id", "t.name"); m != "true" {
+		t.Fatalf("expected no filter without q, got %s", m)
+	}
+}
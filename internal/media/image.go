@@ -0,0 +1,111 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	_ "golang.org/x/image/webp" // image.Decode 에 webp 디코더 등록
+)
+
+// 업로드 이미지 검증/정규화 결과
+type Image struct {
+	Data        []byte
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+}
+
+type Limits struct {
+	MaxBytes     int64
+	MaxDimension int // 가로/세로 최대 픽셀
+	MinDimension int // 가로/세로 최소 픽셀
+}
+
+var (
+	ErrTooLarge        = errors.New("image exceeds size limit")
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrDimensions      = errors.New("image dimensions out of range")
+	ErrCorrupt         = errors.New("image could not be decoded")
+)
+
+// 허용 포맷(스니핑 결과 기준)
+var allowed = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// 헤더로 해상도를 먼저 확인한 뒤(압축 폭탄 방지) 디코딩한다. maxDim 은 가로/세로 최대 픽셀
+func Decode(data []byte, maxDim int) (image.Image, string, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrCorrupt
+	}
+	if cfg.Width > maxDim || cfg.Height > maxDim {
+		return nil, "", ErrDimensions
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrCorrupt
+	}
+	return img, format, nil
+}
+
+// 업로드 원본을 읽어 타입 스니핑 → 크기/해상도 검사 → EXIF 방향 적용 후 재인코딩.
+// 재인코딩 과정에서 EXIF/XMP 등 메타데이터(위치 정보 포함)는 모두 제거된다.
+func Process(r io.Reader, lim Limits) (*Image, error) {
+	data, err := io.ReadAll(io.LimitReader(r, lim.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read image: %w", err)
+	}
+	if int64(len(data)) > lim.MaxBytes {
+		return nil, ErrTooLarge
+	}
+	// 클라이언트가 보낸 Content-Type 대신 실제 바이트로 판단
+	ct := http.DetectContentType(data)
+	if !allowed[ct] {
+		return nil, ErrUnsupportedType
+	}
+
+	// 전체 디코딩 전에 헤더만으로 해상도 확인(압축 폭탄 방지)
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	if cfg.Width > lim.MaxDimension || cfg.Height > lim.MaxDimension ||
+		cfg.Width < lim.MinDimension || cfg.Height < lim.MinDimension {
+		return nil, ErrDimensions
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	if ct == "image/jpeg" {
+		img = applyOrientation(img, exifOrientation(data))
+	}
+
+	var buf bytes.Buffer
+	out := &Image{}
+	if ct == "image/png" {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("encode png: %w", err)
+		}
+		out.ContentType, out.Ext = "image/png", ".png"
+	} else {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+			return nil, fmt.Errorf("encode jpeg: %w", err)
+		}
+		out.ContentType, out.Ext = "image/jpeg", ".jpg"
+	}
+	b := img.Bounds()
+	out.Data, out.Width, out.Height = buf.Bytes(), b.Dx(), b.Dy()
+	return out, nil
+}
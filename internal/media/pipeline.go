@@ -0,0 +1,261 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/storage"
+)
+
+// media_asset.variant_status 가 'pending' 인 이미지를 골라 파생 이미지를 만드는 백그라운드 작업자.
+// 여러 API 레플리카가 동시에 돌아도 FOR UPDATE SKIP LOCKED 로 한 건씩만 가져간다.
+// 저장소에 있는 원본과 소유자 없는(카탈로그) 에셋만 처리한다. 사용자가 등록한 외부 URL 은 서버가 가져오지 않는다
+type Pipeline struct {
+	Pool         *pgxpool.Pool
+	Store        storage.Storage
+	Specs        []VariantSpec
+	HTTP         *http.Client // storage_key 가 없는(카탈로그 외부 URL) 에셋 원본 다운로드용
+	AllowHosts   []string     // 외부 URL 을 가져와도 되는 호스트. 비우면 가져오지 않는다
+	MaxSource    int64
+	MaxDimension int // 원본 가로/세로 최대 픽셀(압축 폭탄 방지)
+	MaxAttempts  int
+
+	wake chan struct{}
+}
+
+func NewPipeline(pool *pgxpool.Pool, store storage.Storage) *Pipeline {
+	return &Pipeline{
+		Pool:         pool,
+		Store:        store,
+		Specs:        DefaultVariants,
+		HTTP:         &http.Client{Timeout: 10 * time.Second},
+		MaxSource:    20 << 20,
+		MaxDimension: 4096,
+		MaxAttempts:  3,
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// 새 업로드 직후 호출하면 폴링 주기를 기다리지 않고 바로 처리(nil 안전)
+func (p *Pipeline) Notify() {
+	if p == nil {
+		return
+	}
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// ctx 가 끝날 때까지 대기열을 비우고, 비면 interval 또는 Notify 까지 대기
+func (p *Pipeline) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		for {
+			ok, err := p.ProcessNext(ctx)
+			if err != nil {
+				log.Printf("media pipeline: %v", err)
+			}
+			if !ok || ctx.Err() != nil {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		case <-p.wake:
+		}
+	}
+}
+
+// 대기 중인 에셋 하나를 처리. 처리할 것이 없으면 false
+func (p *Pipeline) ProcessNext(ctx context.Context) (bool, error) {
+	var id, url string
+	var key *string
+	var attempts int
+	// 처리 중 죽은 작업자가 남긴 'processing' 은 5분 뒤 다시 가져감
+	err := p.Pool.QueryRow(ctx, `
+		UPDATE media_asset
+		SET variant_status='processing', variant_attempts=variant_attempts+1, variant_updated_at=now()
+		WHERE id = (
+			SELECT id FROM media_asset
+			WHERE kind='image'
+			  AND (storage_key IS NOT NULL OR owner_id IS NULL)
+			  AND (variant_status='pending'
+			       OR (variant_status='processing' AND variant_updated_at < now() - interval '5 minutes'))
+			ORDER BY created_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING id, url, storage_key, variant_attempts`).Scan(&id, &url, &key, &attempts)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("claim asset: %w", err)
+	}
+
+	if perr := p.process(ctx, id, url, key); perr != nil {
+		status := "pending"
+		if attempts >= p.MaxAttempts {
+			status = "failed"
+		}
+		_, err := p.Pool.Exec(ctx, `
+			UPDATE media_asset SET variant_status=$2, variant_error=$3, variant_updated_at=now()
+			WHERE id=$1`, id, status, perr.Error())
+		if err != nil {
+			return true, err
+		}
+		return true, fmt.Errorf("asset %s: %w", id, perr)
+	}
+	_, err = p.Pool.Exec(ctx, `
+		UPDATE media_asset SET variant_status='done', variant_error=NULL, variant_updated_at=now()
+		WHERE id=$1`, id)
+	return true, err
+}
+
+func (p *Pipeline) process(ctx context.Context, assetID, url string, key *string) error {
+	data, err := p.source(ctx, url, key)
+	if err != nil {
+		return err
+	}
+	src, format, err := Decode(data, p.MaxDimension)
+	if err != nil {
+		return err
+	}
+	for _, spec := range p.Specs {
+		v, err := MakeVariant(src, format, spec)
+		if err != nil {
+			return err
+		}
+		vkey := "variants/" + assetID + "/" + v.Name + v.Ext
+		if err := p.Store.Put(ctx, vkey, bytes.NewReader(v.Data), int64(len(v.Data)), v.ContentType); err != nil {
+			return err
+		}
+		_, err = p.Pool.Exec(ctx, `
+			INSERT INTO media_asset_variant (asset_id, name, storage_key, url, content_type, width, height, size_bytes)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (asset_id, name) DO UPDATE SET
+			  storage_key=EXCLUDED.storage_key, url=EXCLUDED.url, content_type=EXCLUDED.content_type,
+			  width=EXCLUDED.width, height=EXCLUDED.height, size_bytes=EXCLUDED.size_bytes, created_at=now()`,
+			assetID, v.Name, vkey, p.Store.URL(vkey), v.ContentType, v.Width, v.Height, len(v.Data))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Pipeline) source(ctx context.Context, url string, key *string) ([]byte, error) {
+	src := Source{Store: p.Store, HTTP: p.HTTP, AllowHosts: p.AllowHosts, MaxBytes: p.MaxSource}
+	return src.Read(ctx, url, key)
+}
+
+// 허용 목록에 없는 호스트의 외부 URL(리다이렉트 포함)
+var ErrRemoteNotAllowed = errors.New("remote source host not allowed")
+
+// 에셋 원본 읽기. 저장소에 있는 원본은 저장소에서, 외부 URL 로만 등록된 에셋(카탈로그 시드 등)은
+// AllowHosts 에 있는 호스트일 때만 HTTP 로 가져온다
+type Source struct {
+	Store      storage.Storage
+	HTTP       *http.Client
+	AllowHosts []string
+	MaxBytes   int64
+}
+
+// http(s) 이고 호스트가 허용 목록에 있는지(대소문자 무시, 정확히 일치)
+func (s Source) allowed(u *url.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	return slices.ContainsFunc(s.AllowHosts, func(h string) bool { return strings.EqualFold(h, u.Hostname()) })
+}
+
+func (s Source) Read(ctx context.Context, rawURL string, key *string) ([]byte, error) {
+	var r io.ReadCloser
+	if key != nil && *key != "" {
+		rc, err := s.Store.Get(ctx, *key)
+		if err != nil {
+			return nil, err
+		}
+		r = rc
+	} else {
+		u, err := url.Parse(rawURL)
+		if err != nil || !s.allowed(u) {
+			return nil, ErrRemoteNotAllowed
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		// 리다이렉트도 허용 호스트로만
+		client := *s.HTTP
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 || !s.allowed(req.URL) {
+				return ErrRemoteNotAllowed
+			}
+			return nil
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			return nil, fmt.Errorf("fetch source: %s", res.Status)
+		}
+		r = res.Body
+	}
+	defer r.Close()
+	data, err := io.ReadAll(io.LimitReader(r, s.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.MaxBytes {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+// 에셋별 파생 이미지 JSON 표현({"thumb": {...}, ...})
+type VariantInfo struct {
+	URL         string `json:"url"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	ContentType string `json:"content_type"`
+}
+
+// media_asset_variant 를 에셋 ID 별 맵으로 조회. 변환 전이면 빈 맵
+func LoadVariants(ctx context.Context, pool *pgxpool.Pool, assetID string) (map[string]VariantInfo, error) {
+	out := map[string]VariantInfo{}
+	rows, err := pool.Query(ctx, `
+		SELECT name, url, width, height, content_type
+		FROM media_asset_variant WHERE asset_id=$1`, assetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var v VariantInfo
+		if err := rows.Scan(&name, &v.URL, &v.Width, &v.Height, &v.ContentType); err != nil {
+			return nil, err
+		}
+		out[name] = v
+	}
+	return out, rows.Err()
+}
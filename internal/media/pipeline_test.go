@@ -0,0 +1,67 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// 외부 URL 은 허용 호스트만, 리다이렉트로 다른 호스트에 가는 것도 막는다
+func TestSource_AllowHosts(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.URL.Path == "/redirect" {
+			u, _ := url.Parse(r.URL.Query().Get("to"))
+			http.Redirect(w, r, u.String(), http.StatusFound)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	ctx := context.Background()
+
+	src := Source{HTTP: srv.Client(), MaxBytes: 1 << 10}
+	if _, err := src.Read(ctx, srv.URL+"/a.png", nil); !errors.Is(err, ErrRemoteNotAllowed) {
+		t.Fatalf("empty allowlist: expected ErrRemoteNotAllowed, got %v", err)
+	}
+	if hits != 0 {
+		t.Fatal("disallowed host must not be fetched")
+	}
+
+	src.AllowHosts = []string{u.Hostname()}
+	data, err := src.Read(ctx, srv.URL+"/a.png", nil)
+	if err != nil || string(data) != "ok" {
+		t.Fatalf("allowed host: %q %v", data, err)
+	}
+	other := "http://localhost:" + u.Port() + "/a.png"
+	if _, err := src.Read(ctx, srv.URL+"/redirect?to="+url.QueryEscape(other), nil); !errors.Is(err, ErrRemoteNotAllowed) {
+		t.Fatalf("redirect: expected ErrRemoteNotAllowed, got %v", err)
+	}
+	if _, err := src.Read(ctx, "file:///etc/passwd", nil); !errors.Is(err, ErrRemoteNotAllowed) {
+		t.Fatalf("scheme: expected ErrRemoteNotAllowed, got %v", err)
+	}
+}
+
+func TestDecode_Dimensions(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(300, 200)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Decode(buf.Bytes(), 299); !errors.Is(err, ErrDimensions) {
+		t.Fatalf("expected ErrDimensions, got %v", err)
+	}
+	img, format, err := Decode(buf.Bytes(), 300)
+	if err != nil || format != "png" || img.Bounds().Dx() != 300 {
+		t.Fatalf("decode: %v %s", err, format)
+	}
+	if _, _, err := Decode([]byte("not an image"), 300); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("expected ErrCorrupt, got %v", err)
+	}
+}
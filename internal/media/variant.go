@@ -0,0 +1,99 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+)
+
+// 서버에서 생성하는 파생 이미지 규격
+type VariantSpec struct {
+	Name   string
+	Size   int  // 긴 변(Square 면 한 변) 최대 픽셀
+	Square bool // 가운데 기준 정사각 크롭
+	WebP   bool // true 면 WebP(무손실), 아니면 원본 계열(JPEG/PNG)
+}
+
+var DefaultVariants = []VariantSpec{
+	{Name: "thumb", Size: 160},
+	{Name: "medium", Size: 640},
+	{Name: "square", Size: 320, Square: true},
+	{Name: "webp", Size: 640, WebP: true},
+}
+
+// 생성된 파생 이미지(저장 전)
+type Variant struct {
+	Name        string
+	Data        []byte
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+}
+
+// 원본 이미지로부터 spec 에 맞는 파생 이미지를 만든다. 원본보다 크게 늘리지는 않음
+// alpha 가 있을 수 있는 PNG 원본은 PNG 로, 그 외는 JPEG 로 인코딩
+func MakeVariant(src image.Image, srcFormat string, spec VariantSpec) (*Variant, error) {
+	img := src
+	if spec.Square {
+		img = cropSquare(img)
+	}
+	img = fit(img, spec.Size)
+
+	var buf bytes.Buffer
+	v := &Variant{Name: spec.Name}
+	switch {
+	case spec.WebP:
+		if err := nativewebp.Encode(&buf, img, nil); err != nil {
+			return nil, fmt.Errorf("encode webp: %w", err)
+		}
+		v.ContentType, v.Ext = "image/webp", ".webp"
+	case srcFormat == "png":
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("encode png: %w", err)
+		}
+		v.ContentType, v.Ext = "image/png", ".png"
+	default:
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, fmt.Errorf("encode jpeg: %w", err)
+		}
+		v.ContentType, v.Ext = "image/jpeg", ".jpg"
+	}
+	b := img.Bounds()
+	v.Data, v.Width, v.Height = buf.Bytes(), b.Dx(), b.Dy()
+	return v, nil
+}
+
+func cropSquare(src image.Image) image.Image {
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+	dst := image.NewNRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(dst, dst.Bounds(), src, image.Pt(x0, y0), draw.Src)
+	return dst
+}
+
+// 긴 변이 size 이하가 되도록 비율 유지 축소
+func fit(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if size <= 0 || (w <= size && h <= size) {
+		return src
+	}
+	if w >= h {
+		h = h * size / w
+		w = size
+	} else {
+		w = w * size / h
+		h = size
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, max(w, 1), max(h, 1)))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	return dst
+}
@@ -0,0 +1,69 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"net/http"
+	"testing"
+)
+
+func testImage(w, h int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{uint8(x), uint8(y), 90, 255})
+		}
+	}
+	return img
+}
+
+func TestMakeVariant_Sizes(t *testing.T) {
+	src := testImage(800, 400)
+	cases := []struct {
+		spec   VariantSpec
+		format string
+		w, h   int
+		ct     string
+	}{
+		{VariantSpec{Name: "thumb", Size: 160}, "jpeg", 160, 80, "image/jpeg"},
+		{VariantSpec{Name: "medium", Size: 640}, "png", 640, 320, "image/png"},
+		{VariantSpec{Name: "square", Size: 320, Square: true}, "jpeg", 320, 320, "image/jpeg"},
+		{VariantSpec{Name: "webp", Size: 640, WebP: true}, "jpeg", 640, 320, "image/webp"},
+		// 원본보다 크게 늘리지 않음
+		{VariantSpec{Name: "big", Size: 2000}, "jpeg", 800, 400, "image/jpeg"},
+	}
+	for _, tc := range cases {
+		v, err := MakeVariant(src, tc.format, tc.spec)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.spec.Name, err)
+		}
+		if v.Width != tc.w || v.Height != tc.h {
+			t.Fatalf("%s: want %dx%d, got %dx%d", tc.spec.Name, tc.w, tc.h, v.Width, v.Height)
+		}
+		if v.ContentType != tc.ct {
+			t.Fatalf("%s: want %s, got %s", tc.spec.Name, tc.ct, v.ContentType)
+		}
+		if got := http.DetectContentType(v.Data); got != tc.ct {
+			t.Fatalf("%s: encoded bytes sniff as %s, want %s", tc.spec.Name, got, tc.ct)
+		}
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(v.Data))
+		if err != nil || cfg.Width != tc.w || cfg.Height != tc.h {
+			t.Fatalf("%s: decoded config %+v err=%v", tc.spec.Name, cfg, err)
+		}
+	}
+}
+
+func TestApplyOrientation_Rotate90(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	red := color.NRGBA{255, 0, 0, 255}
+	src.Set(0, 0, red) // 좌상단
+	out := applyOrientation(src, 6)
+	if b := out.Bounds(); b.Dx() != 2 || b.Dy() != 3 {
+		t.Fatalf("want 2x3, got %v", b)
+	}
+	// 시계 방향 90도 회전이면 좌상단 픽셀은 우상단으로 이동
+	if got := color.NRGBAModel.Convert(out.At(1, 0)); got != red {
+		t.Fatalf("expected red at (1,0), got %v", got)
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Auth 뒤에 붙여 사용. uid 가 관리자 목록(ADMIN_USER_IDS)에 없으면 403
+func Admin(adminIDs []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(adminIDs))
+	for _, id := range adminIDs {
+		allowed[id] = true
+	}
+	return func(c *gin.Context) {
+		if !allowed[c.GetString("uid")] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin only"})
+			return
+		}
+		c.Next()
+	}
+}
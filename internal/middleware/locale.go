@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/i18n"
+)
+
+// Accept-Language 로 응답 언어를 정해 c 의 "locale" 에 둔다.
+// 언어마다 응답이 다르므로 Vary 로 캐시 키에 포함시킨다
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		loc := i18n.Negotiate(c.GetHeader("Accept-Language"))
+		c.Set("locale", loc)
+		c.Header("Content-Language", loc)
+		c.Header("Vary", "Accept-Language")
+		c.Next()
+	}
+}
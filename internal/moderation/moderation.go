@@ -0,0 +1,345 @@
+package moderation
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/config"
+)
+
+//go:embed words.txt
+var defaultWords string
+
+// 사용자 입력 검증 실패. 그대로 JSON 으로 내려보낸다
+type ValidationError struct {
+	Message string `json:"error"`
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+}
+
+func (e *ValidationError) Error() string { return e.Message }
+
+// 금칙어 필터. 목록 항목과 입력을 같은 규칙으로 정규화해 비교한다.
+//
+//   - 한글: 음절을 자모로 분해(숫자/기호/라틴 문자 끼워 넣기 무시)
+//   - 자음/모음 약어(ㅅㅂ 등): 낱자모로 입력된 부분에서만 비교
+//   - 라틴: 전각/발음 부호 제거, leetspeak 치환, 반복 글자 축약 후 단어 단위 비교
+//     (단어 전체 또는 단어+굴절 어미만 일치, "Scunthorpe" 같은 단어 속 일치는 무시)
+//   - '=' 항목: 공백 기준 단어 전체 일치, '!' 항목: 예외(허용) 표현
+//
+// nil Filter 는 아무것도 막지 않는다.
+type Filter struct {
+	hangul, abbrev, latin []string
+	tokens                map[string]bool
+	allowHangul           []string
+	allowLatin            []string
+}
+
+// 기본(내장) 목록
+func Default() *Filter {
+	f, _ := Parse(strings.NewReader(defaultWords))
+	return f
+}
+
+// MODERATION_WORDLIST 가 있으면 그 파일로 내장 목록을 교체
+func FromConfig(cfg config.Config) (*Filter, error) {
+	if cfg.ModerationWordlist == "" {
+		return Default(), nil
+	}
+	fh, err := os.Open(cfg.ModerationWordlist)
+	if err != nil {
+		return nil, fmt.Errorf("moderation: %w", err)
+	}
+	defer fh.Close()
+	return Parse(fh)
+}
+
+// 한 줄에 하나, '#' 이후는 주석
+func Parse(r io.Reader) (*Filter, error) {
+	f := &Filter{tokens: map[string]bool{}}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			f.add(line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("moderation: %w", err)
+	}
+	return f, nil
+}
+
+func (f *Filter) add(entry string) {
+	switch entry[0] {
+	case '=':
+		if t := latinForm(entry[1:], false); t != "" {
+			f.tokens[t] = true
+		}
+		return
+	case '!':
+		fs := formsOf(entry[1:])
+		if fs.hangul != "" {
+			f.allowHangul = append(f.allowHangul, fs.hangul)
+		}
+		if fs.latin != "" {
+			f.allowLatin = append(f.allowLatin, fs.latin)
+		}
+		return
+	}
+	fs := formsOf(entry)
+	switch {
+	case fs.hangul != "" && fs.onlyJamo:
+		f.abbrev = append(f.abbrev, strings.ReplaceAll(fs.abbrev, "|", ""))
+	case fs.hangul != "":
+		f.hangul = append(f.hangul, fs.hangul)
+	case fs.latin != "":
+		f.latin = append(f.latin, fs.latin)
+	}
+}
+
+// 금칙어가 있으면 field 를 담은 ValidationError
+func (f *Filter) Check(field, text string) error {
+	if f == nil || strings.TrimSpace(text) == "" {
+		return nil
+	}
+	if f.match(text) {
+		return &ValidationError{
+			Message: field + " contains inappropriate language",
+			Field:   field,
+			Code:    "profanity",
+		}
+	}
+	return nil
+}
+
+func (f *Filter) match(text string) bool {
+	fs := textForms(text)
+	h := fs.hangul
+	for _, a := range f.allowHangul {
+		h = strings.ReplaceAll(h, a, "|")
+	}
+	for _, w := range f.hangul {
+		if strings.Contains(h, w) {
+			return true
+		}
+	}
+	for _, w := range f.abbrev {
+		if strings.Contains(fs.abbrev, w) {
+			return true
+		}
+	}
+	for _, tok := range latinWords(text) {
+		for _, a := range f.allowLatin {
+			tok = strings.ReplaceAll(tok, a, "|")
+		}
+		for _, part := range strings.Split(tok, "|") {
+			for _, w := range f.latin {
+				if isInflection(part, w) {
+					return true
+				}
+			}
+		}
+	}
+	for _, tok := range strings.Fields(text) {
+		if f.tokens[latinForm(tok, false)] {
+			return true
+		}
+	}
+	return false
+}
+
+// 라틴 금칙어 뒤에 붙어도 같은 단어로 보는 굴절 어미(축약 후 형태)
+var inflections = []string{"", "s", "es", "ed", "er", "ers", "ing", "in", "y"}
+
+func isInflection(word, stem string) bool {
+	if !strings.HasPrefix(word, stem) {
+		return false
+	}
+	for _, suf := range inflections {
+		if word[len(stem):] == suf {
+			return true
+		}
+	}
+	return false
+}
+
+type forms struct {
+	hangul   string // 자모 분해 후 음절 시작(자음+모음) 앞에 '.'
+	abbrev   string // 낱자모만, 음절/라틴 문자가 끼면 '|' 로 끊음
+	latin    string // leetspeak 치환 + 반복 축약
+	onlyJamo bool
+}
+
+// 공백으로 나눈 단어별 형태를 '|' 로 이어 붙인다(단어를 넘는 오탐 방지)
+func textForms(text string) forms {
+	var out forms
+	var h, a []string
+	for _, g := range joinSingles(strings.Fields(text)) {
+		fs := formsOf(g)
+		h = append(h, fs.hangul)
+		a = append(a, fs.abbrev)
+	}
+	out.hangul = strings.Join(h, "|")
+	out.abbrev = strings.Join(a, "|")
+	return out
+}
+
+// 라틴 비교용 단어(축약 형태). 글자와 leetspeak 기호가 아닌 것('-', '.', '_' 등)에서도 끊는다
+func latinWords(text string) []string {
+	words := strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !isLatinish(r) })
+	var out []string
+	for _, w := range joinSingles(words) {
+		if l := latinForm(w, true); l != "" {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// 한 글자짜리 단어가 연달아 나오면 띄어 쓰기 회피("시 발", "f u c k")로 보고 붙인다
+func joinSingles(words []string) []string {
+	var groups []string
+	var run strings.Builder
+	for _, w := range words {
+		if letterCount(w) <= 1 {
+			run.WriteString(w)
+			continue
+		}
+		if run.Len() > 0 {
+			groups = append(groups, run.String())
+			run.Reset()
+		}
+		groups = append(groups, w)
+	}
+	if run.Len() > 0 {
+		groups = append(groups, run.String())
+	}
+	return groups
+}
+
+func letterCount(w string) int {
+	n := 0
+	for _, r := range w {
+		if unicode.IsLetter(r) {
+			n++
+		}
+	}
+	return n
+}
+
+func formsOf(s string) forms {
+	var jamo []rune
+	var a strings.Builder
+	onlyJamo := true
+	var walk func(r rune)
+	walk = func(r rune) {
+		switch {
+		case r >= 0xAC00 && r <= 0xD7A3:
+			idx := r - 0xAC00
+			jamo = append(jamo, choseong[idx/(21*28)], 0x314F+(idx%(21*28))/28)
+			if t := idx % 28; t > 0 {
+				jamo = append(jamo, jongseong[t-1])
+			}
+			a.WriteByte('|')
+			onlyJamo = false
+		case r >= 0x3131 && r <= 0x318E:
+			jamo = append(jamo, r)
+			a.WriteRune(r)
+		case r >= 0x1100 && r <= 0x11FF:
+			if c := conjoiningToCompat(r); c != 0 {
+				jamo = append(jamo, c)
+				a.WriteRune(c)
+			}
+		case r < 0x80:
+			if isLatinish(r) {
+				a.WriteByte('|')
+				onlyJamo = false
+			}
+		default:
+			// 전각 문자, 반각 자모, 발음 부호 등은 호환 분해 후 다시 분류
+			if d := norm.NFKD.String(string(r)); d != string(r) {
+				for _, x := range d {
+					walk(x)
+				}
+			}
+		}
+	}
+	for _, r := range s {
+		walk(r)
+	}
+
+	// 낱자모로 쳐도 음절로 쓴 것과 같은 형태가 되도록 "자음+모음" 앞에서 음절을 끊는다
+	var h strings.Builder
+	for i, r := range jamo {
+		if isConsonant(r) && i+1 < len(jamo) && isVowel(jamo[i+1]) {
+			h.WriteByte('.')
+		}
+		h.WriteRune(r)
+	}
+	return forms{hangul: h.String(), abbrev: a.String(), latin: latinForm(s, true), onlyJamo: onlyJamo}
+}
+
+func isConsonant(r rune) bool { return r >= 0x3131 && r <= 0x314E }
+func isVowel(r rune) bool     { return r >= 0x314F && r <= 0x3163 }
+
+var leet = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b', '9': 'g',
+	'@': 'a', '$': 's', '!': 'i', '|': 'l', '+': 't',
+}
+
+func isLatinish(r rune) bool {
+	_, ok := leet[r]
+	return ok || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// 라틴 문자만 남긴 소문자 형태. collapse 면 같은 글자 반복을 하나로
+func latinForm(s string, collapse bool) string {
+	var b strings.Builder
+	var prev rune
+	for _, r := range norm.NFKD.String(s) {
+		if r >= 'A' && r <= 'Z' {
+			r += 'a' - 'A'
+		}
+		if m, ok := leet[r]; ok {
+			r = m
+		}
+		if r < 'a' || r > 'z' {
+			continue
+		}
+		if collapse && r == prev {
+			continue
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
+
+var (
+	choseong  = []rune("ㄱㄲㄴㄷㄸㄹㅁㅂㅃㅅㅆㅇㅈㅉㅊㅋㅌㅍㅎ")
+	jongseong = []rune("ㄱㄲㄳㄴㄵㄶㄷㄹㄺㄻㄼㄽㄾㄿㅀㅁㅂㅄㅅㅆㅇㅈㅊㅋㅌㅍㅎ")
+)
+
+// 첫가끝(조합형) 자모를 호환 자모로
+func conjoiningToCompat(r rune) rune {
+	switch {
+	case r >= 0x1100 && r <= 0x1112:
+		return choseong[r-0x1100]
+	case r >= 0x1161 && r <= 0x1175:
+		return 0x314F + (r - 0x1161)
+	case r >= 0x11A8 && r <= 0x11C2:
+		return jongseong[r-0x11A8]
+	}
+	return 0
+}
@@ -0,0 +1,97 @@
+package moderation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheck_Blocks(t *testing.T) {
+	f := Default()
+	for _, s := range []string{
+		"시발",
+		"씨1발",  // 숫자 끼워 넣기
+		"시.발.", // 기호 끼워 넣기
+		"ㅅㅣ발",  // 낱자모로 치기
+		"시 발",  // 한 글자씩 띄어 쓰기
+		"이 병신아",
+		"ㅅㅂ 뭐야",
+		"ｆｕｃｋ", // 전각
+		"FuUuCK off",
+		"sh1t",
+		"a$$",
+		"f u c k",
+		"what a b!tch",
+		"좆같네",
+		"fucking",
+		"shitty day",
+		"fuck-off",
+		"f.u.c.k",
+		"you b1tches",
+		"motherfuckers", // 합성어는 목록에 따로 둔다
+	} {
+		if err := f.Check("nickname", s); err == nil {
+			t.Errorf("%q: expected to be blocked", s)
+		}
+	}
+}
+
+func TestCheck_Allows(t *testing.T) {
+	f := Default()
+	for _, s := range []string{
+		"",
+		"안녕하세요",
+		"조정 작업 담당", // ㅈㅗ + ㅈ 은 '좆' 이 아님
+		"갑시다",      // 받침 ㅂ + ㅅ 은 약어 'ㅂㅅ' 아님
+		"시발점 근처 카페",
+		"다시 발견했다",
+		"class assistant",
+		"this hit song",
+		"Software Engineer",
+		"Scuba diving instructor",
+		"Scunthorpe", // 라틴 금칙어는 단어 속에서 찾지 않는다
+		"shiitake farmer",
+		"Matsushita",
+		"flame retardant engineer",
+		"졸라맨",
+	} {
+		if err := f.Check("detail", s); err != nil {
+			t.Errorf("%q: unexpected block: %v", s, err)
+		}
+	}
+}
+
+func TestCheck_ErrorNamesField(t *testing.T) {
+	err := Default().Check("job.detail", "병신")
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected *ValidationError, got %T", err)
+	}
+	if ve.Field != "job.detail" || ve.Code != "profanity" {
+		t.Fatalf("unexpected error: %+v", ve)
+	}
+}
+
+func TestParse_CustomList(t *testing.T) {
+	f, err := Parse(strings.NewReader("# custom\nbanana\n=kiwi\n!bananaboat\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Check("x", "b4n4n4") == nil {
+		t.Error("leetspeak variant should match")
+	}
+	if f.Check("x", "bananaboat") != nil {
+		t.Error("allowlisted phrase should pass")
+	}
+	if f.Check("x", "kiwi") == nil || f.Check("x", "kiwifruit") != nil {
+		t.Error("'=' entries must match whole words only")
+	}
+	if f.Check("x", "시발") != nil {
+		t.Error("custom list replaces the built-in one")
+	}
+
+	var nilFilter *Filter
+	if nilFilter.Check("x", "fuck") != nil {
+		t.Error("nil filter must allow everything")
+	}
+}
@@ -0,0 +1,203 @@
+package nickname
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+)
+
+// 길이는 grapheme cluster(사용자가 보는 글자) 기준
+const (
+	MinLength = 2
+	MaxLength = 16
+)
+
+var (
+	ErrLength     = fmt.Errorf("nickname must be %d-%d characters", MinLength, MaxLength)
+	ErrCharacters = errors.New("nickname may only contain Hangul, Latin letters, digits and . _ - (not at the start/end or repeated)")
+	ErrReserved   = errors.New("nickname is reserved")
+	ErrTaken      = errors.New("nickname already taken")
+)
+
+// 정확히 일치하면 사용 불가(구분자 제거 후 비교)
+var reserved = map[string]bool{
+	"admin": true, "administrator": true, "root": true, "system": true, "support": true,
+	"help": true, "official": true, "staff": true, "moderator": true, "mod": true,
+	"null": true, "undefined": true, "me": true, "anonymous": true, "unknown": true,
+	"운영자": true, "관리자": true, "고객센터": true, "공식": true, "운영팀": true, "탈퇴회원": true,
+}
+
+// 포함만 해도 사용 불가(운영진 사칭 방지)
+var reservedParts = []string{"admin", "amigo", "운영자", "관리자", "운영팀"}
+
+// 표시용 정규형: NFKC(전각/반각 통일, 한글 자모 조합) + 앞뒤 공백 제거
+func Normalize(s string) string {
+	return strings.TrimSpace(norm.NFKC.String(s))
+}
+
+// 유일성 비교 키(app_users.nickname_key): 정규형의 소문자
+func Key(s string) string {
+	return strings.ToLower(Normalize(s))
+}
+
+// 규칙 검사 후 저장할 표시용 닉네임 반환
+func Validate(s string) (string, error) {
+	n := Normalize(s)
+	if l := uniseg.GraphemeClusterCount(n); l < MinLength || l > MaxLength {
+		return n, ErrLength
+	}
+	prevSep := true // 첫 글자 구분자 금지
+	for _, r := range n {
+		switch {
+		case isSeparator(r):
+			if prevSep {
+				return n, ErrCharacters
+			}
+			prevSep = true
+		case allowedRune(r):
+			prevSep = false
+		default:
+			return n, ErrCharacters
+		}
+	}
+	if prevSep {
+		return n, ErrCharacters
+	}
+	if IsReserved(n) {
+		return n, ErrReserved
+	}
+	return n, nil
+}
+
+func IsReserved(s string) bool {
+	k := strings.Map(func(r rune) rune {
+		if isSeparator(r) {
+			return -1
+		}
+		return r
+	}, Key(s))
+	if reserved[k] {
+		return true
+	}
+	for _, p := range reservedParts {
+		if strings.Contains(k, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// 가용성 조회 응답의 사유 코드
+func Reason(err error) string {
+	switch {
+	case errors.Is(err, ErrLength):
+		return "length"
+	case errors.Is(err, ErrCharacters):
+		return "characters"
+	case errors.Is(err, ErrReserved):
+		return "reserved"
+	case errors.Is(err, ErrTaken):
+		return "taken"
+	}
+	return ""
+}
+
+func isSeparator(r rune) bool { return r == '.' || r == '_' || r == '-' }
+
+func allowedRune(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	case r >= 0xAC00 && r <= 0xD7A3: // 한글 음절
+		return true
+	}
+	return false
+}
+
+// exceptUID 본인이 쓰고 있는 닉네임은 사용 가능으로 본다
+func Available(ctx context.Context, q db.Querier, key, exceptUID string) (bool, error) {
+	var taken bool
+	err := q.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM app_users WHERE nickname_key=$1 AND id::text <> $2)`, key, exceptUID).Scan(&taken)
+	return !taken, err
+}
+
+// base 를 바탕으로 규칙을 통과하고 아직 쓰이지 않은 대안 닉네임을 최대 n개 생성
+func Suggest(ctx context.Context, q db.Querier, base string, n int) ([]string, error) {
+	stem := sanitize(base)
+	if stem == "" || IsReserved(stem) {
+		stem = "friend"
+	}
+
+	seen := map[string]bool{}
+	var cands, keys []string
+	for i := 0; len(cands) < n*4 && i < n*10; i++ {
+		var c string
+		switch i % 3 {
+		case 0:
+			c = fmt.Sprintf("%s%d", stem, 10+rand.IntN(90))
+		case 1:
+			c = fmt.Sprintf("%s_%d", stem, 100+rand.IntN(900))
+		default:
+			c = fmt.Sprintf("%s%d", stem, 1000+rand.IntN(9000))
+		}
+		v, err := Validate(c)
+		if err != nil || seen[Key(v)] {
+			continue
+		}
+		seen[Key(v)] = true
+		cands = append(cands, v)
+		keys = append(keys, Key(v))
+	}
+
+	rows, err := q.Query(ctx, `SELECT nickname_key FROM app_users WHERE nickname_key = ANY($1)`, keys)
+	if err != nil {
+		return nil, err
+	}
+	taken := map[string]bool{}
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		taken[k] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out := []string{}
+	for i, c := range cands {
+		if len(out) == n {
+			break
+		}
+		if !taken[keys[i]] {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// 허용 문자만 남기고 접미 숫자가 들어갈 자리를 남겨 자른다
+func sanitize(s string) string {
+	var b strings.Builder
+	for _, r := range Normalize(s) {
+		if allowedRune(r) {
+			b.WriteRune(r)
+		}
+	}
+	out := b.String()
+	if rs := []rune(out); len(rs) > MaxLength-5 {
+		out = string(rs[:MaxLength-5])
+	}
+	return out
+}
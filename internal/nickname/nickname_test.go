@@ -0,0 +1,63 @@
+package nickname
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+		err  error
+	}{
+		{"amigo_fan", "", ErrReserved},
+		{"하늘", "하늘", nil},
+		{"  Sky.Walker  ", "Sky.Walker", nil},
+		{"ｓｋｙ１２", "sky12", nil},                             // 전각 → 반각
+		{"\u1112\u1161\u11ab\u1100\u1173\u11af", "한글", nil}, // 첫가끝 자모 → 음절 2글자
+		{"가나다라마바사아자차카타파하가나", "가나다라마바사아자차카타파하가나", nil},
+		{"가나다라마바사아자차카타파하가나다", "", ErrLength},
+		{"x", "", ErrLength},
+		{"a-b-c", "a-b-c", nil},
+		{"a--b", "", ErrCharacters},
+		{".ab", "", ErrCharacters},
+		{"ab_", "", ErrCharacters},
+		{"aㅋ", "", ErrCharacters}, // 낱자모 불가
+		{"Ad.Min", "", ErrReserved},
+		{"ROOT", "", ErrReserved},
+	}
+	for _, tc := range cases {
+		got, err := Validate(tc.in)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%q: want err %v, got %v", tc.in, tc.err, err)
+			continue
+		}
+		if err == nil && got != tc.want {
+			t.Errorf("%q: want %q, got %q", tc.in, tc.want, got)
+		}
+	}
+}
+
+// 대소문자/전각·반각만 다른 닉네임은 같은 키
+func TestKey(t *testing.T) {
+	if Key("Ｓｋｙ") != Key("sky") || Key("SKY") != "sky" {
+		t.Fatalf("keys differ: %q %q %q", Key("Ｓｋｙ"), Key("sky"), Key("SKY"))
+	}
+	if Key("하늘") == Key("하늘1") {
+		t.Fatal("distinct nicknames must not share a key")
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	if got := sanitize("hello world!"); got != "helloworld" {
+		t.Fatalf("got %q", got)
+	}
+	if got := sanitize("abcdefghijklmnopqrstuvwxyz"); len([]rune(got)) != MaxLength-5 {
+		t.Fatalf("expected truncation to %d, got %q", MaxLength-5, got)
+	}
+	// 가장 긴 접미사(_999)를 붙여도 최대 길이 안
+	if _, err := Validate(sanitize("abcdefghijklmnopqrstuvwxyz") + "_999"); err != nil {
+		t.Fatalf("suggestion stem too long: %v", err)
+	}
+}
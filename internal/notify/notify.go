@@ -0,0 +1,21 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+)
+
+// 사용자 알림 종류
+const (
+	KindFaceApproved = "face_review.approved"
+	KindFaceRejected = "face_review.rejected"
+)
+
+// user_notification 에 알림 적재. 호출 측 트랜잭션 안에서 함께 커밋되도록 Querier 를 받는다
+func Send(ctx context.Context, q db.Querier, uid, kind string, payload any) error {
+	_, err := q.Exec(ctx, `
+		INSERT INTO user_notification (user_id, kind, payload, created_at)
+		VALUES ($1, $2, $3, now())`, uid, kind, payload)
+	return err
+}
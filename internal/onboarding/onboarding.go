@@ -0,0 +1,72 @@
+// Package onboarding 은 가입 뒤 채워야 하는 프로필 단계와 모두 마쳤을 때의 보상이다
+package onboarding
+
+import (
+	"context"
+	"time"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+	"github.com/creators-of-happiness/amigo-backend/internal/inventory"
+)
+
+// 단계(프론트가 보여 주는 순서)
+var Steps = []string{"nickname", "gender", "birthdate", "region", "job", "avatar", "photo"}
+
+// 단계별 완료 여부, 최근 사진 심사 상태(photo_review_status), 모두 마쳤는지(completed)
+func State(ctx context.Context, q db.Querier, uid string) (map[string]any, error) {
+	var nickname *string
+	var gender *string
+	var birth *time.Time
+	var regionID *int
+	var profileImg *string
+
+	_ = q.QueryRow(ctx, `SELECT nickname FROM app_users WHERE id=$1`, uid).Scan(&nickname)
+	_ = q.QueryRow(ctx, `
+		SELECT gender, birth_date, region_id, ma.url
+		FROM user_profile up
+		LEFT JOIN media_asset ma ON ma.id = up.profile_image_id
+		WHERE up.user_id=$1`, uid).Scan(&gender, &birth, &regionID, &profileImg)
+
+	var catCode *string
+	var charID, bgID *string
+	_ = q.QueryRow(ctx, `
+		SELECT category_code, character_id, bg_id FROM user_avatar WHERE user_id=$1`, uid).
+		Scan(&catCode, &charID, &bgID)
+
+	var jobCat *string
+	_ = q.QueryRow(ctx, `SELECT category FROM user_job WHERE user_id=$1`, uid).Scan(&jobCat)
+
+	// 심사 대기 중인 사진도 온보딩 단계는 완료로 본다(반려되면 다시 올려야 함)
+	var photoReview *string
+	_ = q.QueryRow(ctx, `
+		SELECT status FROM user_face_upload WHERE user_id=$1
+		ORDER BY created_at DESC LIMIT 1`, uid).Scan(&photoReview)
+
+	state := map[string]any{
+		"nickname":  nickname != nil,
+		"gender":    gender != nil,
+		"birthdate": birth != nil,
+		"region":    regionID != nil,
+		"job":       jobCat != nil,
+		"avatar":    (charID != nil && bgID != nil),
+		"photo":     profileImg != nil || (photoReview != nil && *photoReview == "pending_review"),
+
+		"photo_review_status": photoReview,
+	}
+	done := true
+	for _, step := range Steps {
+		done = done && state[step].(bool)
+	}
+	state["completed"] = done
+	return state, nil
+}
+
+// 단계를 채우는 쓰기 뒤에 부른다. 모두 마쳤으면 온보딩 보상 항목을 지급한다(이미 가진 것은 건너뜀)
+func Reward(ctx context.Context, q db.Querier, uid string) error {
+	state, err := State(ctx, q, uid)
+	if err != nil || !state["completed"].(bool) {
+		return err
+	}
+	_, err = inventory.GrantOnboarding(ctx, q, uid)
+	return err
+}
@@ -0,0 +1,149 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+)
+
+// 공개 범위
+const (
+	Everyone = "everyone"
+	Friends  = "friends"
+	Nobody   = "nobody"
+)
+
+// 공개 범위를 정할 수 있는 프로필 필드
+var Fields = []string{"birthdate", "region", "job", "photo"}
+
+// 공개 범위와 관계없이 타인에게 보이는 응답 키
+var public = []string{"id", "nickname", "gender", "bio", "prompts", "avatar"}
+
+// 필드별로 공개 범위에 따라 보이는 응답 키. 생년월일은 타인에게 나이대로만 보인다
+var keys = map[string][]string{
+	"birthdate": {"age_range"},
+	"region":    {"region"},
+	"job":       {"job"},
+	"photo":     {"photo", "photos"},
+}
+
+// 본인에게만 보이는 키. 타인 응답에는 null 로 둔다
+var selfOnly = []string{"birthdate", "age", "korean_age"}
+
+type Settings struct {
+	Birthdate string `json:"birthdate" binding:"omitempty,oneof=everyone friends nobody"`
+	Region    string `json:"region" binding:"omitempty,oneof=everyone friends nobody"`
+	Job       string `json:"job" binding:"omitempty,oneof=everyone friends nobody"`
+	Photo     string `json:"photo" binding:"omitempty,oneof=everyone friends nobody"`
+}
+
+// 설정하지 않은 사용자의 기본값(user_privacy 컬럼 기본값과 같아야 함)
+func Defaults() Settings {
+	return Settings{Birthdate: Friends, Region: Everyone, Job: Everyone, Photo: Everyone}
+}
+
+func (s Settings) level(field string) string {
+	switch field {
+	case "birthdate":
+		return s.Birthdate
+	case "region":
+		return s.Region
+	case "job":
+		return s.Job
+	case "photo":
+		return s.Photo
+	}
+	return Nobody
+}
+
+// 보는 사람과 프로필 주인의 관계
+type Relation int
+
+const (
+	Stranger Relation = iota
+	Friend
+	Self
+)
+
+// 관계에 따라 필드를 볼 수 있는지. 모르는 범위 값은 비공개로 취급
+func Visible(level string, rel Relation) bool {
+	switch {
+	case rel == Self:
+		return true
+	case level == Everyone:
+		return true
+	case level == Friends:
+		return rel == Friend
+	}
+	return false
+}
+
+func Load(ctx context.Context, q db.Querier, uid string) (Settings, error) {
+	s := Defaults()
+	err := q.QueryRow(ctx, `SELECT birthdate, region, job, photo FROM user_privacy WHERE user_id=$1`, uid).
+		Scan(&s.Birthdate, &s.Region, &s.Job, &s.Photo)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Defaults(), nil
+	}
+	return s, err
+}
+
+func RelationOf(ctx context.Context, q db.Querier, ownerID, viewerID string) (Relation, error) {
+	if ownerID == viewerID {
+		return Self, nil
+	}
+	var ok bool
+	err := q.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM user_friend WHERE user_id=$1 AND friend_id=$2)`, ownerID, viewerID).Scan(&ok)
+	if err != nil {
+		return Stranger, err
+	}
+	if ok {
+		return Friend, nil
+	}
+	return Stranger, nil
+}
+
+// 타인에게 보여 줄 응답을 허용 목록으로 새로 만든다. 목록에 없는 키는 버리고,
+// 볼 수 없는 필드의 키는 null 로 둬 응답 모양을 본인 조회와 맞춘다
+func View(profile map[string]any, s Settings, rel Relation) map[string]any {
+	if rel == Self {
+		return profile
+	}
+	out := make(map[string]any, len(public)+len(selfOnly)+len(keys))
+	for _, k := range public {
+		out[k] = profile[k]
+	}
+	for _, k := range selfOnly {
+		out[k] = nil
+	}
+	for _, f := range Fields {
+		show := Visible(s.level(f), rel)
+		for _, k := range keys[f] {
+			out[k] = nil
+			if show {
+				out[k] = profile[k]
+			}
+		}
+	}
+	return out
+}
+
+// 다른 사용자의 데이터를 내려보내는 모든 응답은 이 함수를 거친다.
+// 주인 설정과 관계를 조회해 보는 사람에게 허용된 필드만 담은 응답을 돌려준다
+func Apply(ctx context.Context, q db.Querier, ownerID, viewerID string, profile map[string]any) (map[string]any, error) {
+	rel, err := RelationOf(ctx, q, ownerID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	if rel == Self {
+		return profile, nil
+	}
+	s, err := Load(ctx, q, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	return View(profile, s, rel), nil
+}
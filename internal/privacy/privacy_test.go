@@ -0,0 +1,62 @@
+package privacy
+
+import "testing"
+
+func TestVisible(t *testing.T) {
+	cases := []struct {
+		level string
+		rel   Relation
+		want  bool
+	}{
+		{Everyone, Stranger, true},
+		{Friends, Stranger, false},
+		{Friends, Friend, true},
+		{Nobody, Friend, false},
+		{Nobody, Self, true},
+		{"", Stranger, false}, // 모르는 값은 비공개
+	}
+	for _, tc := range cases {
+		if got := Visible(tc.level, tc.rel); got != tc.want {
+			t.Errorf("Visible(%q, %d) = %v, want %v", tc.level, tc.rel, got, tc.want)
+		}
+	}
+}
+
+func TestView(t *testing.T) {
+	p := map[string]any{
+		"nickname":  "sky",
+		"birthdate": "1995-05-05",
+		"age":       29,
+		"age_range": "25-29",
+		"region":    map[string]any{"id": 1},
+		"job":       map[string]any{"category": "dev"},
+		"photo":     map[string]any{"url": "x"},
+		"phone":     "+82 10-0000-0000",
+	}
+	v := View(p, Settings{Birthdate: Friends, Region: Nobody, Job: Everyone, Photo: Friends}, Stranger)
+	if v["birthdate"] != nil || v["age"] != nil || v["age_range"] != nil || v["region"] != nil || v["photo"] != nil {
+		t.Fatalf("hidden fields leaked: %v", v)
+	}
+	if v["job"] == nil || v["nickname"] != "sky" {
+		t.Fatalf("public fields removed: %v", v)
+	}
+	// 허용 목록에 없는 키는 공개 범위와 관계없이 빠진다
+	if _, ok := v["phone"]; ok {
+		t.Fatalf("unlisted key leaked: %v", v)
+	}
+	if _, ok := v["region"]; !ok {
+		t.Fatalf("hidden field must stay as null: %v", v)
+	}
+	if View(p, Defaults(), Self)["phone"] == nil {
+		t.Fatal("owner sees the profile unchanged")
+	}
+}
+
+// 친구에게도 정확한 생년월일은 보이지 않고 나이대만
+func TestView_FriendSeesAgeRangeOnly(t *testing.T) {
+	p := map[string]any{"birthdate": "1995-05-05", "age": 29, "age_range": "25-29"}
+	v := View(p, Defaults(), Friend)
+	if v["birthdate"] != nil || v["age"] != nil || v["age_range"] != "25-29" {
+		t.Fatalf("unexpected: %v", v)
+	}
+}
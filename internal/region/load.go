@@ -0,0 +1,170 @@
+package region
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+)
+
+// 일괄 적재 CSV 한 줄. 머리글: code,name,parent_code[,latitude,longitude]
+// parent_code 가 비면 시/도. 부모는 같은 파일이나 이미 적재된 지역이어야 한다
+type Row struct {
+	Line       int
+	Code       string
+	Name       string
+	ParentCode string
+	Latitude   *float64
+	Longitude  *float64
+}
+
+// CSV 를 읽어 부모가 자식보다 먼저 오도록 정렬해 돌려준다
+func ParseCSV(r io.Reader) ([]Row, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	col := map[string]int{}
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+	}
+	for _, req := range []string{"code", "name"} {
+		if _, ok := col[req]; !ok {
+			return nil, fmt.Errorf("header: missing column %q", req)
+		}
+	}
+	field := func(rec []string, name string) string {
+		i, ok := col[name]
+		if !ok || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+	coord := func(rec []string, name string, line int) (*float64, error) {
+		s := field(rec, name)
+		if s == "" {
+			return nil, nil
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid %s %q", line, name, s)
+		}
+		return &v, nil
+	}
+
+	var rows []Row
+	byCode := map[string]int{}
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := Row{Line: line, Code: field(rec, "code"), Name: field(rec, "name"), ParentCode: field(rec, "parent_code")}
+		if row.Code == "" || row.Name == "" {
+			return nil, fmt.Errorf("line %d: code and name are required", line)
+		}
+		if _, dup := byCode[row.Code]; dup {
+			return nil, fmt.Errorf("line %d: duplicate code %s", line, row.Code)
+		}
+		if row.ParentCode == row.Code {
+			return nil, fmt.Errorf("line %d: region cannot be its own parent", line)
+		}
+		if row.Latitude, err = coord(rec, "latitude", line); err != nil {
+			return nil, err
+		}
+		if row.Longitude, err = coord(rec, "longitude", line); err != nil {
+			return nil, err
+		}
+		if (row.Latitude == nil) != (row.Longitude == nil) {
+			return nil, fmt.Errorf("line %d: latitude and longitude must be given together", line)
+		}
+		byCode[row.Code] = len(rows)
+		rows = append(rows, row)
+	}
+
+	// 파일 안에서의 깊이(부모가 파일 밖이면 0). 순환이면 오류
+	depth := make([]int, len(rows))
+	state := make([]int, len(rows)) // 0=미방문 1=방문중 2=완료
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case 1:
+			return fmt.Errorf("line %d: parent cycle at code %s", rows[i].Line, rows[i].Code)
+		case 2:
+			return nil
+		}
+		state[i] = 1
+		if p, ok := byCode[rows[i].ParentCode]; ok {
+			if err := visit(p); err != nil {
+				return err
+			}
+			depth[i] = depth[p] + 1
+		}
+		state[i] = 2
+		return nil
+	}
+	for i := range rows {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	idx := make([]int, len(rows))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return depth[idx[a]] < depth[idx[b]] })
+	out := make([]Row, len(rows))
+	for i, j := range idx {
+		out[i] = rows[j]
+	}
+	return out, nil
+}
+
+// ParseCSV 결과를 code 기준으로 upsert. 단계는 부모 단계+1 로 정해진다.
+// 한 트랜잭션 안에서 호출할 것(중간에 실패하면 전체 취소)
+func Load(ctx context.Context, q db.Querier, rows []Row) (int, error) {
+	for _, row := range rows {
+		var parentID *int
+		level := LevelProvince
+		if row.ParentCode != "" {
+			var id, plevel int
+			err := q.QueryRow(ctx, `SELECT id, level FROM region WHERE code=$1`, row.ParentCode).Scan(&id, &plevel)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return 0, fmt.Errorf("line %d: unknown parent code %s", row.Line, row.ParentCode)
+			}
+			if err != nil {
+				return 0, err
+			}
+			parentID, level = &id, plevel+1
+		}
+		if level > MaxLevel {
+			return 0, fmt.Errorf("line %d: %s is deeper than %d levels", row.Line, row.Code, MaxLevel)
+		}
+		_, err := q.Exec(ctx, `
+			INSERT INTO region (code, name, parent_id, level, latitude, longitude)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (code) DO UPDATE SET
+			  name=EXCLUDED.name, parent_id=EXCLUDED.parent_id, level=EXCLUDED.level,
+			  latitude=COALESCE(EXCLUDED.latitude, region.latitude),
+			  longitude=COALESCE(EXCLUDED.longitude, region.longitude)`,
+			row.Code, row.Name, parentID, level, row.Latitude, row.Longitude)
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %w", row.Line, err)
+		}
+	}
+	return len(rows), nil
+}
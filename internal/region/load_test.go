@@ -0,0 +1,56 @@
+package region
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseCSV_ParentsFirst(t *testing.T) {
+	f, err := os.Open("testdata/regions.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := ParseCSV(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("got %d rows", len(rows))
+	}
+	seen := map[string]bool{}
+	for _, r := range rows {
+		if r.ParentCode != "" && !seen[r.ParentCode] {
+			t.Fatalf("%s (%s) comes before its parent %s", r.Code, r.Name, r.ParentCode)
+		}
+		seen[r.Code] = true
+	}
+	if rows[0].Line != 4 || rows[0].Latitude == nil || *rows[0].Latitude != 37.5665 {
+		t.Fatalf("unexpected first row: %+v", rows[0])
+	}
+}
+
+func TestParseCSV_Errors(t *testing.T) {
+	cases := map[string]string{
+		"missing column": "code,parent_code\n11,\n",
+		"empty name":     "code,name\n11,\n",
+		"duplicate":      "code,name\n11,a\n11,b\n",
+		"bad latitude":   "code,name,latitude,longitude\n11,a,x,1\n",
+		"half coords":    "code,name,latitude\n11,a,37\n",
+		"cycle":          "code,name,parent_code\n1,a,2\n2,b,1\n",
+		"self parent":    "code,name,parent_code\n1,a,1\n",
+	}
+	for name, in := range cases {
+		if _, err := ParseCSV(strings.NewReader(in)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestFullName(t *testing.T) {
+	path := []Region{{Name: "서울특별시"}, {Name: "강남구"}, {Name: "역삼동"}}
+	if got := FullName(path); got != "서울특별시 강남구 역삼동" {
+		t.Fatalf("got %q", got)
+	}
+}
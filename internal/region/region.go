@@ -0,0 +1,251 @@
+package region
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+	"github.com/creators-of-happiness/amigo-backend/internal/geo"
+	"github.com/creators-of-happiness/amigo-backend/internal/i18n"
+)
+
+// 행정구역 단계
+const (
+	LevelProvince = 1 // 시/도
+	LevelDistrict = 2 // 시/군/구
+	LevelTown     = 3 // 읍/면/동
+	MaxLevel      = LevelTown
+)
+
+var ErrNotFound = errors.New("region not found")
+
+type Region struct {
+	ID        int      `json:"id"`
+	Code      *string  `json:"code"`
+	Name      string   `json:"name"`
+	ParentID  *int     `json:"parent_id"`
+	Level     int      `json:"level"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+// 목록 조회용. 하위 지역이 있으면 더 들어갈 수 있다
+type Node struct {
+	Region
+	HasChildren bool `json:"has_children"`
+}
+
+const columns = `id, code, name, parent_id, level, latitude::float8, longitude::float8`
+
+func scan(row pgx.Row, r *Region) error {
+	return row.Scan(&r.ID, &r.Code, &r.Name, &r.ParentID, &r.Level, &r.Latitude, &r.Longitude)
+}
+
+func Get(ctx context.Context, q db.Querier, id int) (Region, error) {
+	var r Region
+	err := scan(q.QueryRow(ctx, `SELECT `+columns+` FROM region WHERE id=$1`, id), &r)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return r, ErrNotFound
+	}
+	return r, err
+}
+
+// 관리자가 숨기지 않은(새로 고를 수 있는) 지역인지
+func IsActive(ctx context.Context, q db.Querier, id int) (bool, error) {
+	var active bool
+	err := q.QueryRow(ctx, `SELECT active FROM region WHERE id=$1`, id).Scan(&active)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, ErrNotFound
+	}
+	return active, err
+}
+
+// 보이는 하위 지역이 없는 지역(사용자가 고를 수 있는 단위)인지
+func IsLeaf(ctx context.Context, q db.Querier, id int) (bool, error) {
+	var leaf bool
+	err := q.QueryRow(ctx, `SELECT NOT EXISTS (SELECT 1 FROM region WHERE parent_id=$1 AND active)`, id).Scan(&leaf)
+	return leaf, err
+}
+
+// parentID 의 보이는 하위 지역(nil 이면 시/도). 이름순
+func Children(ctx context.Context, q db.Querier, parentID *int) ([]Node, error) {
+	rows, err := q.Query(ctx, `
+		SELECT `+columns+`, EXISTS (SELECT 1 FROM region ch WHERE ch.parent_id = region.id AND ch.active)
+		FROM region
+		WHERE parent_id IS NOT DISTINCT FROM $1 AND active
+		ORDER BY name, id`, parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Node{}
+	for rows.Next() {
+		var n Node
+		if err := rows.Scan(&n.ID, &n.Code, &n.Name, &n.ParentID, &n.Level, &n.Latitude, &n.Longitude, &n.HasChildren); err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+// 시/도부터 id 까지의 경로
+func Path(ctx context.Context, q db.Querier, id int) ([]Region, error) {
+	rows, err := q.Query(ctx, `
+		WITH RECURSIVE up AS (
+			SELECT `+columns+`, 0 AS depth FROM region WHERE id=$1
+			UNION ALL
+			SELECT r.id, r.code, r.name, r.parent_id, r.level, r.latitude::float8, r.longitude::float8, up.depth + 1
+			FROM region r JOIN up ON r.id = up.parent_id
+		)
+		SELECT `+columns+` FROM up ORDER BY depth DESC`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Region{}
+	for rows.Next() {
+		var r Region
+		if err := scan(rows, &r); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, ErrNotFound
+	}
+	return out, nil
+}
+
+// "서울특별시 강남구 역삼동"
+func FullName(path []Region) string {
+	names := make([]string, len(path))
+	for i, r := range path {
+		names[i] = r.Name
+	}
+	return strings.Join(names, " ")
+}
+
+// 반경 검색 결과
+type Nearby struct {
+	Region
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// region 좌표와 ($1,$2) 사이 haversine 거리(km) SQL 식
+const distanceKm = `2 * 6371.0088 * asin(least(1, sqrt(
+	power(sin(radians(latitude::float8 - $1) / 2), 2) +
+	cos(radians($1)) * cos(radians(latitude::float8)) * power(sin(radians(longitude::float8 - $2) / 2), 2))))`
+
+// 좌표가 있는 최하위 지역 중 중심에서 km 안에 있는 곳을 가까운 순으로.
+// 위경도 사각형으로 idx_region_lat_lng 를 먼저 타고 거리로 다시 거른다
+func Within(ctx context.Context, q db.Querier, lat, lng, km float64, limit int) ([]Nearby, error) {
+	minLat, maxLat, minLng, maxLng := geo.BoundingBox(lat, lng, km)
+	rows, err := q.Query(ctx, `
+		SELECT * FROM (
+			SELECT `+columns+`, `+distanceKm+` AS km
+			FROM region
+			WHERE latitude BETWEEN $4 AND $5 AND longitude BETWEEN $6 AND $7 AND active
+			  AND NOT EXISTS (SELECT 1 FROM region ch WHERE ch.parent_id = region.id AND ch.active)
+		) r
+		WHERE km <= $3
+		ORDER BY km, id
+		LIMIT $8`, lat, lng, km, minLat, maxLat, minLng, maxLng, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Nearby{}
+	for rows.Next() {
+		var n Nearby
+		if err := rows.Scan(&n.ID, &n.Code, &n.Name, &n.ParentID, &n.Level, &n.Latitude, &n.Longitude, &n.DistanceKm); err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+// 점점 넓힌 반경 안에서 먼저 찾아본다. 끝까지 없으면 전체 검색
+var nearestRadiiKm = []float64{5, 25, 100}
+
+// 좌표가 있는 최하위 지역 중 가장 가까운 곳. 거리(km)도 함께
+func Nearest(ctx context.Context, q db.Querier, lat, lng float64) (Region, float64, error) {
+	for _, km := range nearestRadiiKm {
+		found, err := Within(ctx, q, lat, lng, km, 1)
+		if err != nil {
+			return Region{}, 0, err
+		}
+		if len(found) > 0 {
+			return found[0].Region, found[0].DistanceKm, nil
+		}
+	}
+	var r Region
+	var km float64
+	err := q.QueryRow(ctx, `
+		SELECT `+columns+`, `+distanceKm+` AS km
+		FROM region
+		WHERE latitude IS NOT NULL AND longitude IS NOT NULL AND active
+		  AND NOT EXISTS (SELECT 1 FROM region ch WHERE ch.parent_id = region.id AND ch.active)
+		ORDER BY km LIMIT 1`, lat, lng).
+		Scan(&r.ID, &r.Code, &r.Name, &r.ParentID, &r.Level, &r.Latitude, &r.Longitude, &km)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return r, 0, ErrNotFound
+	}
+	return r, km, err
+}
+
+// 이름을 locale 번역으로 바꾼다(번역이 없으면 그대로)
+func Localize(ctx context.Context, q db.Querier, locale string, rs []Region) error {
+	ptrs := make([]*Region, len(rs))
+	for i := range rs {
+		ptrs[i] = &rs[i]
+	}
+	return localize(ctx, q, locale, ptrs)
+}
+
+// Localize 후 번역된 이름순으로 다시 정렬
+func LocalizeNodes(ctx context.Context, q db.Querier, locale string, ns []Node) error {
+	ptrs := make([]*Region, len(ns))
+	for i := range ns {
+		ptrs[i] = &ns[i].Region
+	}
+	if err := localize(ctx, q, locale, ptrs); err != nil {
+		return err
+	}
+	sort.SliceStable(ns, func(a, b int) bool { return ns[a].Name < ns[b].Name })
+	return nil
+}
+
+func LocalizeNearby(ctx context.Context, q db.Querier, locale string, ns []Nearby) error {
+	ptrs := make([]*Region, len(ns))
+	for i := range ns {
+		ptrs[i] = &ns[i].Region
+	}
+	return localize(ctx, q, locale, ptrs)
+}
+
+func localize(ctx context.Context, q db.Querier, locale string, rs []*Region) error {
+	keys := make([]string, len(rs))
+	for i, r := range rs {
+		keys[i] = strconv.Itoa(r.ID)
+	}
+	names, err := i18n.Lookup(ctx, q, i18n.Region, locale, keys)
+	if err != nil {
+		return err
+	}
+	for i, r := range rs {
+		if n, ok := names[keys[i]]; ok {
+			r.Name = n
+		}
+	}
+	return nil
+}
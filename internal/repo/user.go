@@ -4,7 +4,12 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/history"
+	"github.com/creators-of-happiness/amigo-backend/internal/nickname"
+	"github.com/creators-of-happiness/amigo-backend/internal/util"
 )
 
 type User struct {
@@ -13,17 +18,39 @@ type User struct {
 	Nickname *string
 }
 
-func FindOrCreateUser(ctx context.Context, pool *pgxpool.Pool, phone, nickname string) (*User, error) {
-	// DB가 UUID 기본값을 생성하며, phone UNIQUE에 대해 UPSERT 수행
+func FindOrCreateUser(ctx context.Context, pool *pgxpool.Pool, phone, nick string) (*User, error) {
+	// phone UNIQUE에 대해 UPSERT 수행.
+	// 기존 닉네임이 있으면 유지하고, 비어 있던 경우에만 입력값으로 채운다.
+	// 닉네임을 채우면 변경 쿨다운도 시작된다
+	var key string
+	if nick != "" {
+		key = nickname.Key(nick)
+	}
 	var u User
-	err := pool.QueryRow(ctx, `
-		INSERT INTO app_users (phone, nickname, created_at, updated_at)
-		VALUES ($1, NULLIF($2,''), now(), now())
-		ON CONFLICT (phone) DO UPDATE
-		  SET nickname = COALESCE(EXCLUDED.nickname, app_users.nickname),
-		      updated_at = now()
-		RETURNING id, phone, nickname
-	`, phone, nickname).Scan(&u.ID, &u.Phone, &u.Nickname)
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		// 이력의 행위자는 본인: 기존 사용자면 그 id, 새 사용자면 미리 만든 id
+		var id string
+		if err := tx.QueryRow(ctx, `SELECT COALESCE((SELECT id FROM app_users WHERE phone=$1), gen_random_uuid())::text`, phone).Scan(&id); err != nil {
+			return err
+		}
+		if err := history.SetActor(ctx, tx, history.Actor{ID: id, Source: history.SourceAuth}); err != nil {
+			return err
+		}
+		return tx.QueryRow(ctx, `
+			INSERT INTO app_users (id, phone, nickname, nickname_key, nickname_changed_at, created_at, updated_at)
+			VALUES ($4, $1, NULLIF($2,''), NULLIF($3,''), CASE WHEN $2 <> '' THEN now() END, now(), now())
+			ON CONFLICT (phone) DO UPDATE
+			  SET nickname = COALESCE(app_users.nickname, EXCLUDED.nickname),
+			      nickname_key = COALESCE(app_users.nickname_key, EXCLUDED.nickname_key),
+			      nickname_changed_at = CASE WHEN app_users.nickname IS NULL AND EXCLUDED.nickname IS NOT NULL
+			                                 THEN now() ELSE app_users.nickname_changed_at END,
+			      updated_at = now()
+			RETURNING id, phone, nickname
+		`, phone, nick, key, id).Scan(&u.ID, &u.Phone, &u.Nickname)
+	})
+	if util.IsUniqueViolation(err, "uq_app_users_nickname_key") || util.IsUniqueViolation(err, "app_users_nickname_key") {
+		return nil, nickname.ErrTaken
+	}
 	if err != nil {
 		return nil, fmt.Errorf("create/find user: %w", err)
 	}
@@ -0,0 +1,24 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// 2단계 업로드용 서명 URL 파라미터(HMAC-SHA256). 토큰 없이도 업로드 ID+만료시각으로 검증 가능
+func SignUpload(secret, uploadID string, exp time.Time) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "upload:%s:%d", uploadID, exp.Unix())
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func VerifyUpload(secret, uploadID string, exp time.Time, sig string) bool {
+	if time.Now().After(exp) {
+		return false
+	}
+	want := SignUpload(secret, uploadID, exp)
+	return hmac.Equal([]byte(want), []byte(sig))
+}
@@ -1,13 +1,15 @@
 package util
 
-import "github.com/jackc/pgconn"
+import (
+	"errors"
 
-// 스키마 제약 위반/캐스팅 오류 등을 4xx로 분류
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// 스키마 제약 위반/캐스팅 오류 등을 4xx로 분류(pgx v5 오류, 감싼 것 포함)
 func IsClientInputError(err error) bool {
-	if err == nil {
-		return false
-	}
-	if pg, ok := err.(*pgconn.PgError); ok {
+	var pg *pgconn.PgError
+	if errors.As(err, &pg) {
 		switch pg.Code {
 		case "23503", // foreign_key_violation
 			"23514", // check_violation
@@ -22,7 +24,8 @@ func IsClientInputError(err error) bool {
 
 // Optionally map pg errors to HTTP + app code
 func PGToHTTP(err error) (status int, code string) {
-	if pg, ok := err.(*pgconn.PgError); ok {
+	var pg *pgconn.PgError
+	if errors.As(err, &pg) {
 		switch pg.Code {
 		case "23505":
 			return 409, "CONFLICT"
@@ -32,3 +35,18 @@ func PGToHTTP(err error) (status int, code string) {
 	}
 	return 500, "INTERNAL"
 }
+
+// pgx v5 가 돌려주는 unique_violation(23505). constraint 가 비어 있지 않으면 제약/인덱스 이름까지 비교
+func IsUniqueViolation(err error, constraint string) bool {
+	var pg *pgconn.PgError
+	if !errors.As(err, &pg) || pg.Code != "23505" {
+		return false
+	}
+	return constraint == "" || pg.ConstraintName == constraint
+}
+
+// pgx v5 가 돌려주는 check_violation(23514)
+func IsCheckViolation(err error) bool {
+	var pg *pgconn.PgError
+	return errors.As(err, &pg) && pg.Code == "23514"
+}
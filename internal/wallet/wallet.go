@@ -0,0 +1,428 @@
+// Package wallet 은 사용자 코인 지갑이다. 잔액은 wallet_account 에 두고,
+// 모든 변동은 거래(wallet_txn)와 합이 0 인 분개(wallet_entry)로 원장에 추가만 한다
+package wallet
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	pgconnv5 "github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/creators-of-happiness/amigo-backend/internal/catalog"
+	"github.com/creators-of-happiness/amigo-backend/internal/db"
+	"github.com/creators-of-happiness/amigo-backend/internal/inventory"
+)
+
+// wallet_txn.kind
+const (
+	KindPurchase = "purchase" // 항목 구매(사용자 → revenue)
+	KindGrant    = "grant"    // 관리자 지급(issuance → 사용자)
+	KindRefund   = "refund"   // 구매 환불(revenue → 사용자), 항목은 회수
+)
+
+// 시스템 계정(wallet_account.code). 사용자 거래의 상대 계정이다
+const (
+	accountIssuance = "issuance"
+	accountRevenue  = "revenue"
+)
+
+var (
+	ErrInsufficientFunds = errors.New("insufficient balance")
+	ErrNotForSale        = errors.New("item is not for sale")
+	ErrAlreadyOwned      = errors.New("item already owned")
+	ErrRequestReused     = errors.New("request_id was already used for a different request")
+	ErrNotFound          = errors.New("transaction not found")
+	ErrNotRefundable     = errors.New("only purchases can be refunded")
+)
+
+// 사용자에게 보이는 거래. Amount 는 사용자 잔액 변동(구매는 음수)
+type Txn struct {
+	ID           int64     `json:"id"`
+	Kind         string    `json:"kind"`
+	Amount       int64     `json:"amount"`
+	BalanceAfter int64     `json:"balance_after"`
+	RequestID    *string   `json:"request_id"`
+	Entity       *string   `json:"entity"`
+	ItemID       *string   `json:"item_id"`
+	RefundOf     *int64    `json:"refund_of"`
+	Reason       *string   `json:"reason"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// 잔액. 지갑을 쓴 적이 없으면 0
+func Balance(ctx context.Context, q db.Querier, uid string) (int64, error) {
+	var b int64
+	err := q.QueryRow(ctx, `SELECT COALESCE((SELECT balance FROM wallet_account WHERE user_id = $1), 0)`, uid).Scan(&b)
+	return b, err
+}
+
+const txnCols = `t.id, t.kind, e.amount, e.balance_after, t.request_id, t.entity, t.item_id::text, t.refund_of, t.reason, t.created_at`
+
+// 사용자 계정 분개와 함께(탈퇴로 user_id 가 비었으면 거래 기록만 남고 여기서는 보이지 않는다)
+const txnFrom = `
+	FROM wallet_txn t
+	JOIN wallet_account a ON a.user_id = t.user_id
+	JOIN wallet_entry e ON e.txn_id = t.id AND e.account_id = a.id`
+
+func scanTxn(row pgx.Row) (Txn, error) {
+	var t Txn
+	err := row.Scan(&t.ID, &t.Kind, &t.Amount, &t.BalanceAfter, &t.RequestID, &t.Entity, &t.ItemID, &t.RefundOf, &t.Reason, &t.CreatedAt)
+	return t, err
+}
+
+// 최신순 거래. beforeID>0 이면 그보다 오래된 것만(페이지 넘김)
+func List(ctx context.Context, q db.Querier, uid string, beforeID int64, limit int) ([]Txn, error) {
+	rows, err := q.Query(ctx, `SELECT `+txnCols+txnFrom+`
+		WHERE t.user_id = $1 AND ($2 = 0 OR t.id < $2)
+		ORDER BY t.id DESC LIMIT $3`, uid, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Txn{}
+	for rows.Next() {
+		t, err := scanTxn(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// request_id 는 거래 종류마다 따로다(사용자 구매와 관리자 지급이 같은 id 를 써도 섞이지 않는다)
+func byRequest(ctx context.Context, q db.Querier, uid, kind, requestID string) (Txn, error) {
+	return scanTxn(q.QueryRow(ctx, `SELECT `+txnCols+txnFrom+` WHERE t.user_id = $1 AND t.kind = $2 AND t.request_id = $3`, uid, kind, requestID))
+}
+
+// 항목을 산다. 같은 request_id 로 다시 부르면 처음 결과를 돌려주고 created=false.
+// 팔지 않는 항목(가격 없음, 숨김, 노출 기간 밖)이나 이미 가진 항목은 살 수 없다
+func Purchase(ctx context.Context, pool *pgxpool.Pool, uid, requestID, entity, itemID string) (Txn, bool, error) {
+	if !slices.Contains(inventory.Entities, entity) {
+		return Txn{}, false, inventory.ErrUnknownEntity
+	}
+	// 저장된 item_id(uuid::text)는 소문자라 재시도 비교가 대소문자에 흔들리지 않도록 맞춘다
+	itemID = strings.ToLower(itemID)
+	var t Txn
+	created := false
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		// 같은 request_id 로 이미 처리된 구매가 있으면 그 거래를 돌려준다
+		replayed := func() (bool, error) {
+			prev, err := byRequest(ctx, tx, uid, KindPurchase, requestID)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return false, nil
+			}
+			if err != nil {
+				return false, err
+			}
+			if !prev.sameItem(entity, itemID) {
+				return false, ErrRequestReused
+			}
+			t = prev
+			return true, nil
+		}
+		if done, err := replayed(); done || err != nil {
+			return err
+		}
+
+		var price *int64
+		var ok bool
+		err := tx.QueryRow(ctx, `SELECT i.price, i.active AND `+catalog.Available("i")+`
+			FROM `+entity+` i WHERE i.id = $1::uuid FOR SHARE`, itemID).Scan(&price, &ok)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return inventory.ErrUnknownItem
+		}
+		if err != nil {
+			return err
+		}
+		if price == nil || !ok {
+			return ErrNotForSale
+		}
+		// 계정 행을 잠근 뒤에 보유를 확인해 같은 사용자의 구매를 차례로 처리한다
+		acct, err := account(ctx, tx, uid)
+		if err != nil {
+			return err
+		}
+		// 같은 요청이 동시에 들어와 잠금을 기다리는 사이 다른 쪽이 커밋했을 수 있다
+		if done, err := replayed(); done || err != nil {
+			return err
+		}
+		var owned bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM user_inventory WHERE user_id = $1 AND entity = $2 AND item_id = $3::uuid)`,
+			uid, entity, itemID).Scan(&owned); err != nil {
+			return err
+		}
+		if owned {
+			return ErrAlreadyOwned
+		}
+		t, err = post(ctx, tx, acct, accountRevenue, uid, Txn{Kind: KindPurchase, Amount: -*price,
+			RequestID: &requestID, Entity: &entity, ItemID: &itemID}, "")
+		if err != nil {
+			return err
+		}
+		given, err := inventory.Give(ctx, tx, inventory.Grant{UserID: uid, Entity: entity, ItemID: itemID,
+			Source: inventory.SourcePurchase, SourceRef: strconv.FormatInt(t.ID, 10)})
+		if err != nil {
+			return err
+		}
+		if !given {
+			return ErrAlreadyOwned
+		}
+		created = true
+		return nil
+	})
+	// 같은 request_id 가 동시에 들어와 다른 쪽이 먼저 커밋했다
+	var pe *pgconnv5.PgError
+	if errors.As(err, &pe) && pe.ConstraintName == "uq_wallet_txn_request" {
+		prev, err := byRequest(ctx, pool, uid, KindPurchase, requestID)
+		if err != nil {
+			return Txn{}, false, err
+		}
+		if !prev.sameItem(entity, itemID) {
+			return Txn{}, false, ErrRequestReused
+		}
+		return prev, false, nil
+	}
+	if err != nil {
+		return Txn{}, false, err
+	}
+	return t, created, nil
+}
+
+func (t Txn) sameItem(entity, itemID string) bool {
+	return t.Kind == KindPurchase && t.Entity != nil && *t.Entity == entity && t.ItemID != nil && *t.ItemID == itemID
+}
+
+// 관리자 지급. requestID 를 주면 같은 요청을 한 번만 처리한다(다시 부르면 처음 결과, created=false)
+func Grant(ctx context.Context, pool *pgxpool.Pool, uid string, amount int64, reason, requestID, actorID string) (Txn, bool, error) {
+	var t Txn
+	created := false
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		if requestID != "" {
+			prev, err := byRequest(ctx, tx, uid, KindGrant, requestID)
+			if err == nil {
+				if prev.Kind != KindGrant || prev.Amount != amount {
+					return ErrRequestReused
+				}
+				t = prev
+				return nil
+			}
+			if !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+		}
+		acct, err := account(ctx, tx, uid)
+		if err != nil {
+			return err
+		}
+		in := Txn{Kind: KindGrant, Amount: amount, Reason: &reason}
+		if requestID != "" {
+			in.RequestID = &requestID
+		}
+		t, err = post(ctx, tx, acct, accountIssuance, uid, in, actorID)
+		created = err == nil
+		return err
+	})
+	var pe *pgconnv5.PgError
+	if errors.As(err, &pe) && pe.ConstraintName == "uq_wallet_txn_request" {
+		prev, err := byRequest(ctx, pool, uid, KindGrant, requestID)
+		if err != nil {
+			return Txn{}, false, err
+		}
+		if prev.Kind != KindGrant || prev.Amount != amount {
+			return Txn{}, false, ErrRequestReused
+		}
+		return prev, false, nil
+	}
+	if err != nil {
+		return Txn{}, false, err
+	}
+	return t, created, nil
+}
+
+// 구매를 환불한다. 값을 돌려주고 산 항목을 회수한다(지금 아바타에 쓰고 있으면 바꿀 때까지 유지).
+// 이미 환불했으면 그 환불을 돌려주고 created=false
+func Refund(ctx context.Context, pool *pgxpool.Pool, uid string, txnID int64, reason, actorID string) (Txn, bool, error) {
+	var t Txn
+	created := false
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		// 원 거래를 잠가 같은 구매의 환불을 차례로 처리한다(행은 바꾸지 않는다)
+		var kind string
+		var amount int64
+		var entity, itemID *string
+		err := tx.QueryRow(ctx, `SELECT kind, amount, entity, item_id::text FROM wallet_txn WHERE id = $1 AND user_id = $2 FOR UPDATE`,
+			txnID, uid).Scan(&kind, &amount, &entity, &itemID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if kind != KindPurchase {
+			return ErrNotRefundable
+		}
+		prev, err := scanTxn(tx.QueryRow(ctx, `SELECT `+txnCols+txnFrom+` WHERE t.refund_of = $1`, txnID))
+		if err == nil {
+			t = prev
+			return nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		acct, err := account(ctx, tx, uid)
+		if err != nil {
+			return err
+		}
+		t, err = post(ctx, tx, acct, accountRevenue, uid, Txn{Kind: KindRefund, Amount: amount,
+			Entity: entity, ItemID: itemID, RefundOf: &txnID, Reason: &reason}, actorID)
+		if err != nil {
+			return err
+		}
+		if _, err := inventory.Revoke(ctx, tx, uid, *entity, *itemID); err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	if err != nil {
+		return Txn{}, false, err
+	}
+	return t, created, nil
+}
+
+// 사용자 계정 id. 없으면 만들고, 어느 쪽이든 행을 잠근다
+func account(ctx context.Context, q db.Querier, uid string) (int64, error) {
+	var id int64
+	err := q.QueryRow(ctx, `
+		INSERT INTO wallet_account (user_id) VALUES ($1)
+		ON CONFLICT (user_id) DO UPDATE SET user_id = EXCLUDED.user_id
+		RETURNING id`, uid).Scan(&id)
+	var pe *pgconnv5.PgError
+	if errors.As(err, &pe) && pe.ConstraintName == "wallet_account_user_id_fkey" {
+		return 0, inventory.ErrUnknownUser
+	}
+	return id, err
+}
+
+// 거래 하나를 원장에 넣는다. 사용자 계정이 t.Amount 만큼, 시스템 계정 counter 가 반대로 바뀐다
+func post(ctx context.Context, q db.Querier, acct int64, counter, uid string, t Txn, actorID string) (Txn, error) {
+	amount := t.Amount
+	if amount < 0 {
+		amount = -amount
+	}
+	err := q.QueryRow(ctx, `
+		INSERT INTO wallet_txn (user_id, kind, amount, request_id, entity, item_id, refund_of, reason, actor_id)
+		VALUES ($1, $2, $3, $4, $5, $6::uuid, $7, $8, NULLIF($9, '')::uuid)
+		RETURNING id, created_at`,
+		uid, t.Kind, amount, t.RequestID, t.Entity, t.ItemID, t.RefundOf, t.Reason, actorID).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		return Txn{}, err
+	}
+	err = q.QueryRow(ctx, `INSERT INTO wallet_entry (txn_id, account_id, amount, balance_after) VALUES ($1, $2, $3, 0) RETURNING balance_after`,
+		t.ID, acct, t.Amount).Scan(&t.BalanceAfter)
+	var pe *pgconnv5.PgError
+	if errors.As(err, &pe) && pe.ConstraintName == "chk_wallet_account_balance" {
+		return Txn{}, ErrInsufficientFunds
+	}
+	if err != nil {
+		return Txn{}, err
+	}
+	_, err = q.Exec(ctx, `
+		INSERT INTO wallet_entry (txn_id, account_id, amount, balance_after)
+		SELECT $1, id, $3, 0 FROM wallet_account WHERE code = $2`, t.ID, counter, -t.Amount)
+	return t, err
+}
+
+// 원장과 맞지 않는 계정. Ledger 는 분개 합
+type Mismatch struct {
+	AccountID int64   `json:"account_id"`
+	UserID    *string `json:"user_id"`
+	Code      *string `json:"code"`
+	Balance   int64   `json:"balance"`
+	Ledger    int64   `json:"ledger"`
+}
+
+// 대사 결과. 문제가 없으면 OK 이고 목록은 비어 있다
+type Report struct {
+	OK         bool       `json:"ok"`
+	Accounts   []Mismatch `json:"accounts"`
+	Unbalanced []int64    `json:"unbalanced_transactions"` // 분개 합이 0 이 아니거나 금액이 맞지 않는 거래
+	Total      int64      `json:"total"`                   // 모든 계정 잔액 합(0 이어야 한다)
+	CheckedAt  time.Time  `json:"checked_at"`
+}
+
+// 대사 목록 상한(종류별)
+const reportLimit = 100
+
+// 잔액과 원장을 맞춰 본다. 진행 중인 거래와 섞이지 않도록 한 스냅샷(REPEATABLE READ)에서 읽는다
+func Reconcile(ctx context.Context, pool *pgxpool.Pool) (Report, error) {
+	var r Report
+	err := pgx.BeginTxFunc(ctx, pool, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		var err error
+		r, err = reconcile(ctx, tx)
+		return err
+	})
+	return r, err
+}
+
+func reconcile(ctx context.Context, q db.Querier) (Report, error) {
+	r := Report{Accounts: []Mismatch{}, Unbalanced: []int64{}}
+	rows, err := q.Query(ctx, `
+		SELECT a.id, a.user_id::text, a.code, a.balance, COALESCE(SUM(e.amount), 0)::bigint
+		FROM wallet_account a
+		LEFT JOIN wallet_entry e ON e.account_id = a.id
+		GROUP BY a.id
+		HAVING a.balance <> COALESCE(SUM(e.amount), 0)
+		ORDER BY a.id LIMIT $1`, reportLimit)
+	if err != nil {
+		return r, err
+	}
+	for rows.Next() {
+		var m Mismatch
+		if err := rows.Scan(&m.AccountID, &m.UserID, &m.Code, &m.Balance, &m.Ledger); err != nil {
+			rows.Close()
+			return r, err
+		}
+		r.Accounts = append(r.Accounts, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return r, err
+	}
+
+	// 거래마다 분개 합은 0 이고, 입금 쪽 합은 거래 금액과 같다
+	rows, err = q.Query(ctx, `
+		SELECT t.id
+		FROM wallet_txn t
+		LEFT JOIN wallet_entry e ON e.txn_id = t.id
+		GROUP BY t.id
+		HAVING COALESCE(SUM(e.amount), 0) <> 0 OR COALESCE(SUM(e.amount) FILTER (WHERE e.amount > 0), 0) <> t.amount
+		ORDER BY t.id LIMIT $1`, reportLimit)
+	if err != nil {
+		return r, err
+	}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return r, err
+		}
+		r.Unbalanced = append(r.Unbalanced, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return r, err
+	}
+
+	if err := q.QueryRow(ctx, `SELECT COALESCE(SUM(balance), 0)::bigint, now() FROM wallet_account`).Scan(&r.Total, &r.CheckedAt); err != nil {
+		return r, err
+	}
+	r.OK = len(r.Accounts) == 0 && len(r.Unbalanced) == 0 && r.Total == 0
+	return r, nil
+}